@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"webhook-ui/common/api"
+	"webhook-ui/common/reload"
+)
+
+// parseQuery 解析 webhook 以 entire-query 传入的查询参数（JSON 对象，值为字符串或字符串数组）
+func parseQuery(data string) (url.Values, error) {
+	values := url.Values{}
+	if data == "" {
+		return values, nil
+	}
+	var query map[string]interface{}
+	if err := json.Unmarshal([]byte(data), &query); err != nil {
+		return nil, err
+	}
+	for key, value := range query {
+		switch v := value.(type) {
+		case []interface{}:
+			for _, item := range v {
+				values.Add(key, fmt.Sprint(item))
+			}
+		default:
+			values.Add(key, fmt.Sprint(v))
+		}
+	}
+	return values, nil
+}
+
+func main() {
+	var method, path string
+	flag.StringVar(&method, "method", "GET", "HTTP method of the API operation")
+	flag.StringVar(&path, "path", "/api/hooks", "Path of the API operation, {id} is replaced by API_ID")
+	flag.Parse()
+	// 本脚本由 webhook 执行，signal 方式重载时父进程就是 webhook
+	reload.ParentIsWebhook = true
+
+	// 把 webhook 传入的参数还原为一个 HTTP 请求，交给与独立服务相同的 api.Handler 处理
+	query, err := parseQuery(os.Getenv("API_QUERY"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing API_QUERY: %v\n", err)
+		query = url.Values{}
+	}
+	path = strings.ReplaceAll(path, "{id}", url.PathEscape(os.Getenv("API_ID")))
+	var body io.Reader = http.NoBody
+	if bodyPath := os.Getenv("API_BODY_PATH"); bodyPath != "" {
+		f, err := os.Open(bodyPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error opening request body %s: %v\n", bodyPath, err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		body = f
+	}
+	req, err := http.NewRequest(method, (&url.URL{Path: path, RawQuery: query.Encode()}).String(), body)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error building request: %v\n", err)
+		os.Exit(1)
+	}
+	if name := os.Getenv("API_FILE_NAME"); name != "" {
+		req.Header.Set("X-File-Name", name)
+	}
+
+	// 响应体写到标准输出，webhook 把它作为 hook 的响应返回
+	var w api.Recorder
+	api.Handler(api.ServiceFromEnv()).ServeHTTP(&w, req)
+	os.Stdout.Write(w.Body.Bytes())
+	// webhook 无法设置状态码，以退出码区分成功和失败，实际状态码在响应体的 error.status 中
+	if w.Status >= http.StatusBadRequest {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,12 @@
+module webhook-ui/api
+
+go 1.24.4
+
+require webhook-ui/common v0.0.0
+
+require (
+	golang.org/x/sys v0.30.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace webhook-ui/common => ../common
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"webhook-ui/common/assets"
+)
+
+func main() {
+	// 页面中的字体等通过 assets?file=<文件> 请求，webhook 把查询参数 file 传入环境变量 ASSET_FILE。
+	// 输出原样返回给浏览器，出错时 webhook 返回 500
+	file := os.Getenv("ASSET_FILE")
+	data, _, err := assets.Open(file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error opening asset %q: %v\n", file, err)
+		os.Exit(1)
+	}
+	os.Stdout.Write(data)
+}
@@ -0,0 +1,9 @@
+module webhook-ui/assets
+
+go 1.22
+
+require webhook-ui/common v0.0.0
+
+require gopkg.in/yaml.v3 v3.0.1 // indirect
+
+replace webhook-ui/common => ../common
@@ -0,0 +1,157 @@
+// Package adminhooks 生成管理页面自身使用的 hook（ui、detail、edit_form、save、hook-*、upload_form、upload-*、api-*），
+// 并检查对 hooks 文件的修改是否会删除或改动这些 hook。config/hooks.yaml 就是按默认安装目录生成的结果
+package adminhooks
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"text/template"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/i18n"
+)
+
+// DefaultScriptsDir 是 config/hooks.yaml 中脚本的安装目录
+const DefaultScriptsDir = "/etc/webhook/scripts"
+
+//go:embed hooks.yaml.tmpl
+var hooksTemplate string
+
+var tmpl = template.Must(template.New("hooks.yaml").Parse(hooksTemplate))
+
+// Options 是生成管理 hook 的参数
+type Options struct {
+	ScriptsDir string // 脚本安装目录，每个脚本位于 <ScriptsDir>/<脚本>/<脚本>，默认 DefaultScriptsDir
+	// URLPrefix 不为 nil 时为每个 hook 设置 URL_PREFIX 环境变量（与 webhook 的 -urlprefix 相同，可以为空），
+	// 为 nil 时脚本沿用 webhook 进程的 URL_PREFIX 环境变量
+	URLPrefix *string
+	Format    config.Format // 输出格式，默认 YAML
+}
+
+// Generate 返回管理 hook 的配置片段，可以直接作为 hooks 文件或追加到已有的 YAML hooks 文件末尾
+func Generate(opts Options) ([]byte, error) {
+	dir := strings.TrimRight(opts.ScriptsDir, "/")
+	if dir == "" {
+		dir = DefaultScriptsDir
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, struct{ ScriptsDir string }{dir}); err != nil {
+		return nil, err
+	}
+	if opts.URLPrefix == nil && opts.Format != config.FormatJSON {
+		return buf.Bytes(), nil
+	}
+
+	doc, err := config.ParseDocument(buf.Bytes(), config.FormatYAML)
+	if err != nil {
+		return nil, err
+	}
+	if opts.URLPrefix != nil {
+		prefix := strings.Trim(*opts.URLPrefix, "/")
+		for _, id := range doc.IDs() {
+			if err := doc.SetEnv(id, "URL_PREFIX", prefix); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if opts.Format == config.FormatJSON {
+		doc.SetFormat(config.FormatJSON)
+	}
+	return doc.Bytes()
+}
+
+// IDs 返回所有管理 hook 的 id，顺序与生成的配置相同
+var IDs = sync.OnceValue(func() []string {
+	data, err := Generate(Options{})
+	if err != nil {
+		panic(fmt.Sprintf("adminhooks: invalid template: %v", err))
+	}
+	doc, err := config.ParseDocument(data, config.FormatYAML)
+	if err != nil {
+		panic(fmt.Sprintf("adminhooks: invalid template: %v", err))
+	}
+	return doc.IDs()
+})
+
+// Confirmation 是删除或修改管理 hook 时需要输入的确认文字：页面在确认页的表单字段 allow_admin_changes 中提交，
+// API 在查询参数 allow_admin_changes 中提交
+const Confirmation = "修改管理 hook"
+
+// Confirmed 返回提交的文字是否与 Confirmation 或它在任一页面语言中的译文一致
+func Confirmed(value string) bool {
+	value = strings.TrimSpace(value)
+	for _, lang := range i18n.Languages {
+		if value == i18n.For(lang).T(Confirmation) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsAdmin 返回 id 是否是管理 hook
+func IsAdmin(id string) bool {
+	for _, admin := range IDs() {
+		if admin == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Protected 返回 c 中受保护的 hook：IDs 中的管理 hook，以及 execute-command 位于脚本安装目录 scriptsDir 下的 hook
+// （改名或自行添加的管理页面入口同样受保护），顺序与 c 相同
+func Protected(c config.Config, scriptsDir string) []string {
+	var ids []string
+	for _, hook := range c {
+		if IsAdmin(hook.ID) || underDir(hook.ExecuteCommand, scriptsDir) {
+			ids = append(ids, hook.ID)
+		}
+	}
+	return ids
+}
+
+// underDir 返回 command 是否是 dir 下的文件，相对路径不判断
+func underDir(command, dir string) bool {
+	if dir == "" || !filepath.IsAbs(command) {
+		return false
+	}
+	rel, err := filepath.Rel(dir, filepath.Clean(command))
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, "../")
+}
+
+// Check 比较修改前后 webhook 加载的完整配置（所有 hooks 文件合并后），返回被删除或改动的受保护 hook（见 Protected）的说明。
+// 修改前就不存在的管理 hook 不检查，因此只部署了部分脚本的环境不受影响
+func Check(before, after config.Config, scriptsDir string) []i18n.Message {
+	var problems []i18n.Message
+	for _, id := range Protected(before, scriptsDir) {
+		old := before.Find(id)
+		current := after.Find(id)
+		if current == nil {
+			problems = append(problems, i18n.M("%s: 被删除或停用", id))
+			continue
+		}
+		if fields := changedFields(*old, *current); len(fields) > 0 {
+			problems = append(problems, i18n.M("%s: 修改了 %s", id, strings.Join(fields, "、")))
+		}
+	}
+	return problems
+}
+
+// changedFields 返回两个 hook 中值不同的字段，使用配置文件中的键名
+func changedFields(a, b config.Hook) []string {
+	var fields []string
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	t := va.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if !reflect.DeepEqual(va.Field(i).Interface(), vb.Field(i).Interface()) {
+			name, _, _ := strings.Cut(t.Field(i).Tag.Get("yaml"), ",")
+			fields = append(fields, name)
+		}
+	}
+	return fields
+}
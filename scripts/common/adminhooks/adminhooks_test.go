@@ -0,0 +1,156 @@
+package adminhooks
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/i18n"
+)
+
+// update 为 true 时用模板的生成结果覆盖 config/hooks.yaml：修改 hooks.yaml.tmpl 后执行 go test ./adminhooks -update
+var update = flag.Bool("update", false, "rewrite config/hooks.yaml from hooks.yaml.tmpl")
+
+// configHooks 是仓库中按默认安装目录生成的 hooks 文件
+var configHooks = filepath.Join("..", "..", "..", "config", "hooks.yaml")
+
+func TestGenerateMatchesConfig(t *testing.T) {
+	generated, err := Generate(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *update {
+		if err := os.WriteFile(configHooks, generated, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(configHooks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(generated, want) {
+		t.Errorf("%s 与 hooks.yaml.tmpl 的生成结果不同，修改模板后执行 go test ./adminhooks -update 重新生成", configHooks)
+	}
+}
+
+func TestGenerateOptions(t *testing.T) {
+	prefix := "/admin/"
+	data, err := Generate(Options{ScriptsDir: "/opt/scripts/", URLPrefix: &prefix, Format: config.FormatJSON})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks, err := config.ParseFormat(data, config.FormatJSON)
+	if err != nil {
+		t.Fatalf("生成的 JSON 无法解析: %v", err)
+	}
+	if len(hooks) != len(IDs()) {
+		t.Fatalf("生成了 %d 个 hook，IDs 中有 %d 个", len(hooks), len(IDs()))
+	}
+	for _, hook := range hooks {
+		if !strings.HasPrefix(hook.ExecuteCommand, "/opt/scripts/") {
+			t.Errorf("%s 的 execute-command 为 %s，不在指定的安装目录下", hook.ID, hook.ExecuteCommand)
+		}
+		found := false
+		for _, arg := range hook.PassEnvironmentToCommand {
+			if arg.EnvName == "URL_PREFIX" && arg.Source == "string" && arg.Name == "admin" {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("%s 没有设置 URL_PREFIX=admin", hook.ID)
+		}
+	}
+	if strings.Contains(string(data), DefaultScriptsDir) {
+		t.Errorf("指定安装目录后仍包含 %s", DefaultScriptsDir)
+	}
+}
+
+// adminConfig 返回默认生成的管理 hook 加上一个普通 hook
+func adminConfig(t *testing.T) config.Config {
+	t.Helper()
+	data, err := Generate(Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hooks, err := config.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return append(hooks, config.Hook{ID: "deploy", ExecuteCommand: "/opt/deploy.sh"})
+}
+
+// without 返回去掉 id 后的副本
+func without(c config.Config, id string) config.Config {
+	var out config.Config
+	for _, hook := range c {
+		if hook.ID != id {
+			out = append(out, hook)
+		}
+	}
+	return out
+}
+
+func TestCheck(t *testing.T) {
+	before := adminConfig(t)
+	messages := func(problems []i18n.Message) string {
+		var lines []string
+		for _, p := range problems {
+			lines = append(lines, p.String())
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	if problems := Check(before, before, DefaultScriptsDir); len(problems) != 0 {
+		t.Errorf("未修改时报告了 %s", messages(problems))
+	}
+	// 普通 hook 的删除和修改不受限制
+	if problems := Check(before, without(before, "deploy"), DefaultScriptsDir); len(problems) != 0 {
+		t.Errorf("删除普通 hook 时报告了 %s", messages(problems))
+	}
+
+	if got := messages(Check(before, without(before, "save"), DefaultScriptsDir)); got != "save: 被删除或停用" {
+		t.Errorf("删除 save 时报告 %q", got)
+	}
+
+	changed := append(config.Config{}, before...)
+	for i := range changed {
+		if changed[i].ID == "ui" {
+			changed[i].ExecuteCommand = "/tmp/ui"
+			changed[i].ResponseMessage = "changed"
+		}
+	}
+	if got := messages(Check(before, changed, DefaultScriptsDir)); got != "ui: 修改了 execute-command、response-message" {
+		t.Errorf("修改 ui 时报告 %q", got)
+	}
+
+	// 改名或自行添加的入口：execute-command 位于安装目录下的 hook 同样受保护
+	custom := append(before, config.Hook{ID: "my-ui", ExecuteCommand: DefaultScriptsDir + "/ui/ui"})
+	if got := messages(Check(custom, without(custom, "my-ui"), DefaultScriptsDir)); got != "my-ui: 被删除或停用" {
+		t.Errorf("删除安装目录下的 hook 时报告 %q", got)
+	}
+
+	// 修改前就不存在的管理 hook 不检查
+	partial := without(before, "api-save")
+	if problems := Check(partial, partial, DefaultScriptsDir); len(problems) != 0 {
+		t.Errorf("部分部署时报告了 %s", messages(problems))
+	}
+}
+
+func TestConfirmed(t *testing.T) {
+	cases := map[string]bool{
+		Confirmation:                   true,
+		" " + Confirmation + "\n":      true,
+		i18n.For("en").T(Confirmation): true,
+		"":                             false,
+		"yes":                          false,
+	}
+	for value, want := range cases {
+		if got := Confirmed(value); got != want {
+			t.Errorf("Confirmed(%q) = %v，应为 %v", value, got, want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package adminhooks
+
+import (
+	"os"
+	"path/filepath"
+	"time"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/env"
+	"webhook-ui/common/i18n"
+)
+
+// entryIDs 是打开管理页面并保存配置所需的最少 hook
+var entryIDs = []string{"ui", "edit_form", "save"}
+
+// ScriptsDir 返回脚本的安装目录：环境变量 SCRIPTS_DIR，默认按当前程序的位置推断。
+// 脚本安装为 <目录>/<脚本>/<脚本>，程序不在这样的位置时（如 go run）使用 DefaultScriptsDir
+func ScriptsDir() string {
+	if dir := os.Getenv("SCRIPTS_DIR"); dir != "" {
+		return filepath.Clean(dir)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return DefaultScriptsDir
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	dir := filepath.Dir(exe)
+	if filepath.Base(dir) != filepath.Base(exe) {
+		return DefaultScriptsDir
+	}
+	return filepath.Dir(dir)
+}
+
+// Reachable 返回配置中是否有打开管理页面并保存配置所需的 hook（ui、edit_form、save）
+func Reachable(c config.Config) bool {
+	for _, id := range entryIDs {
+		if c.Find(id) == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// RecoveryPath 返回恢复文件：环境变量 ADMIN_RECOVERY_FILE，默认为 hooks 文件所在目录下的 .webhook-admin-recovery.yaml。
+// ADMIN_RECOVERY_FILE 设置为空时不写入
+func RecoveryPath(hooksPath string) string {
+	return env.Str("ADMIN_RECOVERY_FILE", filepath.Join(filepath.Dir(hooksPath), ".webhook-admin-recovery.yaml"))
+}
+
+// WriteRecovery 把 c 中受保护的 hook（见 Protected）原子写入恢复文件 path。
+// 恢复文件本身是合法的 hooks 文件，不依赖管理页面即可用 webhookctl restore-admin-hooks 或手工恢复
+func WriteRecovery(path string, c config.Config, scriptsDir string) error {
+	doc, err := config.ParseDocument(nil, config.FormatYAML)
+	if err != nil {
+		return err
+	}
+	for _, id := range Protected(c, scriptsDir) {
+		if err := doc.Add(*c.Find(id), ""); err != nil {
+			return err
+		}
+	}
+	body, err := doc.Bytes()
+	if err != nil {
+		return err
+	}
+	header := "# " + i18n.M("管理 hook 的恢复文件，保存配置时自动写入（%s）。", time.Now().Format(time.RFC3339)).String() + "\n" +
+		"# " + i18n.M("无法打开管理页面时在服务器上执行 webhookctl restore-admin-hooks，").String() + "\n" +
+		"# " + i18n.M("或把以下 hook 追加到 hooks 文件末尾（先删除同 id 的 hook）后让 webhook 重新加载。").String() + "\n"
+	data := append([]byte(header), body...)
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Restore 把恢复文件中的 hook 写回 doc：缺少的追加到末尾，内容不同的替换为恢复文件中的版本，返回改动的 hook id
+func Restore(doc *config.Document, recovered config.Config) ([]string, error) {
+	var restored []string
+	for _, hook := range recovered {
+		current, err := doc.Get(hook.ID)
+		if err != nil {
+			err = doc.Add(hook, "")
+		} else if len(changedFields(*current, hook)) > 0 {
+			err = doc.Update(hook.ID, hook)
+		} else {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		restored = append(restored, hook.ID)
+	}
+	return restored, nil
+}
@@ -0,0 +1,421 @@
+// Package api 是供自动化调用的 JSON API：列出和查看 hook、校验和保存配置、列出和上传文件。
+// 与页面使用相同的 config、pipeline、upload 包，每个操作返回状态码和 JSON 响应体，
+// 由 Handler 提供给 net/http，或由 api 脚本在 webhook 的 execute-command 中调用
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"webhook-ui/common/adminhooks"
+	"webhook-ui/common/config"
+	"webhook-ui/common/env"
+	"webhook-ui/common/history"
+	"webhook-ui/common/i18n"
+	"webhook-ui/common/pipeline"
+	"webhook-ui/common/upload"
+)
+
+// Error 是结构化的错误信息，Code 供程序判断，Message 供人阅读
+type Error struct {
+	Status  int      `json:"status"`
+	Code    string   `json:"code"`
+	Message string   `json:"message"`
+	Details []string `json:"details,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if len(e.Details) == 0 {
+		return e.Message
+	}
+	return e.Message + ":\n  " + strings.Join(e.Details, "\n  ")
+}
+
+// ErrorBody 是错误响应的 JSON 结构：{"error": {...}}
+type ErrorBody struct {
+	Error *Error `json:"error"`
+}
+
+// Response 是一次 API 调用的结果
+type Response struct {
+	Status int
+	Body   interface{}
+}
+
+func fail(status int, code, message string, details ...string) Response {
+	return Response{Status: status, Body: ErrorBody{Error: &Error{Status: status, Code: code, Message: message, Details: details}}}
+}
+
+// Service 提供所有 API 操作
+type Service struct {
+	Hooks     string // hooks 文件，与 HOOKS 环境变量写法相同
+	UploadDir string // 上传目录
+
+	// Load 不为空时代替 config.LoadAll(Hooks) 读取 hooks 文件，独立服务用它共用 config.Cache
+	Load func() ([]config.Source, error)
+	// HistoryFile 是独立服务转发请求时写入的执行记录文件，为空时按 history.Path 取第一个 hooks 文件旁的默认位置
+	HistoryFile string
+}
+
+// ServiceFromEnv 按页面脚本使用的环境变量创建 Service
+func ServiceFromEnv() Service {
+	return Service{
+		Hooks:     config.HooksSpec(),
+		UploadDir: env.Str("UPLOAD_DEST_DIR", "/etc/webhook/scripts/upload_destination/"),
+	}
+}
+
+func (s Service) load() ([]config.Source, *Response) {
+	load := s.Load
+	if load == nil {
+		load = func() ([]config.Source, error) { return config.LoadAll(s.Hooks) }
+	}
+	sources, err := load()
+	if err != nil {
+		r := fail(http.StatusInternalServerError, "hooks_unresolved", err.Error())
+		return nil, &r
+	}
+	return sources, nil
+}
+
+// ListHooks 返回 webhook 加载的完整配置（所有文件合并后的 hook 列表），file 不为空时只返回该文件中的 hook
+func (s Service) ListHooks(file string) Response {
+	sources, errResp := s.load()
+	if errResp != nil {
+		return *errResp
+	}
+	if file != "" && !config.Contains(sources, file) {
+		return fail(http.StatusNotFound, "file_not_found", i18n.M("%s 不是 HOOKS 中配置的 hooks 文件", file).String())
+	}
+	var details []string
+	hooks := config.Config{}
+	for _, source := range sources {
+		if file != "" && source.Path != filepath.Clean(file) {
+			continue
+		}
+		if source.Err != nil && !source.IsNotExist() {
+			details = append(details, fmt.Sprintf("%s: %v", source.Path, source.Err))
+		}
+		hooks = append(hooks, source.Hooks...)
+	}
+	if len(details) > 0 {
+		return fail(http.StatusInternalServerError, "config_unreadable", i18n.M("无法解析 hooks 文件").String(), details...)
+	}
+	return Response{Status: http.StatusOK, Body: hooks}
+}
+
+// HookDetail 是单个 hook 的详细信息
+type HookDetail struct {
+	File     string               `json:"file"`
+	Revision string               `json:"revision,omitempty"` // 与编辑页相同的版本号，文件格式无法单独编辑时为空
+	Disabled *config.DisabledHook `json:"disabled,omitempty"` // hook 已被停用时的停用信息
+	Hook     config.Hook          `json:"hook"`
+}
+
+// GetHook 返回 id 对应的 hook，包括已停用的 hook
+func (s Service) GetHook(id string) Response {
+	sources, errResp := s.load()
+	if errResp != nil {
+		return *errResp
+	}
+	for _, source := range sources {
+		if hook := source.Hooks.Find(id); hook != nil {
+			detail := HookDetail{File: source.Path, Hook: *hook}
+			data := []byte(source.Raw)
+			if doc, err := config.ParseDocument(data, config.DetectFormat(source.Path, data)); err == nil {
+				detail.Revision, _ = doc.Revision(id)
+			}
+			return Response{Status: http.StatusOK, Body: detail}
+		}
+		if disabled := source.Disabled.Find(id); disabled != nil {
+			return Response{Status: http.StatusOK, Body: HookDetail{File: source.Path, Disabled: disabled, Hook: disabled.Hook}}
+		}
+	}
+	return fail(http.StatusNotFound, "hook_not_found", i18n.M("hook %q 不存在", id).String())
+}
+
+// ValidateResult 是校验通过时的结果
+type ValidateResult struct {
+	Valid  bool          `json:"valid"`
+	File   string        `json:"file"`
+	Format config.Format `json:"format"`
+	Hooks  int           `json:"hooks"`
+}
+
+// target 返回要校验或保存的文件，file 不是 HOOKS 中的文件时返回 400
+func target(sources []config.Source, file string) (string, *Response) {
+	path, err := pipeline.Target(sources, file)
+	if err != nil {
+		r := fail(http.StatusBadRequest, "invalid_file", err.Error())
+		return "", &r
+	}
+	return path, nil
+}
+
+// validationFailed 把校验错误转换为 422 响应
+func validationFailed(verr *pipeline.ValidationError) Response {
+	if verr.Admin {
+		details := append(strings.Split(verr.Detail, "\n"), i18n.M("确认无误时在查询参数 allow_admin_changes 中提交确认文字 %q", adminhooks.Confirmation).String())
+		return fail(http.StatusConflict, "admin_hooks_changed", verr.Title.String(), details...)
+	}
+	return fail(http.StatusUnprocessableEntity, "invalid_config", verr.Title.String(), strings.Split(verr.Detail, "\n")...)
+}
+
+// ValidateConfig 按 save 的规则检查 file（默认第一个 hooks 文件）的新内容，不写入文件。
+// allowAdminChanges 为 false 时同样检查管理 hook 是否被删除或改动
+func (s Service) ValidateConfig(file string, content []byte, allowAdminChanges bool) Response {
+	sources, errResp := s.load()
+	if errResp != nil {
+		return *errResp
+	}
+	path, errResp := target(sources, file)
+	if errResp != nil {
+		return *errResp
+	}
+	rendered, verr := pipeline.Validate(sources, path, content)
+	if verr == nil && !allowAdminChanges {
+		verr = pipeline.CheckAdmin(sources, path, rendered)
+	}
+	if verr != nil {
+		return validationFailed(verr)
+	}
+	format := config.DetectFormat(path, rendered)
+	hooks, _ := config.ParseFormat(rendered, format)
+	return Response{Status: http.StatusOK, Body: ValidateResult{Valid: true, File: path, Format: format, Hooks: len(hooks)}}
+}
+
+// SaveResult 是保存的结果
+type SaveResult struct {
+	File    string `json:"file"`
+	Applied bool   `json:"applied"` // 新配置是否最终保留；重载后未生效并已自动恢复时为 false
+	Title   string `json:"title"`
+	Message string `json:"message"`          // 重载和健康检查结果
+	Backup  string `json:"backup,omitempty"` // 写入前内容的备份版本
+}
+
+// SaveConfig 与 save 脚本相同：校验、备份、原子写入 file（默认第一个 hooks 文件），然后重载并检查新配置是否生效。
+// 删除或改动管理 hook 时返回 409，除非 allowAdminChanges 为 true。新配置未生效并已恢复时返回 502
+func (s Service) SaveConfig(file string, content []byte, allowAdminChanges bool) Response {
+	sources, errResp := s.load()
+	if errResp != nil {
+		return *errResp
+	}
+	path, errResp := target(sources, file)
+	if errResp != nil {
+		return *errResp
+	}
+	result, err := pipeline.CommitOptions(sources, path, func([]byte) ([]byte, error) {
+		return content, nil
+	}, pipeline.Options{AllowAdminChanges: allowAdminChanges})
+	var verr *pipeline.ValidationError
+	if errors.As(err, &verr) {
+		return validationFailed(verr)
+	} else if err != nil {
+		return fail(http.StatusInternalServerError, "write_failed", err.Error())
+	}
+	saved := SaveResult{File: path, Applied: result.OK, Title: result.Title.String(), Message: result.Message, Backup: result.Backup}
+	if !result.OK {
+		return Response{Status: http.StatusBadGateway, Body: struct {
+			ErrorBody
+			SaveResult
+		}{ErrorBody{&Error{Status: http.StatusBadGateway, Code: "reload_failed", Message: result.Title.String()}}, saved}}
+	}
+	return Response{Status: http.StatusOK, Body: saved}
+}
+
+// ConfigContent 是 hooks 文件的当前内容
+type ConfigContent struct {
+	File    string        `json:"file"`
+	Format  config.Format `json:"format"`
+	Exists  bool          `json:"exists"`
+	Content string        `json:"content"` // 模板模式下为模板原文
+}
+
+// GetConfig 返回 file（默认第一个 hooks 文件）的原文，用于比较差异
+func (s Service) GetConfig(file string) Response {
+	sources, errResp := s.load()
+	if errResp != nil {
+		return *errResp
+	}
+	path, errResp := target(sources, file)
+	if errResp != nil {
+		return *errResp
+	}
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fail(http.StatusInternalServerError, "config_unreadable", err.Error())
+	}
+	return Response{Status: http.StatusOK, Body: ConfigContent{File: path, Format: config.DetectFormat(path, data), Exists: err == nil, Content: string(data)}}
+}
+
+// Versions 返回 file（默认第一个 hooks 文件）的历史版本，最新的在前
+func (s Service) Versions(file string) Response {
+	sources, errResp := s.load()
+	if errResp != nil {
+		return *errResp
+	}
+	path, errResp := target(sources, file)
+	if errResp != nil {
+		return *errResp
+	}
+	versions, err := pipeline.Versions(path)
+	if err != nil {
+		return fail(http.StatusInternalServerError, "backup_unreadable", err.Error())
+	}
+	if versions == nil {
+		versions = []pipeline.Version{}
+	}
+	return Response{Status: http.StatusOK, Body: versions}
+}
+
+// History 返回独立服务记录的执行记录，最新的在前。id 不为空时只返回该 hook 的记录，limit 默认 100
+func (s Service) History(id, limit string) Response {
+	n := 100
+	if limit != "" {
+		var err error
+		if n, err = strconv.Atoi(limit); err != nil || n <= 0 {
+			return fail(http.StatusBadRequest, "invalid_limit", i18n.M("limit 应为正整数: %q", limit).String())
+		}
+	}
+	path := s.HistoryFile
+	if path == "" {
+		sources, errResp := s.load()
+		if errResp != nil {
+			return *errResp
+		}
+		if path = history.Path(sources[0].Path); path == "" {
+			return fail(http.StatusNotFound, "history_disabled", i18n.M("未启用执行记录（HISTORY_FILE 为空）").String())
+		}
+	}
+	entries, err := history.Read(path, id, n)
+	if err != nil {
+		return fail(http.StatusInternalServerError, "history_unreadable", err.Error())
+	}
+	if entries == nil {
+		entries = []history.Entry{}
+	}
+	return Response{Status: http.StatusOK, Body: entries}
+}
+
+// Rollback 把 file（默认第一个 hooks 文件）恢复为历史版本 version，流程与保存相同（当前内容同样会被备份）
+func (s Service) Rollback(file, version string, allowAdminChanges bool) Response {
+	sources, errResp := s.load()
+	if errResp != nil {
+		return *errResp
+	}
+	path, errResp := target(sources, file)
+	if errResp != nil {
+		return *errResp
+	}
+	content, err := pipeline.ReadVersion(path, version)
+	if os.IsNotExist(err) {
+		return fail(http.StatusNotFound, "version_not_found", i18n.M("%s 没有版本 %s", path, version).String())
+	} else if err != nil {
+		return fail(http.StatusBadRequest, "invalid_version", err.Error())
+	}
+	return s.SaveConfig(path, content, allowAdminChanges)
+}
+
+// RestoreAdminHooks 把恢复文件（见 adminhooks.RecoveryPath）中的管理 hook 写回 file（默认第一个 hooks 文件）：
+// 缺少的追加到末尾，改动过的替换为恢复文件中的版本，然后与保存相同地重载并检查。
+// 用于管理 hook 被误删或改坏、无法再打开管理页面的情况；管理 hook 与恢复文件一致时不写入
+func (s Service) RestoreAdminHooks(file string) Response {
+	sources, errResp := s.load()
+	if errResp != nil {
+		return *errResp
+	}
+	path, errResp := target(sources, file)
+	if errResp != nil {
+		return *errResp
+	}
+	recovery := adminhooks.RecoveryPath(sources[0].Path)
+	if recovery == "" {
+		return fail(http.StatusNotFound, "recovery_disabled", i18n.M("未启用管理 hook 的恢复文件（ADMIN_RECOVERY_FILE 为空），可用 webhookctl admin-hooks 重新生成管理 hook").String())
+	}
+	data, err := os.ReadFile(recovery)
+	if os.IsNotExist(err) {
+		return fail(http.StatusNotFound, "recovery_not_found", i18n.M("没有恢复文件 %s，可用 webhookctl admin-hooks 重新生成管理 hook", recovery).String())
+	} else if err != nil {
+		return fail(http.StatusInternalServerError, "recovery_unreadable", err.Error())
+	}
+	// 恢复文件中是渲染后的 hook，不再按模板渲染
+	recovered, err := config.Parse(data)
+	if err != nil {
+		return fail(http.StatusInternalServerError, "recovery_unreadable", i18n.M("无法解析 %s: %v", recovery, err).String())
+	}
+
+	current, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fail(http.StatusInternalServerError, "config_unreadable", err.Error())
+	}
+	doc, err := config.ParseDocument(current, config.DetectFormat(path, current))
+	if err != nil {
+		return fail(http.StatusUnprocessableEntity, "invalid_config", i18n.M("无法解析 %s: %v", path, err).String())
+	}
+	restored, err := adminhooks.Restore(doc, recovered)
+	if err != nil {
+		return fail(http.StatusUnprocessableEntity, "invalid_config", err.Error())
+	}
+	if len(restored) == 0 {
+		return Response{Status: http.StatusOK, Body: SaveResult{File: path, Applied: true, Title: i18n.M("管理 hook 与恢复文件一致，无需恢复").String()}}
+	}
+	content, err := doc.Bytes()
+	if err != nil {
+		return fail(http.StatusInternalServerError, "write_failed", err.Error())
+	}
+	resp := s.SaveConfig(path, content, true)
+	if saved, ok := resp.Body.(SaveResult); ok {
+		saved.Message = strings.TrimSpace(i18n.M("已从 %s 恢复 %s", recovery, strings.Join(restored, ", ")).String() + "\n" + saved.Message)
+		resp.Body = saved
+	}
+	return resp
+}
+
+// ListUploads 列出上传目录中的文件及引用它们的 hook
+func (s Service) ListUploads() Response {
+	var hooks config.Config
+	if sources, errResp := s.load(); errResp == nil {
+		hooks = config.Merge(sources)
+	}
+	files, err := upload.List(s.UploadDir, hooks)
+	if os.IsNotExist(err) {
+		return Response{Status: http.StatusOK, Body: []upload.File{}}
+	} else if err != nil {
+		return fail(http.StatusInternalServerError, "upload_dir_unreadable", err.Error())
+	}
+	return Response{Status: http.StatusOK, Body: files}
+}
+
+// Upload 把 body 保存为上传目录中的 name，extract 为 true 时解压 .tar.gz/.tgz/.zip 压缩包
+func (s Service) Upload(name string, body io.Reader, extract bool) Response {
+	if _, err := upload.SafeName(name); err != nil {
+		return fail(http.StatusBadRequest, "invalid_name", err.Error())
+	}
+	if err := os.MkdirAll(s.UploadDir, 0755); err != nil {
+		return fail(http.StatusInternalServerError, "upload_failed", err.Error())
+	}
+	// 先写到上传目录下的临时文件，保证移动时不跨设备
+	tmp, err := os.CreateTemp(s.UploadDir, ".upload-*")
+	if err != nil {
+		return fail(http.StatusInternalServerError, "upload_failed", err.Error())
+	}
+	defer os.Remove(tmp.Name())
+	_, err = io.Copy(tmp, body)
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return fail(http.StatusInternalServerError, "upload_failed", err.Error())
+	}
+	stored, err := upload.Store(tmp.Name(), name, s.UploadDir, extract)
+	if err != nil {
+		return fail(http.StatusUnprocessableEntity, "upload_rejected", err.Error())
+	}
+	return Response{Status: http.StatusCreated, Body: stored}
+}
@@ -0,0 +1,228 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"webhook-ui/common/adminhooks"
+	"webhook-ui/common/config"
+	"webhook-ui/common/reload"
+)
+
+// setupService 在临时目录中写入 content 作为 hooks 文件，用模拟的 webhook 服务（提供文件中当前的 hook）完成重载和探测，
+// 管理 hook 的恢复文件写在同一目录下
+func setupService(t *testing.T, content []byte) (Service, string) {
+	work := t.TempDir()
+	path := filepath.Join(work, "hooks.yaml")
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := os.ReadFile(path)
+		hooks, _ := config.Parse(data)
+		if r.Method == reload.ProbeMethod && hooks.Find(strings.TrimPrefix(r.URL.Path, "/hooks/")) != nil {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("WEBHOOK_URL", server.URL+"/hooks")
+	t.Setenv("RELOAD_METHOD", "command")
+	t.Setenv("RELOAD_COMMAND", "true")
+	t.Setenv("RELOAD_CONFIRM_TIMEOUT", "500ms")
+	t.Setenv("RELOAD_AUTO_ROLLBACK", "true")
+	t.Setenv("BACKUP_DIR", filepath.Join(work, "backup"))
+	t.Setenv("ADMIN_RECOVERY_FILE", filepath.Join(work, "recovery.yaml"))
+	t.Setenv("SCRIPTS_DIR", adminhooks.DefaultScriptsDir)
+	return Service{Hooks: path, UploadDir: filepath.Join(work, "upload")}, path
+}
+
+// call 通过 Handler 发送请求，把 JSON 响应体解析到 out（为 nil 时不解析），返回状态码
+func call(t *testing.T, s Service, method, target, body string, out any) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	Handler(s).ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	if out != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("%s %s 的响应无法解析: %v\n%s", method, target, err, rec.Body.String())
+		}
+	}
+	return rec.Code
+}
+
+// adminConfig 返回默认生成的管理 hook
+func adminConfig(t *testing.T) []byte {
+	t.Helper()
+	data, err := adminhooks.Generate(adminhooks.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+// edit 对 content 执行 fn 后返回新内容
+func edit(t *testing.T, content []byte, fn func(*config.Document) error) string {
+	t.Helper()
+	doc, err := config.ParseDocument(content, config.FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fn(doc); err != nil {
+		t.Fatal(err)
+	}
+	data, err := doc.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// TestSaveAdminHooksRequiresConfirmation 检查删除或改坏打开管理页面所需的 hook（ui、edit_form、save）时，
+// 没有确认文字的保存和校验返回 409，文件不变
+func TestSaveAdminHooksRequiresConfirmation(t *testing.T) {
+	admin := adminConfig(t)
+	s, path := setupService(t, admin)
+	for _, id := range []string{"ui", "edit_form", "save"} {
+		removed := edit(t, admin, func(d *config.Document) error { return d.Remove(id) })
+		broken := edit(t, admin, func(d *config.Document) error {
+			hook, err := d.Get(id)
+			if err != nil {
+				return err
+			}
+			hook.ExecuteCommand = "/bin/false"
+			return d.Update(id, *hook)
+		})
+		for name, content := range map[string]string{"删除 " + id: removed, "改坏 " + id: broken} {
+			for _, target := range []string{"/api/config", "/api/config?allow_admin_changes=yes"} {
+				var body ErrorBody
+				if status := call(t, s, "PUT", target, content, &body); status != http.StatusConflict || body.Error == nil || body.Error.Code != "admin_hooks_changed" {
+					t.Errorf("%s: PUT %s 返回 %d %+v，应为 409 admin_hooks_changed", name, target, status, body.Error)
+					continue
+				}
+				hint := body.Error.Details[len(body.Error.Details)-1]
+				if !strings.Contains(hint, adminhooks.Confirmation) || !strings.Contains(body.Error.Details[0], id+": ") {
+					t.Errorf("%s: 说明中没有受影响的 hook 或确认文字: %v", name, body.Error.Details)
+				}
+			}
+			if status := call(t, s, "POST", "/api/config/validate", content, nil); status != http.StatusConflict {
+				t.Errorf("%s: 校验返回 %d，应为 409", name, status)
+			}
+			if data, _ := os.ReadFile(path); string(data) != string(admin) {
+				t.Fatalf("%s: 未确认的修改写入了 hooks 文件", name)
+			}
+		}
+	}
+}
+
+// TestRestoreAdminHooks 检查确认后删除 save，再通过 restore-admin-hooks 使用的接口从恢复文件恢复
+func TestRestoreAdminHooks(t *testing.T) {
+	admin := adminConfig(t)
+	s, path := setupService(t, admin)
+
+	var saved SaveResult
+	if status := call(t, s, "POST", "/api/admin-hooks/restore", "", &saved); status != http.StatusNotFound {
+		t.Errorf("没有恢复文件时返回 %d，应为 404", status)
+	}
+
+	removed := edit(t, admin, func(d *config.Document) error { return d.Remove("save") })
+	target := "/api/config?allow_admin_changes=" + url.QueryEscape(adminhooks.Confirmation)
+	if status := call(t, s, "PUT", target, removed, &saved); status != http.StatusOK || !saved.Applied {
+		t.Fatalf("确认后保存返回 %d %+v", status, saved)
+	}
+	if data, _ := os.ReadFile(path); string(data) != removed {
+		t.Fatal("确认后 hooks 文件没有更新")
+	}
+
+	saved = SaveResult{}
+	if status := call(t, s, "POST", "/api/admin-hooks/restore", "", &saved); status != http.StatusOK || !saved.Applied {
+		t.Fatalf("恢复返回 %d %+v", status, saved)
+	}
+	if !strings.HasPrefix(saved.Message, "已从 ") || !strings.Contains(saved.Message, "save") {
+		t.Errorf("恢复的说明为 %q", saved.Message)
+	}
+	data, _ := os.ReadFile(path)
+	hooks, err := config.Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !adminhooks.Reachable(hooks) || hooks.Find("save").ExecuteCommand != adminhooks.DefaultScriptsDir+"/save/save" {
+		t.Errorf("恢复后缺少 save 或内容不正确:\n%s", data)
+	}
+
+	// 已经一致时不再写入
+	saved = SaveResult{}
+	if status := call(t, s, "POST", "/api/admin-hooks/restore", "", &saved); status != http.StatusOK || saved.Title != "管理 hook 与恢复文件一致，无需恢复" {
+		t.Errorf("再次恢复返回 %d %+v", status, saved)
+	}
+}
+
+// TestErrorStatus 检查各种错误请求的状态码和错误代码，出错时 hooks 文件不变
+func TestErrorStatus(t *testing.T) {
+	const hooks = "- id: deploy\n  execute-command: /bin/true\n"
+	s, path := setupService(t, []byte(hooks))
+	cases := []struct {
+		name, method, target, body string
+		status                     int
+		code                       string
+	}{
+		{"空请求体", "PUT", "/api/config", "", http.StatusBadRequest, "empty_body"},
+		{"不是 HOOKS 中的文件", "PUT", "/api/config?file=/etc/passwd", hooks, http.StatusBadRequest, "invalid_file"},
+		{"limit 不是正整数", "GET", "/api/history?limit=0", "", http.StatusBadRequest, "invalid_limit"},
+		{"无效的版本号", "POST", "/api/config/rollback?version=../hooks", "", http.StatusBadRequest, "invalid_version"},
+		{"无效的上传文件名", "POST", "/api/uploads?name=..", "data", http.StatusBadRequest, "invalid_name"},
+		{"hook 不存在", "GET", "/api/hooks/missing", "", http.StatusNotFound, "hook_not_found"},
+		{"列出不存在的文件", "GET", "/api/hooks?file=/etc/passwd", "", http.StatusNotFound, "file_not_found"},
+		{"版本不存在", "POST", "/api/config/rollback?version=20240101-000000.000000", "", http.StatusNotFound, "version_not_found"},
+		{"没有恢复文件", "POST", "/api/admin-hooks/restore", "", http.StatusNotFound, "recovery_not_found"},
+		{"无法解析的配置", "PUT", "/api/config", "- id: [", http.StatusUnprocessableEntity, "invalid_config"},
+		{"校验无法解析的配置", "POST", "/api/config/validate", "- id: [", http.StatusUnprocessableEntity, "invalid_config"},
+	}
+	for _, tc := range cases {
+		var body ErrorBody
+		status := call(t, s, tc.method, tc.target, tc.body, &body)
+		if status != tc.status || body.Error == nil || body.Error.Code != tc.code || body.Error.Status != tc.status {
+			t.Errorf("%s: %s %s 返回 %d %+v，应为 %d %s", tc.name, tc.method, tc.target, status, body.Error, tc.status, tc.code)
+		}
+	}
+	if data, _ := os.ReadFile(path); string(data) != hooks {
+		t.Errorf("出错的请求修改了 hooks 文件:\n%s", data)
+	}
+}
+
+// TestSaveReloadFailed 检查 webhook 重载后没有提供新 hook 时返回 502，结果中带有保存的说明，新配置已自动恢复
+func TestSaveReloadFailed(t *testing.T) {
+	const hooks = "- id: deploy\n  execute-command: /bin/true\n  http-methods: [POST]\n"
+	s, path := setupService(t, []byte(hooks))
+	// 模拟的 webhook 始终只提供 deploy，不加载新配置
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == reload.ProbeMethod && r.URL.Path == "/hooks/deploy" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	t.Setenv("WEBHOOK_URL", server.URL+"/hooks")
+
+	var body struct {
+		ErrorBody
+		SaveResult
+	}
+	status := call(t, s, "PUT", "/api/config", hooks+"- id: build\n  execute-command: /bin/true\n  http-methods: [POST]\n", &body)
+	if status != http.StatusBadGateway || body.Error == nil || body.Error.Code != "reload_failed" {
+		t.Fatalf("重载失败时返回 %d %+v", status, body.Error)
+	}
+	if body.Applied || body.Backup == "" {
+		t.Errorf("保存结果为 %+v，应未生效且有备份", body.SaveResult)
+	}
+	if data, _ := os.ReadFile(path); string(data) != hooks {
+		t.Errorf("重载失败后 hooks 文件没有恢复:\n%s", data)
+	}
+}
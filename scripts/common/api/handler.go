@@ -0,0 +1,149 @@
+package api
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+
+	"webhook-ui/common/adminhooks"
+	"webhook-ui/common/i18n"
+)
+
+// OpenAPI 是 API 的 OpenAPI 3 描述
+//
+//go:embed openapi.yaml
+var OpenAPI []byte
+
+// maxConfigSize 是校验和保存时请求体的大小上限
+const maxConfigSize = 10 << 20 // 10 MiB
+
+// Write 把响应写为 JSON
+func Write(w http.ResponseWriter, r Response) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(r.Status)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(r.Body); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JSON response: %v\n", err)
+	}
+}
+
+// readConfig 读取请求体中的配置内容
+func readConfig(r *http.Request) ([]byte, *Response) {
+	content, err := io.ReadAll(io.LimitReader(r.Body, maxConfigSize+1))
+	if err != nil {
+		resp := fail(http.StatusBadRequest, "invalid_body", err.Error())
+		return nil, &resp
+	}
+	if len(content) > maxConfigSize {
+		resp := fail(http.StatusRequestEntityTooLarge, "body_too_large", i18n.M("配置内容超过 %d 字节", maxConfigSize).String())
+		return nil, &resp
+	}
+	if len(content) == 0 {
+		resp := fail(http.StatusBadRequest, "empty_body", i18n.M("请求体中没有配置内容").String())
+		return nil, &resp
+	}
+	return content, nil
+}
+
+// uploadName 返回上传的文件名：查询参数 name，或 URL 编码的 X-File-Name 请求头
+func uploadName(r *http.Request) string {
+	if name := r.URL.Query().Get("name"); name != "" {
+		return name
+	}
+	name := r.Header.Get("X-File-Name")
+	if decoded, err := url.PathUnescape(name); err == nil {
+		return decoded
+	}
+	return name
+}
+
+// allowAdminChanges 返回查询参数 allow_admin_changes 是否是与确认页相同的确认文字（见 adminhooks.Confirmation），
+// 见 pipeline.Options.AllowAdminChanges
+func allowAdminChanges(r *http.Request) bool {
+	return adminhooks.Confirmed(r.URL.Query().Get("allow_admin_changes"))
+}
+
+// Handler 返回提供所有 API 的 http.Handler，路径见 openapi.yaml
+func Handler(s Service) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/hooks", func(w http.ResponseWriter, r *http.Request) {
+		Write(w, s.ListHooks(r.URL.Query().Get("file")))
+	})
+	mux.HandleFunc("GET /api/hooks/{id}", func(w http.ResponseWriter, r *http.Request) {
+		Write(w, s.GetHook(r.PathValue("id")))
+	})
+	mux.HandleFunc("POST /api/config/validate", func(w http.ResponseWriter, r *http.Request) {
+		content, errResp := readConfig(r)
+		if errResp != nil {
+			Write(w, *errResp)
+			return
+		}
+		Write(w, s.ValidateConfig(r.URL.Query().Get("file"), content, allowAdminChanges(r)))
+	})
+	mux.HandleFunc("PUT /api/config", func(w http.ResponseWriter, r *http.Request) {
+		content, errResp := readConfig(r)
+		if errResp != nil {
+			Write(w, *errResp)
+			return
+		}
+		Write(w, s.SaveConfig(r.URL.Query().Get("file"), content, allowAdminChanges(r)))
+	})
+	mux.HandleFunc("GET /api/config", func(w http.ResponseWriter, r *http.Request) {
+		Write(w, s.GetConfig(r.URL.Query().Get("file")))
+	})
+	mux.HandleFunc("GET /api/config/versions", func(w http.ResponseWriter, r *http.Request) {
+		Write(w, s.Versions(r.URL.Query().Get("file")))
+	})
+	mux.HandleFunc("POST /api/config/rollback", func(w http.ResponseWriter, r *http.Request) {
+		Write(w, s.Rollback(r.URL.Query().Get("file"), r.URL.Query().Get("version"), allowAdminChanges(r)))
+	})
+	mux.HandleFunc("POST /api/admin-hooks/restore", func(w http.ResponseWriter, r *http.Request) {
+		Write(w, s.RestoreAdminHooks(r.URL.Query().Get("file")))
+	})
+	mux.HandleFunc("GET /api/history", func(w http.ResponseWriter, r *http.Request) {
+		Write(w, s.History(r.URL.Query().Get("id"), r.URL.Query().Get("limit")))
+	})
+	mux.HandleFunc("GET /api/uploads", func(w http.ResponseWriter, r *http.Request) {
+		Write(w, s.ListUploads())
+	})
+	mux.HandleFunc("POST /api/uploads", func(w http.ResponseWriter, r *http.Request) {
+		Write(w, s.Upload(uploadName(r), r.Body, r.URL.Query().Get("extract") != "false"))
+	})
+	mux.HandleFunc("GET /api/openapi.yaml", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/yaml; charset=utf-8")
+		w.Write(OpenAPI)
+	})
+	return mux
+}
+
+// Recorder 是在进程内调用 Handler 时使用的 http.ResponseWriter，记录状态码和响应体
+type Recorder struct {
+	Status int
+	Body   bytes.Buffer
+	header http.Header
+}
+
+func (w *Recorder) Header() http.Header {
+	if w.header == nil {
+		w.header = http.Header{}
+	}
+	return w.header
+}
+
+func (w *Recorder) WriteHeader(status int) {
+	if w.Status == 0 {
+		w.Status = status
+	}
+}
+
+func (w *Recorder) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.Body.Write(p)
+}
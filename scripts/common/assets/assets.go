@@ -0,0 +1,87 @@
+// Package assets 是各页面共用的静态资源：样式表、脚本和字体，编译时嵌入程序，页面在无法访问外网的环境中同样能正常显示。
+// 样式表和脚本较小，直接内联到页面中；字体文件较大，由 assets hook（独立服务中为 <前缀>/assets）按需提供，浏览器可以缓存
+package assets
+
+import (
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"webhook-ui/common/i18n"
+)
+
+//go:embed static
+var static embed.FS
+
+// contentTypes 是静态资源的 Content-Type，按扩展名
+var contentTypes = map[string]string{
+	".css":   "text/css; charset=utf-8",
+	".js":    "text/javascript; charset=utf-8",
+	".woff2": "font/woff2",
+	".txt":   "text/plain; charset=utf-8",
+}
+
+func mustRead(name string) string {
+	data, err := static.ReadFile("static/" + name)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+var (
+	style  = template.CSS(mustRead("style.css"))
+	script = template.JS(mustRead("app.js"))
+)
+
+// Style 返回共用样式表，放在页面的 <style> 中
+func Style() template.CSS {
+	return style
+}
+
+// Script 返回共用脚本，放在页面的 <script> 中
+func Script() template.JS {
+	return script
+}
+
+// FuncMap 返回页面模板中使用的函数：{{ assetStyle }}、{{ assetScript }}
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"assetStyle":  Style,
+		"assetScript": Script,
+	}
+}
+
+// Open 返回 file（相对 static 目录的路径，如 fonts/FiraSans-Regular.woff2）的内容和 Content-Type
+func Open(file string) ([]byte, string, error) {
+	file = strings.TrimPrefix(file, "/")
+	if !fs.ValidPath(file) || file == "." {
+		return nil, "", i18n.Errorf("无效的文件 %q", file)
+	}
+	data, err := static.ReadFile("static/" + file)
+	if err != nil {
+		return nil, "", err
+	}
+	contentType, ok := contentTypes[path.Ext(file)]
+	if !ok {
+		contentType = "application/octet-stream"
+	}
+	return data, contentType, nil
+}
+
+// Handler 按查询参数 file 提供静态资源，资源随程序发布，允许浏览器缓存一周
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, contentType, err := Open(r.URL.Query().Get("file"))
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "public, max-age=604800")
+		w.Write(data)
+	})
+}
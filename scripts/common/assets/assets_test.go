@@ -0,0 +1,52 @@
+package assets
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestOpen(t *testing.T) {
+	for file, want := range map[string]string{
+		"fonts/FiraSans-Regular.woff2":  "font/woff2",
+		"/fonts/FiraMono-Regular.woff2": "font/woff2",
+		"style.css":                     "text/css; charset=utf-8",
+		"fonts/OFL.txt":                 "text/plain; charset=utf-8",
+	} {
+		data, contentType, err := Open(file)
+		if err != nil || len(data) == 0 || contentType != want {
+			t.Errorf("Open(%q) = %d 字节, %q, %v，应为 %q", file, len(data), contentType, err, want)
+		}
+	}
+}
+
+// TestOpenRejectsEscapes 检查只能读取嵌入的 static 目录中的文件
+func TestOpenRejectsEscapes(t *testing.T) {
+	for _, file := range []string{
+		"", ".", "/", "..", "../assets.go", "fonts/../../assets.go", "fonts/../style.css",
+		"./style.css", "fonts//OFL.txt", `fonts\..\style.css`, "/etc/passwd", "missing.css", "fonts",
+	} {
+		if data, _, err := Open(file); err == nil {
+			t.Errorf("Open(%q) 返回了 %d 字节", file, len(data))
+		}
+	}
+}
+
+func TestHandler(t *testing.T) {
+	get := func(file string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/assets?file="+url.QueryEscape(file), nil))
+		return rec
+	}
+	rec := get("fonts/FiraSans-Medium.woff2")
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "font/woff2" || !strings.Contains(rec.Header().Get("Cache-Control"), "max-age") {
+		t.Errorf("字体返回 %d %v", rec.Code, rec.Header())
+	}
+	for _, file := range []string{"../assets.go", "fonts/../../go.mod"} {
+		if rec := get(file); rec.Code != http.StatusNotFound {
+			t.Errorf("%s 返回 %d，应为 404", file, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+// 各页面共用的脚本，内联在页面末尾
+
+// t 翻译脚本中的文字：uiText 由布局模板按页面语言生成（见 pages.scriptText），没有译文时使用原文，%s 依次替换为 args
+function t(text) {
+    var translated = (typeof uiText !== "undefined" && uiText[text]) || text;
+    for (var i = 1; i < arguments.length; i++) {
+        translated = translated.replace("%s", arguments[i]);
+    }
+    return translated;
+}
+
+// askReason 在停用/启用 hook 前询问原因，操作人保存在浏览器中，下次不再询问。用于 hook-disable、hook-enable 表单的 onsubmit
+function askReason(form, required) {
+    var id = form.elements["id"].value;
+    var reason = prompt(required ? t("停用 hook %s 的原因：", id) : t("启用 hook %s 的原因（可选）：", id));
+    if (reason === null || (required && reason.trim() === "")) {
+        return false;
+    }
+    var user = localStorage.getItem("webhook-ui-operator");
+    if (!user) {
+        user = prompt(t("操作人：")) || "";
+        if (user) {
+            localStorage.setItem("webhook-ui-operator", user);
+        }
+    }
+    form.elements["reason"].value = reason;
+    form.elements["user"].value = user;
+    return true;
+}
+
+// requireTyped 在输入框的内容与 phrase 一致时才启用按钮，服务端同样会检查
+function requireTyped(inputId, buttonId, phrase) {
+    var input = document.getElementById(inputId);
+    input.addEventListener("input", function() {
+        document.getElementById(buttonId).disabled = input.value.trim() !== phrase;
+    });
+}
+
+// setLang 把选择的页面语言保存在 cookie 中（优先于浏览器的 Accept-Language），然后刷新页面。用于导航栏的语言切换
+function setLang(lang) {
+    document.cookie = "webhook_ui_lang=" + encodeURIComponent(lang) + "; path=/; max-age=31536000; SameSite=Lax";
+    location.reload();
+    return false;
+}
@@ -0,0 +1,71 @@
+package config
+
+import (
+	"os"
+	"slices"
+	"sync"
+)
+
+// Cache 缓存 LoadAll 的结果，供长期运行的进程在多个请求之间共用。
+// 每次 Load 都会重新解析 HOOKS 并检查每个 hooks 文件及其停用记录的修改时间和大小，有变化时重新读取，
+// 因此通过其他途径（webhook 脚本、手工编辑）修改的文件也能及时反映。
+// 模板模式下渲染结果可能依赖其他文件和环境变量，不做缓存。
+// 返回的 Source 由所有调用方共用，不能修改
+type Cache struct {
+	spec string
+
+	mu      sync.Mutex
+	paths   []string
+	stamps  []stamp
+	sources []Source
+}
+
+// stamp 是判断文件是否变化的依据
+type stamp struct {
+	exists  bool
+	size    int64
+	modTime int64 // UnixNano，time.Time 不能直接用 == 比较
+}
+
+func statStamp(path string) stamp {
+	info, err := os.Stat(path)
+	if err != nil {
+		return stamp{}
+	}
+	return stamp{exists: true, size: info.Size(), modTime: info.ModTime().UnixNano()}
+}
+
+// NewCache 创建按 spec（HOOKS 的写法）读取 hooks 文件的缓存
+func NewCache(spec string) *Cache {
+	return &Cache{spec: spec}
+}
+
+// Load 返回所有 hooks 文件的当前内容
+func (c *Cache) Load() ([]Source, error) {
+	paths, err := ResolvePaths(c.spec)
+	if err != nil {
+		return nil, err
+	}
+	if TemplateEnabled() {
+		return LoadSources(paths), nil
+	}
+	stamps := make([]stamp, 0, 2*len(paths))
+	for _, path := range paths {
+		stamps = append(stamps, statStamp(path), statStamp(DisabledPath(path)))
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.sources == nil || !slices.Equal(paths, c.paths) || !slices.Equal(stamps, c.stamps) {
+		c.paths, c.stamps, c.sources = paths, stamps, LoadSources(paths)
+	}
+	return c.sources, nil
+}
+
+// Invalidate 丢弃缓存，下次 Load 时重新读取。写入文件后调用，
+// 避免修改时间精度不足、同一时刻内写入相同大小的内容时读到旧的结果
+func (c *Cache) Invalidate() {
+	c.mu.Lock()
+	c.sources = nil
+	c.mu.Unlock()
+}
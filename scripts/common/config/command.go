@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// CommandStatus 表示 hook 的 execute-command 指向的文件状态
+type CommandStatus string
+
+const (
+	CommandOK            CommandStatus = "ok"
+	CommandMissing       CommandStatus = "missing"
+	CommandNotExecutable CommandStatus = "not-executable"
+)
+
+// CommandPath 按 webhook 的规则得到 execute-command 的查找路径：
+// 相对路径且设置了 command-working-directory 时相对于工作目录
+func (h Hook) CommandPath() string {
+	if h.ExecuteCommand == "" {
+		return ""
+	}
+	if filepath.IsAbs(h.ExecuteCommand) || h.CommandWorkingDirectory == "" {
+		return filepath.Clean(h.ExecuteCommand)
+	}
+	return filepath.Join(h.CommandWorkingDirectory, h.ExecuteCommand)
+}
+
+// CheckCommand 检查 execute-command 是否存在且可执行。不含路径分隔符的命令在 PATH 中查找
+func (h Hook) CheckCommand() CommandStatus {
+	path := h.CommandPath()
+	if path == "" {
+		return CommandMissing
+	}
+	if !strings.ContainsRune(path, os.PathSeparator) {
+		if _, err := exec.LookPath(path); err != nil {
+			return CommandMissing
+		}
+		return CommandOK
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return CommandMissing
+	}
+	if info.IsDir() || info.Mode().Perm()&0111 == 0 {
+		return CommandNotExecutable
+	}
+	return CommandOK
+}
+
+// HooksReferencing 返回 execute-command 指向 path 的 hook；path 为目录时，也包括执行目录内文件的 hook
+func (c Config) HooksReferencing(path string) []Hook {
+	path = filepath.Clean(path)
+	var hooks []Hook
+	for _, h := range c {
+		cmd := h.CommandPath()
+		if cmd == "" {
+			continue
+		}
+		if cmd == path || strings.HasPrefix(cmd, path+string(os.PathSeparator)) {
+			hooks = append(hooks, h)
+		}
+	}
+	return hooks
+}
+
+// UniqueID 返回不与现有 hook 重复的 id，重复时追加 -2、-3 ...
+func (c Config) UniqueID(base string) string {
+	if c.Find(base) == nil {
+		return base
+	}
+	for i := 2; ; i++ {
+		id := fmt.Sprintf("%s-%d", base, i)
+		if c.Find(id) == nil {
+			return id
+		}
+	}
+}
+
+// NewHookFor 为可执行文件生成一个新 hook 的初始配置，id 取文件名并保证不重复
+func NewHookFor(c Config, command string) Hook {
+	command = filepath.Clean(command)
+	return Hook{
+		ID:                          c.UniqueID(filepath.Base(command)),
+		ExecuteCommand:              command,
+		CommandWorkingDirectory:     filepath.Dir(command),
+		CaptureCommandOutput:        true,
+		CaptureCommandOutputOnError: true,
+		HTTPMethods:                 []string{"POST"},
+	}
+}
+
+// Marshal 把 hook 列表序列化为 YAML，序列化为其他格式使用 MarshalFormat
+func Marshal(hooks []Hook) ([]byte, error) {
+	return marshalYAML(hooks)
+}
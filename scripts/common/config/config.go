@@ -0,0 +1,92 @@
+// Package config 定义与 webhook 配置文件匹配的 Go 结构体，以及各个脚本共用的读取方法
+package config
+
+import "os"
+
+// 定义与 webhook 配置文件匹配的 Go 结构体
+type Config []Hook
+
+type Header struct {
+	Name  string `yaml:"name" json:"name"`
+	Value string `yaml:"value" json:"value"`
+}
+
+type ResponseHeaders []Header
+
+type Argument struct {
+	Source       string `yaml:"source,omitempty" json:"source,omitempty"`
+	Name         string `yaml:"name,omitempty" json:"name,omitempty"`
+	EnvName      string `yaml:"envname,omitempty" json:"envname,omitempty"`
+	Base64Decode bool   `yaml:"base64decode,omitempty" json:"base64decode,omitempty"`
+}
+
+type Rules struct {
+	And   *AndRule   `yaml:"and,omitempty" json:"and,omitempty"`
+	Or    *OrRule    `yaml:"or,omitempty" json:"or,omitempty"`
+	Not   *NotRule   `yaml:"not,omitempty" json:"not,omitempty"`
+	Match *MatchRule `yaml:"match,omitempty" json:"match,omitempty"`
+}
+
+type AndRule []Rules
+
+type OrRule []Rules
+
+type NotRule Rules
+
+type MatchRule struct {
+	Type      string   `yaml:"type,omitempty" json:"type,omitempty"`
+	Regex     string   `yaml:"regex,omitempty" json:"regex,omitempty"`
+	Secret    string   `yaml:"secret,omitempty" json:"secret,omitempty"`
+	Value     string   `yaml:"value,omitempty" json:"value,omitempty"`
+	Parameter Argument `yaml:"parameter,omitempty" json:"parameter,omitempty"` // Note: webhook's match.parameter is singular
+	IPRange   string   `yaml:"ip-range,omitempty" json:"ip-range,omitempty"`
+}
+
+type Hook struct {
+	ID                                  string          `yaml:"id,omitempty" json:"id,omitempty"`
+	ExecuteCommand                      string          `yaml:"execute-command,omitempty" json:"execute-command,omitempty"`
+	CommandWorkingDirectory             string          `yaml:"command-working-directory,omitempty" json:"command-working-directory,omitempty"`
+	ResponseMessage                     string          `yaml:"response-message,omitempty" json:"response-message,omitempty"`
+	ResponseHeaders                     ResponseHeaders `yaml:"response-headers,omitempty" json:"response-headers,omitempty"`
+	CaptureCommandOutput                bool            `yaml:"include-command-output-in-response,omitempty" json:"include-command-output-in-response,omitempty"`
+	StreamCommandOutput                 bool            `yaml:"stream-command-output,omitempty" json:"stream-command-output,omitempty"`
+	CaptureCommandOutputOnError         bool            `yaml:"include-command-output-in-response-on-error,omitempty" json:"include-command-output-in-response-on-error,omitempty"`
+	PassEnvironmentToCommand            []Argument      `yaml:"pass-environment-to-command,omitempty" json:"pass-environment-to-command,omitempty"`
+	PassArgumentsToCommand              []Argument      `yaml:"pass-arguments-to-command,omitempty" json:"pass-arguments-to-command,omitempty"`
+	PassFileToCommand                   []Argument      `yaml:"pass-file-to-command,omitempty" json:"pass-file-to-command,omitempty"`
+	JSONStringParameters                []Argument      `yaml:"parse-parameters-as-json,omitempty" json:"parse-parameters-as-json,omitempty"`
+	TriggerRule                         *Rules          `yaml:"trigger-rule,omitempty" json:"trigger-rule,omitempty"`
+	TriggerRuleMismatchHttpResponseCode int             `yaml:"trigger-rule-mismatch-http-response-code,omitempty" json:"trigger-rule-mismatch-http-response-code,omitempty"`
+	TriggerSignatureSoftFailures        bool            `yaml:"trigger-signature-soft-failures,omitempty" json:"trigger-signature-soft-failures,omitempty"`
+	IncomingPayloadContentType          string          `yaml:"incoming-payload-content-type,omitempty" json:"incoming-payload-content-type,omitempty"`
+	SuccessHttpResponseCode             int             `yaml:"success-http-response-code,omitempty" json:"success-http-response-code,omitempty"`
+	HTTPMethods                         []string        `yaml:"http-methods,omitempty" json:"http-methods,omitempty"`
+}
+
+// Parse 解析 YAML 或 JSON 格式的配置内容，格式根据内容判断
+func Parse(data []byte) (Config, error) {
+	return ParseFormat(data, DetectFormat("", data))
+}
+
+// Load 读取并解析配置文件，格式根据扩展名和内容判断；模板模式下先渲染模板。
+// 文件不存在时返回的错误满足 os.IsNotExist，由调用方决定如何处理
+func Load(path string) (Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if data, err = Expand(data); err != nil {
+		return nil, err
+	}
+	return ParseFormat(data, DetectFormat(path, data))
+}
+
+// Find 按 id 查找 hook，找不到时返回 nil
+func (c Config) Find(id string) *Hook {
+	for i := range c {
+		if c[i].ID == id {
+			return &c[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"webhook-ui/common/i18n"
+)
+
+// historyKeep 是旁路文件中保留的停用/启用记录条数
+const historyKeep = 200
+
+// DisabledHook 是被停用的 hook。webhook 没有停用 hook 的配置项，因此停用时把定义从 hooks 文件移到旁路文件，
+// 启用时再原样放回原来的位置
+type DisabledHook struct {
+	ID       string    `yaml:"id" json:"id"`
+	Index    int       `yaml:"index" json:"index"`       // 停用前在文件中的位置
+	Fragment string    `yaml:"fragment" json:"fragment"` // 停用前的原文（YAML 列表项或 JSON 对象，模板模式下为模板原文）
+	By       string    `yaml:"by" json:"by"`
+	Reason   string    `yaml:"reason" json:"reason"`
+	Time     time.Time `yaml:"time" json:"time"`
+	Hook     Hook      `yaml:"-" json:"-"` // 解析后的定义，用于页面展示
+}
+
+// ToggleRecord 是一次停用或启用的记录
+type ToggleRecord struct {
+	Time   time.Time `yaml:"time"`
+	Action string    `yaml:"action"` // disable 或 enable
+	ID     string    `yaml:"id"`
+	By     string    `yaml:"by"`
+	Reason string    `yaml:"reason,omitempty"`
+}
+
+// DisabledState 是一个 hooks 文件的旁路文件内容：被停用的 hook 和停用/启用记录
+type DisabledState struct {
+	Hooks   []DisabledHook `yaml:"hooks"`
+	History []ToggleRecord `yaml:"history"`
+}
+
+// DisabledPath 返回 hooks 文件的旁路文件路径。以 . 开头，不会被 HOOKS 的通配符或 webhook 加载
+func DisabledPath(path string) string {
+	return filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".disabled.yaml")
+}
+
+// LoadDisabled 读取 hooks 文件的旁路文件，文件不存在时返回空状态
+func LoadDisabled(path string) (*DisabledState, error) {
+	state := &DisabledState{}
+	data, err := os.ReadFile(DisabledPath(path))
+	if os.IsNotExist(err) {
+		return state, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("%s: %v", DisabledPath(path), err)
+	}
+	for i := range state.Hooks {
+		state.Hooks[i].Hook = state.Hooks[i].parse()
+	}
+	return state, nil
+}
+
+// parse 解析停用的 hook 的原文，无法解析时只保留 id
+func (h DisabledHook) parse() Hook {
+	fragment, err := Expand([]byte(h.Fragment))
+	if err != nil {
+		return Hook{ID: h.ID}
+	}
+	format := FormatYAML
+	if trimmed := bytes.TrimSpace(fragment); len(trimmed) > 0 && trimmed[0] == '{' {
+		format = FormatJSON
+	}
+	item, _, err := parseFragment(fragment, format)
+	if err != nil {
+		return Hook{ID: h.ID}
+	}
+	var hook Hook
+	if err := item.Decode(&hook); err != nil {
+		return Hook{ID: h.ID}
+	}
+	return hook
+}
+
+// Find 返回 id 对应的停用的 hook
+func (s *DisabledState) Find(id string) *DisabledHook {
+	for i := range s.Hooks {
+		if s.Hooks[i].ID == id {
+			return &s.Hooks[i]
+		}
+	}
+	return nil
+}
+
+// Disable 记录 hook 已被停用
+func (s *DisabledState) Disable(hook DisabledHook) {
+	s.Hooks = append(s.Hooks, hook)
+	s.record(ToggleRecord{Time: hook.Time, Action: "disable", ID: hook.ID, By: hook.By, Reason: hook.Reason})
+}
+
+// Enable 移除停用的 hook 并记录，返回被移除的定义
+func (s *DisabledState) Enable(id, by, reason string, at time.Time) (DisabledHook, error) {
+	for i, h := range s.Hooks {
+		if h.ID == id {
+			s.Hooks = append(s.Hooks[:i:i], s.Hooks[i+1:]...)
+			s.record(ToggleRecord{Time: at, Action: "enable", ID: id, By: by, Reason: reason})
+			return h, nil
+		}
+	}
+	return DisabledHook{}, i18n.Errorf("hook %q 没有被停用", id)
+}
+
+func (s *DisabledState) record(r ToggleRecord) {
+	s.History = append(s.History, r)
+	if len(s.History) > historyKeep {
+		s.History = s.History[len(s.History)-historyKeep:]
+	}
+}
+
+// Recent 返回最近的 n 条记录，最新的在前
+func (s *DisabledState) Recent(n int) []ToggleRecord {
+	var recent []ToggleRecord
+	for i := len(s.History) - 1; i >= 0 && len(recent) < n; i-- {
+		recent = append(recent, s.History[i])
+	}
+	return recent
+}
+
+// Bytes 序列化旁路文件
+func (s *DisabledState) Bytes() ([]byte, error) {
+	return marshalYAML(s)
+}
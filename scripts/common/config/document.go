@@ -0,0 +1,676 @@
+package config
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"webhook-ui/common/i18n"
+)
+
+// Document 是基于 yaml.v3 节点树的 hooks 文件。添加、删除、重命名、排序和更新 hook 时只改动涉及的节点，
+// 其余 hook 的注释、键顺序和格式原样保留；Config 只适合读取，序列化会丢失这些信息
+type Document struct {
+	root   *yaml.Node // DocumentNode
+	list   *yaml.Node // 顶层的 hook 列表
+	format Format
+
+	// 块风格的 YAML 文件按 hook 切分的原文。未修改的 hook 序列化时直接使用原文，
+	// 以保留 yaml.v3 无法还原的空行、缩进和注释前的空格
+	preamble []byte
+	original map[*yaml.Node][]byte
+	// spaced 表示原文中每个 hook 之后都有空行，新增、移动到中间或移出末尾的 hook 按同样的方式分隔
+	spaced bool
+	tail   *yaml.Node
+}
+
+// ParseDocument 按指定格式解析配置内容，空内容视为没有 hook
+func ParseDocument(data []byte, format Format) (*Document, error) {
+	if format == FormatJSON {
+		if err := Validate(data, format); err != nil {
+			return nil, err
+		}
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, err
+	}
+	if root.Kind == 0 {
+		root = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.SequenceNode, Tag: "!!seq"}}}
+	}
+	if root.Kind != yaml.DocumentNode || len(root.Content) != 1 || root.Content[0].Kind != yaml.SequenceNode {
+		return nil, i18n.Errorf("hooks 文件的顶层必须是 hook 列表")
+	}
+	d := &Document{root: &root, list: root.Content[0], format: format}
+	if format == FormatYAML && d.list.Style&yaml.FlowStyle == 0 {
+		d.split(data)
+	}
+	return d, nil
+}
+
+// split 按顶层列表项切分原文：每个 hook 从 "-" 所在行开始（包括紧挨在上方的注释行），到下一个 hook 之前结束
+func (d *Document) split(data []byte) {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	starts := make([]int, len(d.list.Content))
+	for i, item := range d.list.Content {
+		start := item.Line - 1
+		for start > 0 && !bytes.HasPrefix(bytes.TrimSpace(lines[start]), []byte("-")) {
+			start--
+		}
+		// 只处理 "-" 在第一列的顶层列表，其他写法退回到整体序列化
+		if !bytes.HasPrefix(lines[start], []byte("-")) || (i > 0 && start <= starts[i-1]) {
+			return
+		}
+		for start > 0 && (i == 0 || start-1 > starts[i-1]) && bytes.HasPrefix(bytes.TrimSpace(lines[start-1]), []byte("#")) {
+			start--
+		}
+		starts[i] = start
+	}
+	d.original = map[*yaml.Node][]byte{}
+	if len(starts) == 0 {
+		d.preamble = data
+		return
+	}
+	d.preamble = bytes.Join(lines[:starts[0]], nil)
+	for i, item := range d.list.Content {
+		end := len(lines)
+		if i+1 < len(starts) {
+			end = starts[i+1]
+		}
+		text := bytes.Join(lines[starts[i]:end], nil)
+		if !bytes.HasSuffix(text, []byte("\n")) {
+			text = append(text, '\n')
+		}
+		d.original[item] = text
+	}
+	d.tail = d.list.Content[len(d.list.Content)-1]
+	d.spaced = len(d.list.Content) > 1
+	for _, item := range d.list.Content[:len(d.list.Content)-1] {
+		if !bytes.HasSuffix(d.original[item], []byte("\n\n")) {
+			d.spaced = false
+		}
+	}
+}
+
+// touch 标记 hook 已被修改，序列化时不再使用原文
+func (d *Document) touch(n *yaml.Node) {
+	delete(d.original, n)
+}
+
+// Format 返回文档序列化时使用的格式
+func (d *Document) Format() Format {
+	return d.format
+}
+
+// SetFormat 设置序列化格式，用于 YAML 与 JSON 互相转换
+func (d *Document) SetFormat(format Format) {
+	if format == FormatYAML && d.format == FormatJSON {
+		// JSON 解析得到的是流式风格的节点，转换为 YAML 时改为块风格
+		resetStyle(d.root)
+	}
+	if format != d.format {
+		d.original = nil
+	}
+	d.format = format
+}
+
+func resetStyle(n *yaml.Node) {
+	if n.Kind != yaml.ScalarNode || n.Style == yaml.DoubleQuotedStyle {
+		n.Style = 0
+	}
+	for _, c := range n.Content {
+		resetStyle(c)
+	}
+}
+
+// Hooks 把文档解码为 Config
+func (d *Document) Hooks() (Config, error) {
+	var config Config
+	if err := d.list.Decode(&config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// IDs 按文件中的顺序返回所有 hook 的 id
+func (d *Document) IDs() []string {
+	ids := make([]string, 0, len(d.list.Content))
+	for _, item := range d.list.Content {
+		if v := mappingValue(item, "id"); v != nil {
+			ids = append(ids, v.Value)
+		} else {
+			ids = append(ids, "")
+		}
+	}
+	return ids
+}
+
+// mappingValue 返回映射节点中 key 对应的值节点
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func (d *Document) index(id string) int {
+	for i, itemID := range d.IDs() {
+		if itemID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (d *Document) find(id string) (int, error) {
+	i := d.index(id)
+	if i < 0 {
+		return -1, i18n.Errorf("hook %q 不存在", id)
+	}
+	return i, nil
+}
+
+// Get 返回 id 对应的 hook
+func (d *Document) Get(id string) (*Hook, error) {
+	i, err := d.find(id)
+	if err != nil {
+		return nil, err
+	}
+	var hook Hook
+	if err := d.list.Content[i].Decode(&hook); err != nil {
+		return nil, err
+	}
+	return &hook, nil
+}
+
+// Node 返回 id 对应 hook 的节点，用于展示原文片段
+func (d *Document) Node(id string) (*yaml.Node, error) {
+	i, err := d.find(id)
+	if err != nil {
+		return nil, err
+	}
+	return d.list.Content[i], nil
+}
+
+func encodeHook(hook Hook) (*yaml.Node, error) {
+	var n yaml.Node
+	if err := n.Encode(hook); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// Add 在列表末尾追加 hook，comment 不为空时作为 hook 上方的注释
+func (d *Document) Add(hook Hook, comment string) error {
+	if hook.ID == "" {
+		return i18n.Errorf("hook id 不能为空")
+	}
+	if d.index(hook.ID) >= 0 {
+		return i18n.Errorf("hook %q 已存在", hook.ID)
+	}
+	n, err := encodeHook(hook)
+	if err != nil {
+		return err
+	}
+	n.HeadComment = comment
+	if d.list.Style == yaml.FlowStyle && d.format == FormatYAML {
+		// 空文件 "[]" 是流式风格，追加后改为块风格
+		d.list.Style = 0
+	}
+	d.list.Content = append(d.list.Content, n)
+	return nil
+}
+
+// Remove 删除 id 对应的 hook，包括它上方的注释
+func (d *Document) Remove(id string) error {
+	i, err := d.find(id)
+	if err != nil {
+		return err
+	}
+	d.list.Content = append(d.list.Content[:i], d.list.Content[i+1:]...)
+	return nil
+}
+
+// Rename 修改 hook 的 id，只改动 id 的值，行尾注释等保持不变
+func (d *Document) Rename(oldID, newID string) error {
+	i, err := d.find(oldID)
+	if err != nil {
+		return err
+	}
+	if newID == "" {
+		return i18n.Errorf("hook id 不能为空")
+	}
+	if newID != oldID && d.index(newID) >= 0 {
+		return i18n.Errorf("hook %q 已存在", newID)
+	}
+	d.touch(d.list.Content[i])
+	v := mappingValue(d.list.Content[i], "id")
+	v.Value = newID
+	v.Tag = "!!str"
+	if v.Style != yaml.SingleQuotedStyle && v.Style != yaml.DoubleQuotedStyle {
+		v.Style = 0
+	}
+	return nil
+}
+
+// Move 把 hook 移动到列表中的 index 位置，超出范围时移动到开头或末尾
+func (d *Document) Move(id string, index int) error {
+	i, err := d.find(id)
+	if err != nil {
+		return err
+	}
+	item := d.list.Content[i]
+	rest := append(d.list.Content[:i:i], d.list.Content[i+1:]...)
+	if index < 0 {
+		index = 0
+	}
+	if index > len(rest) {
+		index = len(rest)
+	}
+	d.list.Content = append(rest[:index:index], append([]*yaml.Node{item}, rest[index:]...)...)
+	return nil
+}
+
+// Update 用 hook 更新 id 对应的 hook（hook.ID 与 id 不同时同时重命名）。
+// 逐个字段合并：值未变的字段保持原样，已有字段原地修改，新字段追加在末尾；
+// 删除 hook 中已清空的字段，但保留 Hook 结构体中没有定义的字段
+func (d *Document) Update(id string, hook Hook) error {
+	i, err := d.find(id)
+	if err != nil {
+		return err
+	}
+	if hook.ID == "" {
+		return i18n.Errorf("hook id 不能为空")
+	}
+	if hook.ID != id && d.index(hook.ID) >= 0 {
+		return i18n.Errorf("hook %q 已存在", hook.ID)
+	}
+	n, err := encodeHook(hook)
+	if err != nil {
+		return err
+	}
+	d.touch(d.list.Content[i])
+	mergeNode(d.list.Content[i], n, reflect.TypeOf(hook))
+	return nil
+}
+
+// SetEnv 为 id 对应的 hook 设置固定值的环境变量（pass-environment-to-command 中 source 为 string 的项），
+// 已有同名环境变量时替换，否则追加。value 可以为空字符串
+func (d *Document) SetEnv(id, envName, value string) error {
+	i, err := d.find(id)
+	if err != nil {
+		return err
+	}
+	item := d.list.Content[i]
+	seq := mappingValue(item, "pass-environment-to-command")
+	if seq == nil {
+		seq = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		item.Content = append(item.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "pass-environment-to-command"}, seq)
+	}
+	str := func(v string) *yaml.Node { return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v} }
+	quoted := str(value)
+	quoted.Style = yaml.DoubleQuotedStyle
+	arg := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map", Content: []*yaml.Node{
+		str("source"), str("string"), str("envname"), str(envName), str("name"), quoted,
+	}}
+	d.touch(item)
+	for j, existing := range seq.Content {
+		if v := mappingValue(existing, "envname"); v != nil && v.Value == envName {
+			seq.Content[j] = arg
+			return nil
+		}
+	}
+	seq.Content = append(seq.Content, arg)
+	return nil
+}
+
+// Fragment 返回 hook 的原文片段，用于单独编辑：YAML 是以 "- " 开头的列表项（包括上方的注释），JSON 是一个对象
+func (d *Document) Fragment(id string) ([]byte, error) {
+	i, err := d.find(id)
+	if err != nil {
+		return nil, err
+	}
+	item := d.list.Content[i]
+	if d.format == FormatJSON {
+		var buf bytes.Buffer
+		if err := writeJSON(&buf, item); err != nil {
+			return nil, err
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+			return nil, err
+		}
+		out.WriteByte('\n')
+		return out.Bytes(), nil
+	}
+	if text, ok := d.original[item]; ok {
+		return append(bytes.TrimRight(text, " \t\r\n"), '\n'), nil
+	}
+	return marshalYAML(&yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{item}})
+}
+
+// Revision 返回 hook 当前内容的摘要。单独编辑 hook 时随表单提交，保存前比较，避免覆盖别人刚做的修改
+func (d *Document) Revision(id string) (string, error) {
+	fragment, err := d.Fragment(id)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(fragment)
+	return hex.EncodeToString(sum[:8]), nil
+}
+
+// parseFragment 解析单个 hook 的片段：只有一项的列表或一个映射。
+// 返回 hook 节点，以及可以直接拼接到块风格 YAML 文件中的原文（无法直接拼接时为 nil）
+func parseFragment(fragment []byte, format Format) (*yaml.Node, []byte, error) {
+	if format == FormatJSON {
+		if err := Validate(fragment, format); err != nil {
+			return nil, nil, err
+		}
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(fragment, &root); err != nil {
+		return nil, nil, err
+	}
+	if root.Kind == 0 || len(root.Content) == 0 {
+		return nil, nil, i18n.Errorf("hook 内容为空")
+	}
+	top := root.Content[0]
+	item, text := top, []byte(nil)
+	if top.Kind == yaml.SequenceNode {
+		if len(top.Content) != 1 {
+			return nil, nil, i18n.Errorf("只能包含一个 hook，实际为 %d 个", len(top.Content))
+		}
+		item = top.Content[0]
+		lines := bytes.SplitAfter(fragment, []byte("\n"))
+		// 只有 "-" 在第一列的块风格列表项才能原样拼接
+		if format == FormatYAML && top.Style&yaml.FlowStyle == 0 && item.Line > 0 && bytes.HasPrefix(lines[item.Line-1], []byte("-")) {
+			text = append(bytes.TrimRight(fragment, " \t\r\n"), '\n')
+		}
+	}
+	if item.Kind != yaml.MappingNode {
+		return nil, nil, i18n.Errorf("hook 必须是包含 id 等字段的映射")
+	}
+	if v := mappingValue(item, "id"); v == nil || v.Value == "" {
+		return nil, nil, i18n.Errorf("hook id 不能为空")
+	}
+	var hook Hook
+	if err := item.Decode(&hook); err != nil {
+		return nil, nil, err
+	}
+	return item, text, nil
+}
+
+// Insert 把片段中的 hook 追加到列表末尾，返回它的 id
+func (d *Document) Insert(fragment []byte) (string, error) {
+	item, text, err := parseFragment(fragment, d.format)
+	if err != nil {
+		return "", err
+	}
+	id := mappingValue(item, "id").Value
+	if d.index(id) >= 0 {
+		return "", i18n.Errorf("hook %q 已存在", id)
+	}
+	if d.list.Style == yaml.FlowStyle && d.format == FormatYAML {
+		d.list.Style = 0
+	}
+	d.list.Content = append(d.list.Content, item)
+	if d.original != nil && text != nil {
+		d.original[item] = text
+	}
+	return id, nil
+}
+
+// Replace 用片段中的 hook 替换 id 对应的 hook，返回新的 id（片段中可以修改 id）。
+// 片段原文原样写入文件，其他 hook 不受影响
+func (d *Document) Replace(id string, fragment []byte) (string, error) {
+	i, err := d.find(id)
+	if err != nil {
+		return "", err
+	}
+	item, text, err := parseFragment(fragment, d.format)
+	if err != nil {
+		return "", err
+	}
+	newID := mappingValue(item, "id").Value
+	if newID != id && d.index(newID) >= 0 {
+		return "", i18n.Errorf("hook %q 已存在", newID)
+	}
+	old := d.list.Content[i]
+	d.list.Content[i] = item
+	if d.original != nil {
+		if text != nil {
+			// 保留原来与下一个 hook 之间的空行
+			oldText := d.original[old]
+			trimmed := bytes.TrimRight(oldText, " \t\r\n")
+			if n := bytes.Count(oldText[len(trimmed):], []byte("\n")); n > 1 {
+				text = append(text, bytes.Repeat([]byte("\n"), n-1)...)
+			}
+			d.original[item] = text
+		}
+		delete(d.original, old)
+	}
+	return newID, nil
+}
+
+// Duplicate 复制 id 对应的 hook，以 newID 插入到原 hook 之后
+func (d *Document) Duplicate(id, newID string) error {
+	i, err := d.find(id)
+	if err != nil {
+		return err
+	}
+	if newID == "" {
+		return i18n.Errorf("hook id 不能为空")
+	}
+	if d.index(newID) >= 0 {
+		return i18n.Errorf("hook %q 已存在", newID)
+	}
+	item := copyNode(d.list.Content[i])
+	v := mappingValue(item, "id")
+	v.Value, v.Tag, v.Style = newID, "!!str", 0
+	v.LineComment = ""
+	d.list.Content = append(d.list.Content[:i+1], append([]*yaml.Node{item}, d.list.Content[i+1:]...)...)
+	return nil
+}
+
+func copyNode(n *yaml.Node) *yaml.Node {
+	c := *n
+	c.Content = make([]*yaml.Node, len(n.Content))
+	for i, child := range n.Content {
+		c.Content[i] = copyNode(child)
+	}
+	return &c
+}
+
+// mergeNode 把 src 合并到 dst 中，尽量保留 dst 的注释和风格。t 是节点对应的 Go 类型，用于判断哪些键是已知字段
+func mergeNode(dst, src *yaml.Node, t reflect.Type) {
+	for t != nil && t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch {
+	case dst.Kind == yaml.ScalarNode && src.Kind == yaml.ScalarNode:
+		if dst.Value == src.Value && dst.ShortTag() == src.ShortTag() {
+			return
+		}
+		dst.Value, dst.Tag = src.Value, src.Tag
+		// 保留原来的引号风格，未加引号的值交给编码器决定是否需要引号
+		if dst.Style&(yaml.SingleQuotedStyle|yaml.DoubleQuotedStyle) == 0 {
+			dst.Style = src.Style
+		}
+	case dst.Kind == yaml.MappingNode && src.Kind == yaml.MappingNode && t != nil && t.Kind() == reflect.Struct:
+		known := knownKeys(t)
+		var content []*yaml.Node
+		for i := 0; i+1 < len(dst.Content); i += 2 {
+			key := dst.Content[i].Value
+			if v := mappingValue(src, key); v != nil {
+				mergeNode(dst.Content[i+1], v, known[key])
+			} else if _, ok := known[key]; ok {
+				continue // 已清空的字段
+			}
+			content = append(content, dst.Content[i], dst.Content[i+1])
+		}
+		for i := 0; i+1 < len(src.Content); i += 2 {
+			if mappingValue(dst, src.Content[i].Value) == nil {
+				content = append(content, src.Content[i], src.Content[i+1])
+			}
+		}
+		dst.Content = content
+	case dst.Kind == yaml.SequenceNode && src.Kind == yaml.SequenceNode && len(dst.Content) == len(src.Content) &&
+		t != nil && t.Kind() == reflect.Slice:
+		for i := range dst.Content {
+			mergeNode(dst.Content[i], src.Content[i], t.Elem())
+		}
+	default:
+		// 结构不同（例如列表长度变化）时整体替换，保留原节点的注释
+		head, line, foot := dst.HeadComment, dst.LineComment, dst.FootComment
+		*dst = *src
+		dst.HeadComment, dst.LineComment, dst.FootComment = head, line, foot
+	}
+}
+
+// knownKeys 返回结构体的 yaml 键及对应字段的类型
+func knownKeys(t reflect.Type) map[string]reflect.Type {
+	keys := map[string]reflect.Type{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name := strings.Split(f.Tag.Get("yaml"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+		ft := f.Type
+		if ft.Kind() == reflect.Ptr {
+			ft = ft.Elem()
+		}
+		keys[name] = ft
+	}
+	return keys
+}
+
+// Bytes 按文档的格式序列化。YAML 使用两个空格缩进，JSON 保持键的顺序
+func (d *Document) Bytes() ([]byte, error) {
+	if d.format == FormatJSON {
+		var buf bytes.Buffer
+		if err := writeJSON(&buf, d.list); err != nil {
+			return nil, err
+		}
+		var out bytes.Buffer
+		if err := json.Indent(&out, buf.Bytes(), "", "  "); err != nil {
+			return nil, err
+		}
+		out.WriteByte('\n')
+		return out.Bytes(), nil
+	}
+	if d.original == nil {
+		return marshalYAML(d.root)
+	}
+	out := append([]byte(nil), d.preamble...)
+	if len(out) > 0 && !bytes.HasSuffix(out, []byte("\n")) {
+		out = append(out, '\n')
+	}
+	for i, item := range d.list.Content {
+		text, ok := d.original[item]
+		if !ok {
+			var err error
+			text, err = marshalYAML(&yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq", Content: []*yaml.Node{item}})
+			if err != nil {
+				return nil, err
+			}
+		}
+		if d.spaced {
+			last := i == len(d.list.Content)-1
+			if !last && !bytes.HasSuffix(text, []byte("\n\n")) {
+				text = append(text[:len(text):len(text)], '\n')
+			} else if last && item != d.tail {
+				text = append(bytes.TrimRight(text, " \t\r\n"), '\n')
+			}
+		}
+		out = append(out, text...)
+	}
+	return out, nil
+}
+
+func marshalYAML(v interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(v); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeJSON 把节点树按原有键顺序写为 JSON
+func writeJSON(buf *bytes.Buffer, n *yaml.Node) error {
+	switch n.Kind {
+	case yaml.DocumentNode:
+		if len(n.Content) == 0 {
+			buf.WriteString("null")
+			return nil
+		}
+		return writeJSON(buf, n.Content[0])
+	case yaml.AliasNode:
+		return writeJSON(buf, n.Alias)
+	case yaml.SequenceNode:
+		buf.WriteByte('[')
+		for i, c := range n.Content {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, c); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case yaml.MappingNode:
+		buf.WriteByte('{')
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSONValue(buf, n.Content[i].Value); err != nil {
+				return err
+			}
+			buf.WriteByte(':')
+			if err := writeJSON(buf, n.Content[i+1]); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		switch n.ShortTag() {
+		case "!!null":
+			buf.WriteString("null")
+		case "!!bool", "!!int", "!!float":
+			var v interface{}
+			if err := n.Decode(&v); err != nil {
+				return err
+			}
+			return writeJSONValue(buf, v)
+		default:
+			return writeJSONValue(buf, n.Value)
+		}
+	}
+	return nil
+}
+
+func writeJSONValue(buf *bytes.Buffer, v interface{}) error {
+	encoder := json.NewEncoder(buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1) // Encode 会在末尾追加换行
+	return nil
+}
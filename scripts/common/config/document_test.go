@@ -0,0 +1,238 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+// 带有文件头注释、hook 上方注释（随 hook 一起移动）、行尾注释、空行和非标准缩进的配置，用于检查未改动的部分是否原样保留
+const sampleYAML = `# webhook 配置
+
+# 部署相关的 hook
+- id: deploy # 生产环境
+  execute-command: /opt/deploy.sh
+  command-working-directory: /opt
+  x-owner: ops
+
+# 清理缓存
+- id: clean
+  execute-command:   /opt/clean.sh
+
+- id: ping
+  execute-command: /bin/true
+  response-message: pong
+`
+
+func parseSample(t *testing.T) *Document {
+	t.Helper()
+	d, err := ParseDocument([]byte(sampleYAML), FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func documentText(t *testing.T, d *Document) string {
+	t.Helper()
+	data, err := d.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestDocumentUnchanged(t *testing.T) {
+	d := parseSample(t)
+	if got := documentText(t, d); got != sampleYAML {
+		t.Errorf("未修改的文档序列化后与原文不同:\n%s", got)
+	}
+	if ids := strings.Join(d.IDs(), ","); ids != "deploy,clean,ping" {
+		t.Errorf("IDs() = %s", ids)
+	}
+}
+
+func TestDocumentMove(t *testing.T) {
+	cases := []struct {
+		id    string
+		index int
+		want  string
+	}{
+		{"ping", 0, `# webhook 配置
+
+- id: ping
+  execute-command: /bin/true
+  response-message: pong
+
+# 部署相关的 hook
+- id: deploy # 生产环境
+  execute-command: /opt/deploy.sh
+  command-working-directory: /opt
+  x-owner: ops
+
+# 清理缓存
+- id: clean
+  execute-command:   /opt/clean.sh
+`},
+		{"deploy", 99, `# webhook 配置
+
+# 清理缓存
+- id: clean
+  execute-command:   /opt/clean.sh
+
+- id: ping
+  execute-command: /bin/true
+  response-message: pong
+
+# 部署相关的 hook
+- id: deploy # 生产环境
+  execute-command: /opt/deploy.sh
+  command-working-directory: /opt
+  x-owner: ops
+`},
+		{"clean", -1, `# webhook 配置
+
+# 清理缓存
+- id: clean
+  execute-command:   /opt/clean.sh
+
+# 部署相关的 hook
+- id: deploy # 生产环境
+  execute-command: /opt/deploy.sh
+  command-working-directory: /opt
+  x-owner: ops
+
+- id: ping
+  execute-command: /bin/true
+  response-message: pong
+`},
+	}
+	for _, tc := range cases {
+		d := parseSample(t)
+		if err := d.Move(tc.id, tc.index); err != nil {
+			t.Fatal(err)
+		}
+		if got := documentText(t, d); got != tc.want {
+			t.Errorf("Move(%q, %d) 后:\n%s\n期望:\n%s", tc.id, tc.index, got, tc.want)
+		}
+	}
+
+	if err := parseSample(t).Move("missing", 0); err == nil {
+		t.Error("移动不存在的 hook 没有报错")
+	}
+}
+
+func TestDocumentRename(t *testing.T) {
+	d := parseSample(t)
+	if err := d.Rename("deploy", "release"); err != nil {
+		t.Fatal(err)
+	}
+	got := documentText(t, d)
+	// 行尾注释和未知字段保留，其余 hook 的原文不变
+	if !strings.Contains(got, "- id: release # 生产环境\n") || !strings.Contains(got, "x-owner: ops") {
+		t.Errorf("重命名后 hook 的注释或字段丢失:\n%s", got)
+	}
+	if !strings.Contains(got, "# 清理缓存\n- id: clean\n  execute-command:   /opt/clean.sh\n") {
+		t.Errorf("未修改的 hook 原文被改动:\n%s", got)
+	}
+
+	for _, tc := range []struct{ oldID, newID string }{
+		{"clean", "ping"},
+		{"clean", ""},
+		{"missing", "other"},
+	} {
+		if err := parseSample(t).Rename(tc.oldID, tc.newID); err == nil {
+			t.Errorf("Rename(%q, %q) 没有报错", tc.oldID, tc.newID)
+		}
+	}
+}
+
+func TestDocumentUpdate(t *testing.T) {
+	d := parseSample(t)
+	hook, err := d.Get("deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hook.ExecuteCommand = "/opt/deploy-v2.sh"
+	hook.CommandWorkingDirectory = ""
+	hook.ResponseMessage = "ok"
+	if err := d.Update("deploy", *hook); err != nil {
+		t.Fatal(err)
+	}
+	want := `# webhook 配置
+
+# 部署相关的 hook
+- id: deploy # 生产环境
+  execute-command: /opt/deploy-v2.sh
+  x-owner: ops
+  response-message: ok
+
+# 清理缓存
+- id: clean
+  execute-command:   /opt/clean.sh
+
+- id: ping
+  execute-command: /bin/true
+  response-message: pong
+`
+	if got := documentText(t, d); got != want {
+		t.Errorf("Update 后:\n%s\n期望:\n%s", got, want)
+	}
+
+	// hook.ID 与 id 不同时同时重命名，新 id 不能与其他 hook 重复
+	hook, _ = d.Get("ping")
+	hook.ID = "clean"
+	if err := d.Update("ping", *hook); err == nil {
+		t.Error("更新为已存在的 id 没有报错")
+	}
+	hook.ID = "health"
+	if err := d.Update("ping", *hook); err != nil {
+		t.Fatal(err)
+	}
+	if ids := strings.Join(d.IDs(), ","); ids != "deploy,clean,health" {
+		t.Errorf("IDs() = %s", ids)
+	}
+}
+
+func TestDocumentAddRemove(t *testing.T) {
+	d := parseSample(t)
+	if err := d.Remove("clean"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Add(Hook{ID: "new", ExecuteCommand: "/bin/new"}, "新增的 hook"); err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Add(Hook{ID: "deploy"}, ""); err == nil {
+		t.Error("添加重复的 id 没有报错")
+	}
+	got := documentText(t, d)
+	if strings.Contains(got, "清理缓存") || strings.Contains(got, "clean.sh") {
+		t.Errorf("删除 hook 后仍留有它的注释或内容:\n%s", got)
+	}
+	if !strings.HasSuffix(got, "\n\n# 新增的 hook\n- id: new\n  execute-command: /bin/new\n") {
+		t.Errorf("新增的 hook 没有按原文的空行分隔追加在末尾:\n%s", got)
+	}
+}
+
+func TestDocumentJSON(t *testing.T) {
+	d, err := ParseDocument([]byte(`[{"id": "b", "execute-command": "/bin/b"}, {"id": "a", "execute-command": "/bin/a"}]`), FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := d.Move("a", 0); err != nil {
+		t.Fatal(err)
+	}
+	want := `[
+  {
+    "id": "a",
+    "execute-command": "/bin/a"
+  },
+  {
+    "id": "b",
+    "execute-command": "/bin/b"
+  }
+]
+`
+	if got := documentText(t, d); got != want {
+		t.Errorf("JSON 文档移动后:\n%s\n期望:\n%s", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Format 是 hooks 文件的格式，webhook 同时支持 YAML 和 JSON
+type Format string
+
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+// Other 返回另一种格式，用于格式转换
+func (f Format) Other() Format {
+	if f == FormatJSON {
+		return FormatYAML
+	}
+	return FormatJSON
+}
+
+// DetectFormat 判断配置内容的格式：内容是合法的 JSON 时为 JSON；
+// 扩展名为 .json 时也按 JSON 处理，以便给出 JSON 的语法错误；其余按 YAML 处理
+func DetectFormat(path string, data []byte) Format {
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) > 0 && (trimmed[0] == '[' || trimmed[0] == '{') && json.Valid(trimmed) {
+		return FormatJSON
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		return FormatJSON
+	}
+	return FormatYAML
+}
+
+// Validate 检查配置内容的语法，JSON 语法错误附带行号和列号
+func Validate(data []byte, format Format) error {
+	var temp interface{}
+	if format == FormatJSON {
+		if err := json.Unmarshal(data, &temp); err != nil {
+			return jsonError(data, err)
+		}
+		return nil
+	}
+	return yaml.Unmarshal(data, &temp)
+}
+
+// jsonError 把 encoding/json 错误中的字节偏移转换为行列号
+func jsonError(data []byte, err error) error {
+	var offset int64
+	switch e := err.(type) {
+	case *json.SyntaxError:
+		offset = e.Offset
+	case *json.UnmarshalTypeError:
+		offset = e.Offset
+	default:
+		return err
+	}
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	column := len(before) - bytes.LastIndexByte(before, '\n')
+	return fmt.Errorf("line %d, column %d: %v", line, column, err)
+}
+
+// ParseFormat 按指定格式解析配置内容
+func ParseFormat(data []byte, format Format) (Config, error) {
+	var config Config
+	if format == FormatJSON {
+		if err := json.Unmarshal(data, &config); err != nil {
+			return nil, jsonError(data, err)
+		}
+		return config, nil
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// MarshalFormat 把 hook 列表序列化为指定格式
+func MarshalFormat(hooks []Hook, format Format) ([]byte, error) {
+	if format != FormatJSON {
+		return marshalYAML(hooks)
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if hooks == nil {
+		hooks = []Hook{}
+	}
+	if err := encoder.Encode(hooks); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Convert 把配置内容转换为 to 格式，保留键的顺序和 Hook 结构体中没有定义的字段。
+// JSON 没有注释，转换为 JSON 时 YAML 注释会丢失
+func Convert(data []byte, to Format) ([]byte, error) {
+	doc, err := ParseDocument(data, DetectFormat("", data))
+	if err != nil {
+		return nil, err
+	}
+	doc.SetFormat(to)
+	return doc.Bytes()
+}
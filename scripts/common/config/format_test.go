@@ -0,0 +1,87 @@
+package config
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	cases := []struct {
+		path, data string
+		want       Format
+	}{
+		{"hooks.yaml", `[{"id": "a"}]`, FormatJSON},
+		{"hooks.yaml", "  \n{\"id\": \"a\"}\n", FormatJSON},
+		{"hooks.json", "- id: a\n", FormatJSON}, // 扩展名为 .json 时按 JSON 报告语法错误
+		{"hooks.json", `[{"id": "a"`, FormatJSON},
+		{"hooks.yaml", `[{"id": "a"`, FormatYAML},
+		{"hooks.yaml", "- id: a\n", FormatYAML},
+		{"hooks.yaml", "[a, b]", FormatYAML}, // YAML 的流式写法不是合法的 JSON
+		{"", "", FormatYAML},
+	}
+	for _, tc := range cases {
+		if got := DetectFormat(tc.path, []byte(tc.data)); got != tc.want {
+			t.Errorf("DetectFormat(%q, %q) = %s，应为 %s", tc.path, tc.data, got, tc.want)
+		}
+	}
+}
+
+// TestConvertRoundTrip 检查 YAML 转为 JSON 再转回 YAML 后 hook 的内容、键的顺序和未定义的字段都不变，只丢失注释
+func TestConvertRoundTrip(t *testing.T) {
+	data, err := Convert([]byte(sampleYAML), FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if DetectFormat("", data) != FormatJSON {
+		t.Fatalf("转换结果不是 JSON:\n%s", data)
+	}
+	text := string(data)
+	if strings.Contains(text, "#") {
+		t.Errorf("JSON 中有注释:\n%s", text)
+	}
+	if i, j := strings.Index(text, `"command-working-directory"`), strings.Index(text, `"x-owner"`); i < 0 || j < i {
+		t.Errorf("JSON 中的键顺序不对或丢失了 x-owner:\n%s", text)
+	}
+
+	back, err := Convert(data, FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if DetectFormat("", back) != FormatYAML {
+		t.Fatalf("转换回来的结果不是 YAML:\n%s", back)
+	}
+	want, err := Parse([]byte(sampleYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string][]byte{"JSON": data, "YAML": back} {
+		got, err := ParseFormat(content, DetectFormat("", content))
+		if err != nil {
+			t.Fatalf("%s 无法解析: %v", name, err)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s 中的 hook 与原文不同:\n%+v\n%+v", name, got, want)
+		}
+	}
+	if !strings.Contains(string(back), "x-owner: ops") {
+		t.Errorf("转换回来后丢失了 x-owner:\n%s", back)
+	}
+
+	// 已经是目标格式时内容不变
+	same, err := Convert([]byte(sampleYAML), FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(same) != sampleYAML {
+		t.Errorf("YAML 转为 YAML 后内容改变:\n%s", same)
+	}
+}
+
+func TestConvertInvalid(t *testing.T) {
+	for _, data := range []string{`[{"id": "a"`, "- id: [", `{"id": "a"}`} {
+		if out, err := Convert([]byte(data), FormatYAML); err == nil {
+			t.Errorf("Convert(%q) 没有报错，结果为 %q", data, out)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"webhook-ui/common/env"
+	"webhook-ui/common/i18n"
+)
+
+// DefaultHooks 是未设置 HOOKS 环境变量时使用的 hooks 文件，所有脚本和独立服务相同
+const DefaultHooks = "/etc/webhook/hooks.yaml"
+
+// HooksSpec 返回 HOOKS 环境变量，未设置时为 DefaultHooks
+func HooksSpec() string {
+	return env.Str("HOOKS", DefaultHooks)
+}
+
+// Source 是一个 hooks 文件及其解析结果。webhook 可以通过多个 -hooks 参数加载多个文件
+type Source struct {
+	Path     string
+	Hooks    Config
+	Err      error  // 读取或解析失败；文件不存在时 Err 满足 os.IsNotExist
+	Raw      string // 文件原文
+	Rendered string // 模板模式下渲染后的内容，渲染失败或未开启模板模式时为空
+
+	Disabled    *DisabledState // 停用的 hook 及停用/启用记录，不会被 webhook 加载
+	DisabledErr error          // 旁路文件读取或解析失败
+}
+
+// ResolvePaths 解析 HOOKS 环境变量：支持逗号分隔的多个文件，以及 hooks.d/*.yaml 这样的通配符。
+// 通配符展开后按文件名排序，忽略隐藏文件和目录，重复的路径只保留第一次出现的位置
+func ResolvePaths(spec string) ([]string, error) {
+	var paths []string
+	seen := map[string]bool{}
+	add := func(path string) {
+		path = filepath.Clean(path)
+		if !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if !strings.ContainsAny(item, "*?[") {
+			add(item)
+			continue
+		}
+		matches, err := filepath.Glob(item)
+		if err != nil {
+			return nil, i18n.Errorf("无效的通配符 %q: %v", item, err)
+		}
+		sort.Strings(matches)
+		for _, m := range matches {
+			// 忽略以 . 开头的文件（锁文件、备份目录等）和目录
+			if strings.HasPrefix(filepath.Base(m), ".") {
+				continue
+			}
+			if info, err := os.Stat(m); err == nil && info.IsDir() {
+				continue
+			}
+			add(m)
+		}
+	}
+	if len(paths) == 0 {
+		return nil, i18n.Errorf("HOOKS 中没有匹配的 hooks 文件: %q", spec)
+	}
+	return paths, nil
+}
+
+// LoadSources 依次读取每个 hooks 文件，单个文件出错不影响其他文件
+func LoadSources(paths []string) []Source {
+	sources := make([]Source, 0, len(paths))
+	for _, path := range paths {
+		sources = append(sources, loadSource(path))
+	}
+	return sources
+}
+
+func loadSource(path string) Source {
+	source := Source{Path: path}
+	source.Disabled, source.DisabledErr = LoadDisabled(path)
+	if source.Disabled == nil {
+		source.Disabled = &DisabledState{}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		source.Err = err
+		return source
+	}
+	source.Raw = string(data)
+	if TemplateEnabled() {
+		if data, err = Render(data); err != nil {
+			source.Err = i18n.Errorf("模板渲染失败: %v", err)
+			return source
+		}
+		source.Rendered = string(data)
+	}
+	source.Hooks, source.Err = ParseFormat(data, DetectFormat(path, data))
+	if source.Err != nil && !TemplateEnabled() && strings.Contains(source.Raw, "{{") {
+		source.Err = i18n.Errorf("%v（文件中包含模板表达式，webhook 使用 -template 参数时请设置 TEMPLATE=true）", source.Err)
+	}
+	return source
+}
+
+// LoadAll 按 HOOKS 的写法解析并读取所有 hooks 文件
+func LoadAll(spec string) ([]Source, error) {
+	paths, err := ResolvePaths(spec)
+	if err != nil {
+		return nil, err
+	}
+	return LoadSources(paths), nil
+}
+
+// Merge 把所有文件中的 hook 合并为 webhook 实际加载的完整配置
+func Merge(sources []Source) Config {
+	var merged Config
+	for _, s := range sources {
+		merged = append(merged, s.Hooks...)
+	}
+	return merged
+}
+
+// Contains 判断 path 是否为其中一个 hooks 文件
+func Contains(sources []Source, path string) bool {
+	path = filepath.Clean(path)
+	for _, s := range sources {
+		if s.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+// DuplicateIDs 返回在多个文件（或同一文件中多次）出现的 hook id，以及它们所在的文件。
+// webhook 遇到重复的 id 会拒绝加载
+func DuplicateIDs(sources []Source) map[string][]string {
+	locations := map[string][]string{}
+	for _, s := range sources {
+		for _, h := range s.Hooks {
+			locations[h.ID] = append(locations[h.ID], s.Path)
+		}
+	}
+	duplicates := map[string][]string{}
+	for id, paths := range locations {
+		if len(paths) > 1 {
+			duplicates[id] = paths
+		}
+	}
+	return duplicates
+}
+
+// ReplaceSource 返回把 path 对应文件的内容替换为 hooks 后的文件列表，用于在保存前检查整体配置
+func ReplaceSource(sources []Source, path string, hooks Config) []Source {
+	path = filepath.Clean(path)
+	replaced := make([]Source, 0, len(sources))
+	for _, s := range sources {
+		if s.Path == path {
+			s = Source{Path: path, Hooks: hooks, Disabled: s.Disabled}
+		}
+		replaced = append(replaced, s)
+	}
+	return replaced
+}
+
+// ReplaceDisabled 返回把 path 对应文件的停用状态替换为 state 后的文件列表
+func ReplaceDisabled(sources []Source, path string, state *DisabledState) []Source {
+	path = filepath.Clean(path)
+	replaced := make([]Source, 0, len(sources))
+	for _, s := range sources {
+		if s.Path == path {
+			s.Disabled = state
+		}
+		replaced = append(replaced, s)
+	}
+	return replaced
+}
+
+// DisabledIDs 返回所有被停用的 hook id 及其所在的 hooks 文件
+func DisabledIDs(sources []Source) map[string]string {
+	ids := map[string]string{}
+	for _, s := range sources {
+		if s.Disabled == nil {
+			continue
+		}
+		for _, h := range s.Disabled.Hooks {
+			ids[h.ID] = s.Path
+		}
+	}
+	return ids
+}
+
+// IsNotExist 判断文件是否不存在（新建的 hooks 文件）
+func (s Source) IsNotExist() bool {
+	return s.Err != nil && os.IsNotExist(s.Err)
+}
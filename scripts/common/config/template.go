@@ -0,0 +1,63 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// TemplateEnabled 对应 webhook 的 -template 参数：环境变量 TEMPLATE=true 时，
+// hooks 文件是 Go 模板，需要先渲染再按 YAML/JSON 解析
+func TemplateEnabled() bool {
+	enabled, _ := strconv.ParseBool(os.Getenv("TEMPLATE"))
+	return enabled
+}
+
+// TemplateFuncs 与 webhook 渲染 hooks 模板时使用的函数相同
+var TemplateFuncs = template.FuncMap{
+	"cat":        cat,
+	"credential": credential,
+	"getenv":     os.Getenv,
+}
+
+// cat 返回文件内容（去掉末尾换行），读取失败时返回空字符串
+func cat(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(string(data), "\n")
+}
+
+// credential 读取 systemd 通过 $CREDENTIALS_DIRECTORY 传入的凭据
+func credential(name string) string {
+	dir := os.Getenv("CREDENTIALS_DIRECTORY")
+	if dir == "" {
+		return ""
+	}
+	return cat(filepath.Join(dir, name))
+}
+
+// Render 按 webhook 的方式渲染 hooks 模板
+func Render(data []byte) ([]byte, error) {
+	tmpl, err := template.New("hooks").Funcs(TemplateFuncs).Parse(string(data))
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, nil); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Expand 在模板模式下返回渲染后的内容，否则原样返回。文件中保存的始终是模板原文
+func Expand(data []byte) ([]byte, error) {
+	if !TemplateEnabled() {
+		return data, nil
+	}
+	return Render(data)
+}
@@ -0,0 +1,12 @@
+// Package env 读取各脚本共用的环境变量
+package env
+
+import "os"
+
+// Str 从环境变量获取字符串，如果不存在则返回默认值。环境变量设置为空时返回空字符串
+func Str(key, defaultValue string) string {
+	if value, exists := os.LookupEnv(key); exists {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,45 @@
+//go:build !windows
+
+// Package filelock 提供跨进程的排他锁。脚本模式下每个请求是一个独立的进程，
+// 修改共享的文件（hooks 文件、分块上传的暂存会话）前都需要先加锁
+package filelock
+
+import (
+	"os"
+	"syscall"
+)
+
+// Lock 对锁文件 path 加排他锁（文件不存在时创建），直到返回的函数被调用
+func Lock(path string) (func(), error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, nil
+}
+
+// TryLock 与 Lock 相同，但锁已被其他进程或同一进程中的其他文件描述符持有时不等待，返回 false
+func TryLock(path string) (func(), bool, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o644)
+	if err != nil {
+		return nil, false, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return func() {
+		syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+		f.Close()
+	}, true, nil
+}
@@ -0,0 +1,13 @@
+//go:build windows
+
+package filelock
+
+// Lock 在 Windows 上不加锁
+func Lock(path string) (func(), error) {
+	return func() {}, nil
+}
+
+// TryLock 在 Windows 上不加锁
+func TryLock(path string) (func(), bool, error) {
+	return func() {}, true, nil
+}
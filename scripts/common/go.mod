@@ -0,0 +1,8 @@
+module webhook-ui/common
+
+go 1.22
+
+require (
+	golang.org/x/sys v0.30.0
+	gopkg.in/yaml.v3 v3.0.1
+)
@@ -0,0 +1,150 @@
+// Package history 记录经过独立服务转发到 webhook 的请求和响应，供页面和 API 查看 hook 的执行记录。
+// 记录以 JSON Lines 格式追加到一个文件中，超过大小上限时把当前文件改名为 <文件>.1 后重新开始，最多保留两份
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"webhook-ui/common/env"
+)
+
+// BodyLimit 是每条记录中保存的请求体和响应体的最大字节数，超出部分截断
+const BodyLimit = 64 << 10 // 64 KiB
+
+// redactedHeaders 是不记录的请求头，避免把凭据写入记录文件
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+}
+
+// Path 返回记录文件：环境变量 HISTORY_FILE，默认为 hooks 文件所在目录下的 .webhook-history.jsonl。
+// HISTORY_FILE 设置为空时不记录
+func Path(hooksPath string) string {
+	return env.Str("HISTORY_FILE", filepath.Join(filepath.Dir(hooksPath), ".webhook-history.jsonl"))
+}
+
+// Entry 是一次请求的记录
+type Entry struct {
+	RequestID  string            `json:"request_id"`
+	Time       time.Time         `json:"time"`
+	Hook       string            `json:"hook"`
+	Method     string            `json:"method"`
+	Path       string            `json:"path"`
+	Query      string            `json:"query,omitempty"`
+	RemoteAddr string            `json:"remote_addr"`
+	Headers    map[string]string `json:"headers,omitempty"`
+	Body       string            `json:"body,omitempty"`
+	Status     int               `json:"status"`
+	Response   string            `json:"response,omitempty"`
+	DurationMS int64             `json:"duration_ms"`
+	Truncated  bool              `json:"truncated,omitempty"` // 请求体或响应体超过 BodyLimit 被截断
+	Error      string            `json:"error,omitempty"`     // 转发失败的原因
+}
+
+// Headers 返回用于记录的请求头，同名的多个值用逗号连接，凭据类请求头被去掉
+func Headers(h http.Header) map[string]string {
+	out := map[string]string{}
+	for name, values := range h {
+		if redactedHeaders[name] {
+			continue
+		}
+		out[name] = strings.Join(values, ", ")
+	}
+	return out
+}
+
+// Log 是记录文件，同一进程内的写入互斥
+type Log struct {
+	Path     string
+	MaxBytes int64 // 文件超过该大小时轮换，0 表示 10 MiB
+
+	mu sync.Mutex
+}
+
+// Append 追加一条记录
+func (l *Log) Append(e Entry) error {
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	maxBytes := l.MaxBytes
+	if maxBytes <= 0 {
+		maxBytes = 10 << 20
+	}
+	if info, err := os.Stat(l.Path); err == nil && info.Size()+int64(len(line)) > maxBytes {
+		if err := os.Rename(l.Path, l.Path+".1"); err != nil {
+			return err
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(l.Path), 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(l.Path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(line); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Read 返回 path（及轮换出的 <path>.1）中的记录，最新的在前。hook 不为空时只返回该 hook 的记录，
+// limit 大于 0 时最多返回 limit 条。记录文件不存在时返回空列表
+func Read(path, hook string, limit int) ([]Entry, error) {
+	var entries []Entry
+	for _, file := range []string{path, path + ".1"} {
+		fileEntries, err := readFile(file, hook)
+		if err != nil {
+			return nil, err
+		}
+		// 文件中按时间先后排列，倒序后追加，当前文件在前
+		for i := len(fileEntries) - 1; i >= 0; i-- {
+			entries = append(entries, fileEntries[i])
+			if limit > 0 && len(entries) == limit {
+				return entries, nil
+			}
+		}
+	}
+	return entries, nil
+}
+
+func readFile(path, hook string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []Entry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16*BodyLimit) // 二进制内容转义为 JSON 后最多变为原来的 6 倍
+	for line := 1; scanner.Scan(); line++ {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			// 写入中途被中断的行只跳过，不影响其他记录
+			fmt.Fprintf(os.Stderr, "Warning: skipping invalid history line %s:%d: %v\n", path, line, err)
+			continue
+		}
+		if hook == "" || e.Hook == hook {
+			entries = append(entries, e)
+		}
+	}
+	return entries, scanner.Err()
+}
@@ -0,0 +1,73 @@
+package history
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"webhook-ui/common/config"
+)
+
+// Redacted 替换记录中被隐去的请求头和查询参数的值
+const Redacted = "[REDACTED]"
+
+// Secrets 是 hook 的触发规则中 match 规则比较的请求头和查询参数。它们通常是共享密钥、令牌或签名，
+// 记录前用 Entry.Redact 隐去
+type Secrets struct {
+	Headers map[string]bool // 规范化后的请求头名称，如 X-Token
+	Query   map[string]bool
+}
+
+// SecretsOf 收集 hook 触发规则（包括 and、or、not 中嵌套的规则）中 source 为 header 或 url 的 match 参数
+func SecretsOf(hook config.Hook) Secrets {
+	s := Secrets{Headers: map[string]bool{}, Query: map[string]bool{}}
+	s.collect(hook.TriggerRule)
+	return s
+}
+
+func (s Secrets) collect(r *config.Rules) {
+	if r == nil {
+		return
+	}
+	if r.And != nil {
+		for i := range *r.And {
+			s.collect(&(*r.And)[i])
+		}
+	}
+	if r.Or != nil {
+		for i := range *r.Or {
+			s.collect(&(*r.Or)[i])
+		}
+	}
+	if r.Not != nil {
+		s.collect((*config.Rules)(r.Not))
+	}
+	if r.Match != nil && r.Match.Parameter.Name != "" {
+		switch r.Match.Parameter.Source {
+		case "header":
+			s.Headers[http.CanonicalHeaderKey(r.Match.Parameter.Name)] = true
+		case "url":
+			s.Query[r.Match.Parameter.Name] = true
+		}
+	}
+}
+
+// Redact 把 e 中属于 secrets 的请求头和查询参数的值替换为 Redacted，查询参数的顺序保持不变
+func (e *Entry) Redact(secrets Secrets) {
+	for name := range e.Headers {
+		if secrets.Headers[http.CanonicalHeaderKey(name)] {
+			e.Headers[name] = Redacted
+		}
+	}
+	if e.Query == "" || len(secrets.Query) == 0 {
+		return
+	}
+	parts := strings.Split(e.Query, "&")
+	for i, part := range parts {
+		key, _, _ := strings.Cut(part, "=")
+		if name, err := url.QueryUnescape(key); err == nil && secrets.Query[name] {
+			parts[i] = key + "=" + url.QueryEscape(Redacted)
+		}
+	}
+	e.Query = strings.Join(parts, "&")
+}
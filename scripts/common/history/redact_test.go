@@ -0,0 +1,45 @@
+package history
+
+import (
+	"net/http"
+	"testing"
+
+	"webhook-ui/common/config"
+)
+
+func TestRedact(t *testing.T) {
+	// 嵌套在 and、or、not 中的 match 规则都要收集，payload 和 request 来源不在请求头和查询参数中
+	match := func(source, name string) config.Rules {
+		return config.Rules{Match: &config.MatchRule{Type: "value", Value: "s3cret", Parameter: config.Argument{Source: source, Name: name}}}
+	}
+	not := config.NotRule(match("url", "sig"))
+	hook := config.Hook{ID: "deploy", TriggerRule: &config.Rules{And: &config.AndRule{
+		match("header", "x-token"),
+		{Or: &config.OrRule{match("url", "token"), {Not: &not}}},
+		match("payload", "ref"),
+	}}}
+
+	h := http.Header{}
+	h.Set("X-Token", "s3cret")
+	h.Set("User-Agent", "curl")
+	h.Set("Authorization", "Bearer abc")
+	e := Entry{Headers: Headers(h), Query: "a=1&token=s3cret&sig=x%2By&to%6Ben=again&tokens=keep"}
+	e.Redact(SecretsOf(hook))
+
+	if e.Headers["X-Token"] != Redacted || e.Headers["User-Agent"] != "curl" {
+		t.Errorf("请求头为 %v", e.Headers)
+	}
+	if _, ok := e.Headers["Authorization"]; ok {
+		t.Error("Authorization 请求头被记录")
+	}
+	if want := "a=1&token=%5BREDACTED%5D&sig=%5BREDACTED%5D&to%6Ben=%5BREDACTED%5D&tokens=keep"; e.Query != want {
+		t.Errorf("查询参数为 %s，期望 %s", e.Query, want)
+	}
+
+	// 没有触发规则的 hook 不改动记录
+	plain := Entry{Headers: map[string]string{"X-Token": "v"}, Query: "token=v"}
+	plain.Redact(SecretsOf(config.Hook{ID: "plain"}))
+	if plain.Headers["X-Token"] != "v" || plain.Query != "token=v" {
+		t.Errorf("没有触发规则时记录被改动: %+v", plain)
+	}
+}
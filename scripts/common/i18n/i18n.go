@@ -0,0 +1,201 @@
+// Package i18n 提供页面文字的翻译。页面和各个包中的文字以简体中文（zh-CN）书写，
+// locales/<语言>.yaml 是消息目录：键是原文，值是译文。
+//
+// 带 %s、%d、%v、%q 的键是格式字符串，先翻译格式字符串再代入参数（Printer.Sprintf），
+// 参数中的文件名、hook id 等用户数据原样输出。pipeline、upload 等包不知道页面语言，
+// 给用户看的说明用 M 和 Errorf 生成 Message，由页面按语言翻译
+package i18n
+
+import (
+	"embed"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed locales/*.yaml
+var locales embed.FS
+
+// Default 是原文的语言，浏览器没有给出支持的语言时使用
+const Default = "zh-CN"
+
+// CookieName 是保存用户选择的语言的 cookie，优先于 Accept-Language
+const CookieName = "webhook_ui_lang"
+
+// Languages 是支持的语言，Names 是它们在语言切换中的名称
+var (
+	Languages = []string{"zh-CN", "en"}
+	Names     = map[string]string{"zh-CN": "简体中文", "en": "English"}
+)
+
+// catalog 是一种语言的消息目录
+type catalog struct {
+	messages map[string]string
+}
+
+var (
+	catalogMu sync.Mutex
+	catalogs  = map[string]*catalog{}
+)
+
+// load 读取 lang 的消息目录，缺少目录文件时返回空目录（即显示原文）
+func load(lang string) *catalog {
+	catalogMu.Lock()
+	defer catalogMu.Unlock()
+	if c, ok := catalogs[lang]; ok {
+		return c
+	}
+	c := &catalog{messages: map[string]string{}}
+	catalogs[lang] = c
+	data, err := locales.ReadFile("locales/" + lang + ".yaml")
+	if err != nil {
+		return c
+	}
+	if err := yaml.Unmarshal(data, &c.messages); err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing message catalog %s: %v\n", lang, err)
+		return c
+	}
+	return c
+}
+
+// Printer 把原文翻译为一种语言
+type Printer struct {
+	lang    string
+	catalog *catalog
+}
+
+// For 返回 lang 的 Printer，不支持的语言使用 Default
+func For(lang string) Printer {
+	if !Supported(lang) {
+		lang = Default
+	}
+	return Printer{lang: lang, catalog: load(lang)}
+}
+
+// Lang 返回 Printer 的语言
+func (p Printer) Lang() string {
+	return p.lang
+}
+
+// T 翻译 message。多行文字逐行翻译；目录中没有的文字（如 YAML 解析器的错误）原样返回。
+// message 必须是固定的原文，含有用户数据的文字使用 Sprintf
+func (p Printer) T(message string) string {
+	if p.catalog == nil || message == "" {
+		return message
+	}
+	if translation, ok := p.catalog.messages[message]; ok {
+		return translation
+	}
+	if strings.Contains(message, "\n") {
+		lines := strings.Split(message, "\n")
+		for i, line := range lines {
+			lines[i] = p.T(line)
+		}
+		return strings.Join(lines, "\n")
+	}
+	return message
+}
+
+// Sprintf 先翻译格式字符串 format 再代入参数。参数原样输出，只有 Message 和 Errorf 返回的错误会翻译
+func (p Printer) Sprintf(format string, args ...any) string {
+	translated := make([]any, len(args))
+	for i, arg := range args {
+		switch arg := arg.(type) {
+		case Message:
+			translated[i] = p.Message(arg)
+		case *Error:
+			translated[i] = p.Message(arg.Message)
+		default:
+			translated[i] = arg
+		}
+	}
+	return fmt.Sprintf(p.T(format), translated...)
+}
+
+// Message 翻译 m，没有参数时与 T 相同
+func (p Printer) Message(m Message) string {
+	if len(m.Args) == 0 {
+		return p.T(m.Format)
+	}
+	return p.Sprintf(m.Format, m.Args...)
+}
+
+// Text 翻译页面模板中的 T：固定的原文、Message 或 Errorf 返回的错误，其他错误和值原样输出
+func (p Printer) Text(v any) string {
+	switch v := v.(type) {
+	case string:
+		return p.T(v)
+	case Message:
+		return p.Message(v)
+	case *Error:
+		return p.Message(v.Message)
+	case error:
+		return v.Error()
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// Supported 返回是否支持 lang
+func Supported(lang string) bool {
+	for _, l := range Languages {
+		if l == lang {
+			return true
+		}
+	}
+	return false
+}
+
+// match 把浏览器的语言标签按主语言对应到支持的语言，如 en-US -> en、zh-Hans-CN -> zh-CN
+func match(tag string) string {
+	primary, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+	for _, l := range Languages {
+		if p, _, _ := strings.Cut(strings.ToLower(l), "-"); p == primary {
+			return l
+		}
+	}
+	return ""
+}
+
+// Negotiate 选择页面语言：cookie 是 Cookie 请求头，其中 CookieName 的值优先；
+// 否则按 Accept-Language 请求头中的权重选择第一个支持的语言；都没有时使用 Default
+func Negotiate(cookie, acceptLanguage string) string {
+	for _, part := range strings.Split(cookie, ";") {
+		name, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == CookieName {
+			if l := match(value); l != "" {
+				return l
+			}
+		}
+	}
+
+	type choice struct {
+		lang string
+		q    float64
+	}
+	var choices []choice
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if f, err := strconv.ParseFloat(value, 64); err == nil {
+				q = f
+			}
+		}
+		if l := match(tag); l != "" && q > 0 {
+			choices = append(choices, choice{l, q})
+		}
+	}
+	sort.SliceStable(choices, func(i, j int) bool { return choices[i].q > choices[j].q })
+	if len(choices) > 0 {
+		return choices[0].lang
+	}
+	return Default
+}
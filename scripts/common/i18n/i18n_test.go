@@ -0,0 +1,239 @@
+package i18n
+
+import (
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+)
+
+func TestT(t *testing.T) {
+	en := For("en")
+	cases := []struct{ in, want string }{
+		{"保存成功", "Saved"},
+		{"删除", "Delete"},
+		{"", ""},
+		// 目录中没有的文字原样返回
+		{"yaml: line 3: did not find expected key", "yaml: line 3: did not find expected key"},
+		// 多行文字逐行翻译，没有的行保留原文
+		{"保存成功\nyaml: line 3\n删除", "Saved\nyaml: line 3\nDelete"},
+	}
+	for _, tc := range cases {
+		if got := en.T(tc.in); got != tc.want {
+			t.Errorf("T(%q) = %q，期望 %q", tc.in, got, tc.want)
+		}
+	}
+	if got := For("zh-CN").T("删除"); got != "删除" {
+		t.Errorf("zh-CN 的 T 应返回原文，实际为 %q", got)
+	}
+	if got := For("fr").Lang(); got != Default {
+		t.Errorf("不支持的语言应使用 %s，实际为 %s", Default, got)
+	}
+}
+
+func TestSprintf(t *testing.T) {
+	en := For("en")
+	// 先翻译格式字符串再代入参数：参数中的 hook id 恰好是目录中的原文时也不翻译
+	if got := en.Sprintf("确定删除 hook %s 吗？", "删除"); got != "Delete hook 删除?" {
+		t.Errorf("Sprintf = %q", got)
+	}
+	// Message 和 Errorf 返回的错误作为参数时翻译，其他错误原样输出
+	if got := en.Sprintf("%s：%s", M("保存成功"), Errorf("编辑 hook %s", "删除")); got != "Saved: Edit hook 删除" {
+		t.Errorf("Sprintf = %q", got)
+	}
+	if got := en.Sprintf("%s：%s", "删除", errors.New("保存成功")); got != "删除: 保存成功" {
+		t.Errorf("Sprintf = %q", got)
+	}
+}
+
+func TestMessage(t *testing.T) {
+	en := For("en")
+	m := M("确定删除 hook %s 吗？", "保存成功")
+	if got := en.Message(m); got != "Delete hook 保存成功?" {
+		t.Errorf("Message = %q", got)
+	}
+	if got := m.String(); got != "确定删除 hook 保存成功 吗？" {
+		t.Errorf("String = %q", got)
+	}
+	// JSON 中输出原文，读回后作为整体，不再翻译其中的参数
+	text, _ := m.MarshalText()
+	var back Message
+	back.UnmarshalText(text)
+	if got := en.Message(back); got != string(text) {
+		t.Errorf("读回的 Message 翻译为 %q，应原样输出 %q", got, text)
+	}
+	if !(Message{}).IsZero() || m.IsZero() {
+		t.Error("IsZero 结果错误")
+	}
+
+	cases := []struct {
+		in   any
+		want string
+	}{
+		{"删除", "Delete"},
+		{M("删除"), "Delete"},
+		{Errorf("编辑 hook %s", "删除"), "Edit hook 删除"},
+		{errors.New("删除"), "删除"},
+		{nil, ""},
+		{42, "42"},
+	}
+	for _, tc := range cases {
+		if got := en.Text(tc.in); got != tc.want {
+			t.Errorf("Text(%#v) = %q，期望 %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestErrorfUnwrap(t *testing.T) {
+	err := Errorf("无法读取 %s: %v", "hooks.yaml", os.ErrNotExist)
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Error("errors.Is 没有找到参数中的错误")
+	}
+	if got := err.Error(); got != "无法读取 hooks.yaml: file does not exist" {
+		t.Errorf("Error() = %q", got)
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	cases := []struct{ cookie, accept, want string }{
+		{"", "", Default},
+		{"", "en-US,en;q=0.9", "en"},
+		{"", "fr-FR,zh-Hans-CN;q=0.8,en;q=0.5", "zh-CN"},
+		{"", "zh-CN;q=0.3,en;q=0.7", "en"},
+		{"", "en;q=0,fr", Default},
+		{"other=1; " + CookieName + "=en", "zh-CN", "en"},
+		{CookieName + "=fr", "en", "en"},
+	}
+	for _, tc := range cases {
+		if got := Negotiate(tc.cookie, tc.accept); got != tc.want {
+			t.Errorf("Negotiate(%q, %q) = %s，期望 %s", tc.cookie, tc.accept, got, tc.want)
+		}
+	}
+}
+
+// 译文中每个参数对应的格式动词必须与原文一致，否则 Sprintf 代入参数时会错位或输出 %!(EXTRA ...)。
+// 译文可以用 %[n]s 调整参数顺序
+func TestCatalogVerbs(t *testing.T) {
+	for lang := range Names {
+		for key, value := range load(lang).messages {
+			if k, v := verbs(key), verbs(value); !reflect.DeepEqual(k, v) {
+				t.Errorf("%s: %q 的译文 %q 中各参数的格式动词为 %v，原文为 %v", lang, key, value, v, k)
+			}
+		}
+	}
+}
+
+var verbPattern = regexp.MustCompile(`%(?:\[(\d+)\])?[-+# 0-9.]*([a-zA-Z%])`)
+
+// verbs 返回格式字符串中第 n 个参数使用的格式动词（键为 n），%% 不计入
+func verbs(format string) map[int]string {
+	result := map[int]string{}
+	next := 1
+	for _, m := range verbPattern.FindAllStringSubmatch(format, -1) {
+		if m[2] == "%" {
+			continue
+		}
+		if m[1] != "" {
+			next, _ = strconv.Atoi(m[1])
+		}
+		result[next] = m[2]
+		next++
+	}
+	return result
+}
+
+// scriptsDir 是各个脚本和 common 所在的目录
+var scriptsDir = filepath.Join("..", "..")
+
+// templateText 匹配页面模板中 T 和 Tf 的原文
+var templateText = regexp.MustCompile(`(?:\{\{-?|\()\s*Tf?\s+("(?:[^"\\]|\\.)*")`)
+
+// TestCatalogComplete 检查各个脚本中 i18n.M、i18n.Errorf、Printer 的 T 和 Sprintf 以及页面模板中 T、Tf 的原文都有英文译文
+func TestCatalogComplete(t *testing.T) {
+	messages := load("en").messages
+	check := func(pos, text string) {
+		if _, ok := messages[text]; ok || !hasHan(text) {
+			return
+		}
+		// 多行的原文逐行翻译
+		for _, line := range strings.Split(text, "\n") {
+			if _, ok := messages[line]; !ok && hasHan(line) {
+				t.Errorf("%s: en.yaml 中没有 %q", pos, line)
+			}
+		}
+	}
+	err := filepath.WalkDir(scriptsDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		switch {
+		case d.IsDir():
+			return nil
+		case strings.HasSuffix(path, ".html"):
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			for _, m := range templateText.FindAllSubmatch(data, -1) {
+				if text, err := strconv.Unquote(string(m[1])); err == nil {
+					check(path, text)
+				}
+			}
+		case strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go"):
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, path, nil, 0)
+			if err != nil {
+				return err
+			}
+			ast.Inspect(file, func(n ast.Node) bool {
+				if text, ok := catalogText(n); ok {
+					check(fset.Position(n.Pos()).String(), text)
+				}
+				return true
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+// catalogText 返回 n 是 i18n.M(...)、i18n.Errorf(...) 或 Printer 的 T(...)、Sprintf(...) 时作为原文的字符串常量
+func catalogText(n ast.Node) (string, bool) {
+	call, ok := n.(*ast.CallExpr)
+	if !ok || len(call.Args) == 0 {
+		return "", false
+	}
+	sel, ok := call.Fun.(*ast.SelectorExpr)
+	if !ok {
+		return "", false
+	}
+	pkg, _ := sel.X.(*ast.Ident)
+	switch {
+	case pkg != nil && pkg.Name == "i18n" && (sel.Sel.Name == "M" || sel.Sel.Name == "Errorf"):
+	case (pkg == nil || pkg.Name != "fmt") && (sel.Sel.Name == "T" || sel.Sel.Name == "Sprintf"):
+	default:
+		return "", false
+	}
+	lit, ok := call.Args[0].(*ast.BasicLit)
+	if !ok || lit.Kind != token.STRING {
+		return "", false
+	}
+	text, err := strconv.Unquote(lit.Value)
+	return text, err == nil
+}
+
+// hasHan 返回 s 中是否有汉字，没有汉字的文字（如 %s: %v）不需要翻译
+func hasHan(s string) bool {
+	return strings.ContainsFunc(s, func(r rune) bool { return unicode.Is(unicode.Han, r) })
+}
@@ -0,0 +1,64 @@
+package i18n
+
+import "fmt"
+
+// Message 是一条待翻译的文字：Format 是消息目录中的键，Args 是代入的参数。
+// 不知道页面语言的包（pipeline、reload、upload 等）用它返回给用户看的说明，页面通过 Printer.Message 翻译
+type Message struct {
+	Format string
+	Args   []any
+}
+
+// M 返回由 format 和 args 组成的 Message
+func M(format string, args ...any) Message {
+	return Message{Format: format, Args: args}
+}
+
+// String 返回原文
+func (m Message) String() string {
+	if len(m.Args) == 0 {
+		return m.Format
+	}
+	return fmt.Sprintf(m.Format, m.Args...)
+}
+
+// IsZero 返回 m 是否为空
+func (m Message) IsZero() bool {
+	return m.Format == ""
+}
+
+// MarshalText 输出原文，JSON API 和命令行中的说明仍是字符串
+func (m Message) MarshalText() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText 读入 MarshalText 输出的原文，参数已经代入，不再翻译其中的部分
+func (m *Message) UnmarshalText(text []byte) error {
+	*m = Message{Format: string(text)}
+	return nil
+}
+
+// Error 是 Errorf 返回的错误，页面可以按语言翻译它的说明
+type Error struct {
+	Message
+}
+
+// Errorf 与 fmt.Errorf 相同，但保留格式字符串和参数，供 Printer.Sprintf 翻译。不支持 %w，参数中的错误可通过 errors.Is/As 找到
+func Errorf(format string, args ...any) error {
+	return &Error{M(format, args...)}
+}
+
+func (e *Error) Error() string {
+	return e.String()
+}
+
+// Unwrap 返回参数中的错误
+func (e *Error) Unwrap() []error {
+	var errs []error
+	for _, arg := range e.Args {
+		if err, ok := arg.(error); ok {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
@@ -0,0 +1,148 @@
+package pages
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/pipeline"
+)
+
+// detailChanges 是详情页最多显示的变化条数
+const detailChanges = 20
+
+// 变化的类型
+const (
+	changeAdded   = "added"
+	changeChanged = "changed"
+	changeRemoved = "removed"
+)
+
+// diffLine 是差异中的一行，Op 为 " "（未改动）、"+"（新增）或 "-"（删除）
+type diffLine struct {
+	Op   string
+	Text string
+}
+
+// hookChange 是 hook 在一次保存中的变化。备份保存的是写入前的内容，
+// 因此版本 Version 与它之后的内容之间的差异就是在 Time 那次保存中产生的
+type hookChange struct {
+	Time    time.Time
+	Version string // 保存前的版本号，可通过 POST /api/config/rollback 回滚到这个版本
+	Kind    string // added、changed 或 removed（从文件中删除或被停用）
+	Diff    []diffLine
+}
+
+// hookFragment 返回 data 中 hook id 的原文片段。模板模式下原文无法解析时使用渲染结果；
+// hook 不在 data 中时返回 nil
+func hookFragment(data []byte, format config.Format, id string) ([]byte, error) {
+	doc, err := config.ParseDocument(data, format)
+	if err != nil {
+		rendered, rerr := config.Expand(data)
+		if rerr != nil {
+			return nil, err
+		}
+		if doc, err = config.ParseDocument(rendered, format); err != nil {
+			return nil, err
+		}
+	}
+	if !contains(doc.IDs(), id) {
+		return nil, nil
+	}
+	return doc.Fragment(id)
+}
+
+// hookChanges 比较 path 的当前内容 current 和各个历史版本中 hook id 的原文片段，返回最新的变化在前。
+// 无法解析的版本被跳过，它前后两个版本之间的差异记在较早的那次保存上
+func hookChanges(path string, current []byte, id string) ([]hookChange, error) {
+	versions, err := pipeline.Versions(path)
+	if err != nil {
+		return nil, err
+	}
+	rendered, _ := config.Expand(current)
+	format := config.DetectFormat(path, rendered)
+	newer, err := hookFragment(current, format, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []hookChange
+	for _, v := range versions {
+		if len(changes) == detailChanges {
+			break
+		}
+		data, err := pipeline.ReadVersion(path, v.ID)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not read version %s of %s: %v\n", v.ID, path, err)
+			continue
+		}
+		rendered, _ := config.Expand(data)
+		older, err := hookFragment(data, config.DetectFormat(path, rendered), id)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not parse version %s of %s: %v\n", v.ID, path, err)
+			continue
+		}
+		change := hookChange{Time: v.Time, Version: v.ID}
+		switch {
+		case older == nil && newer == nil, string(older) == string(newer):
+			continue
+		case older == nil:
+			change.Kind = changeAdded
+		case newer == nil:
+			change.Kind = changeRemoved
+		default:
+			change.Kind = changeChanged
+		}
+		change.Diff = lineDiff(string(older), string(newer))
+		changes = append(changes, change)
+		newer = older
+	}
+	return changes, nil
+}
+
+// splitLines 把文本拆成行，去掉末尾的换行
+func splitLines(text string) []string {
+	text = strings.TrimRight(text, "\n")
+	if text == "" {
+		return nil
+	}
+	return strings.Split(text, "\n")
+}
+
+// lineDiff 返回从 a 到 b 的逐行差异（最长公共子序列）。hook 片段一般只有几十行，不需要更快的算法
+func lineDiff(a, b string) []diffLine {
+	x, y := splitLines(a), splitLines(b)
+	// lcs[i][j] 是 x[i:] 和 y[j:] 的最长公共子序列长度
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var diff []diffLine
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			diff = append(diff, diffLine{Op: " ", Text: x[i]})
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			diff = append(diff, diffLine{Op: "-", Text: x[i]})
+			i++
+		default:
+			diff = append(diff, diffLine{Op: "+", Text: y[j]})
+			j++
+		}
+	}
+	return diff
+}
@@ -0,0 +1,714 @@
+package pages
+
+import (
+	"crypto/sha256"
+	"encoding"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"webhook-ui/common/filelock"
+	"webhook-ui/common/i18n"
+)
+
+// 分块上传协议（upload-chunk，参数见 ChunkRequest，结果均为 JSON）：
+//
+//	init     file_name、total_size、chunk_size、sha256（可选）、file_key
+//	         返回 upload_id 和已收到的块编号；同一文件再次 init 得到同一个 upload_id，用于断点续传
+//	chunk    upload_id、index、chunk（块内容）、chunk_sha256（块内容的 SHA-256，必填）
+//	status   upload_id，返回已收到的块编号
+//	finalize upload_id、sha256（可选），按顺序拼接所有块并校验 SHA-256，然后与普通上传一样放到上传目录
+//
+// 每个块在保存前校验 chunk_sha256，校验失败的块不保存，由客户端重传。整个文件的 SHA-256 在块到达时
+// 按顺序累计（见 hashState），finalize 时与拼接结果比较；init 或 finalize 时提供了 sha256 的还要与它一致。
+// 块暂存在 Chunks.StagingDir/<upload_id>/ 下，超过 Chunks.TTL 未活动的暂存目录在 init 时被清理。
+//
+// 每个会话有自己的锁（暂存目录中的 .lock），chunk、status、finalize 只锁定所在的会话，不同文件的上传互不等待。
+// StagingDir/.lock 只在 init 创建会话和清理过期会话时短暂持有，拼接、校验和解压大文件时不持有。
+
+const (
+	defaultChunkSize = 2 << 20 // 2 MiB
+	maxChunkSize     = 16 << 20
+	maxTotalSize     = 4 << 30 // 4 GiB
+	manifestFile     = "manifest.json"
+	hashStateFile    = "sha256.json"
+	lockFile         = ".lock"
+	chunkFilePrefix  = "chunk-"
+)
+
+var uploadIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// chunkManifest 记录一次分块上传的元数据，保存在暂存目录的 manifest.json 中
+type chunkManifest struct {
+	FileName  string `json:"file_name"`
+	TotalSize int64  `json:"total_size"`
+	ChunkSize int64  `json:"chunk_size"`
+	SHA256    string `json:"sha256,omitempty"`
+	Created   string `json:"created"`
+}
+
+func (m chunkManifest) totalChunks() int {
+	if m.TotalSize == 0 {
+		return 1
+	}
+	return int((m.TotalSize + m.ChunkSize - 1) / m.ChunkSize)
+}
+
+// expectedChunkSize 返回第 index 块应有的字节数，最后一块可能不足 ChunkSize
+func (m chunkManifest) expectedChunkSize(index int) int64 {
+	if index == m.totalChunks()-1 {
+		return m.TotalSize - int64(index)*m.ChunkSize
+	}
+	return m.ChunkSize
+}
+
+// chunkResponse 是分块上传各个动作的 JSON 响应
+type chunkResponse struct {
+	Success     bool         `json:"success"`
+	Action      string       `json:"action"`
+	UploadID    string       `json:"upload_id,omitempty"`
+	FileName    string       `json:"file_name,omitempty"`
+	ChunkSize   int64        `json:"chunk_size,omitempty"`
+	TotalChunks int          `json:"total_chunks,omitempty"`
+	Received    []int        `json:"received,omitempty"`
+	SHA256      string       `json:"sha256,omitempty"`
+	Title       i18n.Message `json:"-"`
+	Message     i18n.Message `json:"-"`
+	// 上传页面直接展示的标题和说明，由 UploadChunk 按页面语言翻译 Title 和 Message 得到
+	TitleText   string `json:"title,omitempty"`
+	MessageText string `json:"message,omitempty"`
+}
+
+// uploadID 由文件名、大小和客户端提供的文件标识计算得出，保证同一文件重新 init 时可以续传
+func uploadID(fileName string, totalSize int64, fileKey, sum string) string {
+	h := sha256.Sum256([]byte(strings.Join([]string{fileName, strconv.FormatInt(totalSize, 10), fileKey, sum}, "\x00")))
+	return hex.EncodeToString(h[:16])
+}
+
+func chunkPath(dir string, index int) string {
+	return filepath.Join(dir, fmt.Sprintf("%s%06d", chunkFilePrefix, index))
+}
+
+func readManifest(dir string) (chunkManifest, error) {
+	var m chunkManifest
+	data, err := os.ReadFile(filepath.Join(dir, manifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return m, i18n.Errorf("上传会话不存在或已过期，请重新开始上传")
+		}
+		return m, err
+	}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, i18n.Errorf("上传会话元数据损坏: %v", err)
+	}
+	return m, nil
+}
+
+// hashState 是从第 0 块开始按顺序累计的整个文件的 SHA-256，保存在暂存目录的 sha256.json 中。
+// 块可能乱序到达（续传时只补传缺少的块），只累计已连续收到的部分
+type hashState struct {
+	Next  int    `json:"next"`  // 下一个要累计的块编号
+	State []byte `json:"state"` // sha256 的中间状态（MarshalBinary）
+}
+
+// advanceHash 把从 Next 开始已连续收到的块累计到整个文件的 SHA-256 中并保存，返回累计结果和下一个要累计的块编号
+func advanceHash(dir string, m chunkManifest) (hash.Hash, int, error) {
+	h := sha256.New()
+	var state hashState
+	data, err := os.ReadFile(filepath.Join(dir, hashStateFile))
+	if err == nil {
+		if err := json.Unmarshal(data, &state); err != nil {
+			return nil, 0, i18n.Errorf("上传会话元数据损坏: %v", err)
+		}
+		if err := h.(encoding.BinaryUnmarshaler).UnmarshalBinary(state.State); err != nil {
+			return nil, 0, i18n.Errorf("上传会话元数据损坏: %v", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, 0, err
+	}
+
+	start := state.Next
+	for ; state.Next < m.totalChunks(); state.Next++ {
+		in, err := os.Open(chunkPath(dir, state.Next))
+		if os.IsNotExist(err) {
+			break
+		} else if err != nil {
+			return nil, 0, i18n.Errorf("无法读取块 %d: %v", state.Next, err)
+		}
+		_, err = io.Copy(h, in)
+		in.Close()
+		if err != nil {
+			return nil, 0, i18n.Errorf("无法读取块 %d: %v", state.Next, err)
+		}
+	}
+	if state.Next == start {
+		return h, state.Next, nil
+	}
+
+	// 先写临时文件再改名，中途失败时保留上一次的状态
+	if state.State, err = h.(encoding.BinaryMarshaler).MarshalBinary(); err != nil {
+		return nil, 0, err
+	}
+	data, _ = json.Marshal(state)
+	tmp := filepath.Join(dir, "."+hashStateFile)
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return nil, 0, i18n.Errorf("无法写入上传会话元数据: %v", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, hashStateFile)); err != nil {
+		return nil, 0, i18n.Errorf("无法写入上传会话元数据: %v", err)
+	}
+	return h, state.Next, nil
+}
+
+// receivedChunks 列出暂存目录中已完整写入的块编号。块文件通过改名落盘，存在即完整
+func receivedChunks(dir string) ([]int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var received []int
+	for _, e := range entries {
+		name := e.Name()
+		if !strings.HasPrefix(name, chunkFilePrefix) {
+			continue
+		}
+		index, err := strconv.Atoi(strings.TrimPrefix(name, chunkFilePrefix))
+		if err != nil {
+			continue
+		}
+		received = append(received, index)
+	}
+	sort.Ints(received)
+	return received, nil
+}
+
+// touch 更新会话的最后活动时间，垃圾回收以 manifest.json 的修改时间为准
+func touch(dir string) {
+	now := time.Now()
+	os.Chtimes(filepath.Join(dir, manifestFile), now, now)
+}
+
+// resetSession 清空会话目录，保留会话的锁文件
+func resetSession(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if e.Name() == lockFile {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectStaleUploads 删除超过 TTL 未活动的暂存目录。正在处理请求（会话锁被持有）的会话跳过
+func (c *Chunks) collectStaleUploads() {
+	entries, err := os.ReadDir(c.StagingDir)
+	if err != nil {
+		return
+	}
+	for _, e := range entries {
+		if !e.IsDir() || !uploadIDPattern.MatchString(e.Name()) {
+			continue
+		}
+		dir := filepath.Join(c.StagingDir, e.Name())
+		info, err := os.Stat(filepath.Join(dir, manifestFile))
+		if err != nil {
+			// 没有 manifest 的目录以目录本身的修改时间为准
+			info, err = os.Stat(dir)
+			if err != nil {
+				continue
+			}
+		}
+		if time.Since(info.ModTime()) <= c.TTL {
+			continue
+		}
+		unlock, ok := c.tryLockSession(e.Name(), dir)
+		if !ok {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not remove stale upload '%s': %v\n", dir, err)
+		} else {
+			fmt.Fprintf(os.Stderr, "Removed stale upload '%s'\n", dir)
+		}
+		unlock()
+	}
+}
+
+// ChunkRequest 是分块上传一个动作的参数，与上传页面提交的 JSON 字段对应。数值以字符串传递
+type ChunkRequest struct {
+	Action      string    // action
+	UploadID    string    // upload_id
+	FileName    string    // file_name
+	TotalSize   string    // total_size
+	ChunkSize   string    // chunk_size
+	SHA256      string    // sha256
+	FileKey     string    // file_key
+	Index       string    // index
+	ChunkSHA256 string    // chunk_sha256
+	Chunk       io.Reader // chunk：解码后的块内容，没有时为 nil
+}
+
+// Chunks 是分块上传的会话存储。脚本每次请求创建一个，独立服务所有请求共用一个。
+// 操作之间通过暂存目录中的锁文件互斥，脚本模式下同时处理请求的多个进程也不会同时修改会话
+type Chunks struct {
+	StagingDir string
+	TTL        time.Duration
+
+	// 同一进程内的互斥，锁文件在 Windows 上不生效：mu 对应 StagingDir/.lock，sessions 对应各会话的锁
+	mu         sync.Mutex
+	sessionsMu sync.Mutex
+	sessions   map[string]*sessionLock
+}
+
+// sessionLock 是一个会话在进程内的锁，refs 为等待或持有它的请求数，为 0 时从 Chunks.sessions 中删除
+type sessionLock struct {
+	sync.Mutex
+	refs int
+}
+
+func (c *Chunks) acquire(id string) *sessionLock {
+	c.sessionsMu.Lock()
+	defer c.sessionsMu.Unlock()
+	if c.sessions == nil {
+		c.sessions = map[string]*sessionLock{}
+	}
+	l := c.sessions[id]
+	if l == nil {
+		l = &sessionLock{}
+		c.sessions[id] = l
+	}
+	l.refs++
+	return l
+}
+
+func (c *Chunks) release(id string) {
+	c.sessionsMu.Lock()
+	defer c.sessionsMu.Unlock()
+	if l := c.sessions[id]; l != nil {
+		if l.refs--; l.refs == 0 {
+			delete(c.sessions, id)
+		}
+	}
+}
+
+// lockSession 锁定会话 id，直到返回的函数被调用。会话目录 dir 不存在时返回会话已过期的错误
+func (c *Chunks) lockSession(id, dir string) (func(), error) {
+	l := c.acquire(id)
+	l.Lock()
+	unlock, err := filelock.Lock(filepath.Join(dir, lockFile))
+	if err != nil {
+		l.Unlock()
+		c.release(id)
+		if os.IsNotExist(err) {
+			return nil, i18n.Errorf("上传会话不存在或已过期，请重新开始上传")
+		}
+		return nil, i18n.Errorf("无法锁定 %s: %v", dir, err)
+	}
+	return func() {
+		unlock()
+		l.Unlock()
+		c.release(id)
+	}, nil
+}
+
+// tryLockSession 与 lockSession 相同，但会话正被使用时不等待，返回 false
+func (c *Chunks) tryLockSession(id, dir string) (func(), bool) {
+	l := c.acquire(id)
+	if !l.TryLock() {
+		c.release(id)
+		return nil, false
+	}
+	unlock, ok, err := filelock.TryLock(filepath.Join(dir, lockFile))
+	if err != nil || !ok {
+		l.Unlock()
+		c.release(id)
+		return nil, false
+	}
+	return func() {
+		unlock()
+		l.Unlock()
+		c.release(id)
+	}, true
+}
+
+// global 在 StagingDir/.lock 的保护下执行 fn，只用于创建会话目录和清理过期会话
+func (c *Chunks) global(fn func() error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err := os.MkdirAll(c.StagingDir, 0700); err != nil {
+		return i18n.Errorf("无法创建暂存目录 %s: %v", c.StagingDir, err)
+	}
+	unlock, err := filelock.Lock(filepath.Join(c.StagingDir, lockFile))
+	if err != nil {
+		return i18n.Errorf("无法锁定 %s: %v", c.StagingDir, err)
+	}
+	defer unlock()
+	return fn()
+}
+
+func parseIntParam(name, value string) (int64, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, i18n.Errorf("缺少参数 %s", name)
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n < 0 {
+		return 0, i18n.Errorf("参数 %s 不是有效的非负整数: %q", name, value)
+	}
+	return n, nil
+}
+
+// session 校验 upload_id 并锁定对应的会话，返回暂存目录和解锁函数
+func (c *Chunks) session(id string) (string, func(), error) {
+	if !uploadIDPattern.MatchString(id) {
+		return "", nil, i18n.Errorf("无效的 upload_id: %q", id)
+	}
+	dir := filepath.Join(c.StagingDir, id)
+	unlock, err := c.lockSession(id, dir)
+	if err != nil {
+		return "", nil, err
+	}
+	return dir, unlock, nil
+}
+
+func (c *Chunks) init(r ChunkRequest) chunkResponse {
+	resp := chunkResponse{Action: "init"}
+	fileName := filepath.Base(r.FileName)
+	if fileName == "" || fileName == "." || fileName == string(os.PathSeparator) {
+		resp.Message = i18n.M("缺少参数 file_name")
+		return resp
+	}
+	resp.FileName = fileName
+	totalSize, err := parseIntParam("total_size", r.TotalSize)
+	if err != nil {
+		resp.Message = i18n.M("%v", err)
+		return resp
+	}
+	if totalSize > maxTotalSize {
+		resp.Message = i18n.M("文件大小超过上限 %d 字节", int64(maxTotalSize))
+		return resp
+	}
+	chunkSize := int64(defaultChunkSize)
+	if r.ChunkSize != "" {
+		if chunkSize, err = parseIntParam("chunk_size", r.ChunkSize); err != nil {
+			resp.Message = i18n.M("%v", err)
+			return resp
+		}
+	}
+	if chunkSize <= 0 || chunkSize > maxChunkSize {
+		resp.Message = i18n.M("块大小必须在 1 到 %d 字节之间", int64(maxChunkSize))
+		return resp
+	}
+	sum := strings.ToLower(strings.TrimSpace(r.SHA256))
+	if sum != "" {
+		if b, err := hex.DecodeString(sum); err != nil || len(b) != sha256.Size {
+			resp.Message = i18n.M("无效的 SHA-256: %q", sum)
+			return resp
+		}
+	}
+
+	id := uploadID(fileName, totalSize, r.FileKey, sum)
+	dir := filepath.Join(c.StagingDir, id)
+	err = c.global(func() error {
+		c.collectStaleUploads()
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return i18n.Errorf("无法创建暂存目录: %v", err)
+		}
+		// 续传时更新活动时间，释放全局锁后其他进程的 init 不会把它当作过期会话清理
+		touch(dir)
+		return nil
+	})
+	if err != nil {
+		resp.Message = i18n.M("%v", err)
+		return resp
+	}
+	unlock, err := c.lockSession(id, dir)
+	if err != nil {
+		resp.Message = i18n.M("%v", err)
+		return resp
+	}
+	defer unlock()
+
+	m, err := readManifest(dir)
+	if err != nil || m.ChunkSize != chunkSize {
+		// 新会话，或块大小变化导致旧块无法复用：重新开始
+		if err := resetSession(dir); err != nil {
+			resp.Message = i18n.M("无法创建暂存目录: %v", err)
+			return resp
+		}
+		m = chunkManifest{
+			FileName:  fileName,
+			TotalSize: totalSize,
+			ChunkSize: chunkSize,
+			SHA256:    sum,
+			Created:   time.Now().Format(time.RFC3339),
+		}
+		data, _ := json.MarshalIndent(m, "", "  ")
+		if err := os.WriteFile(filepath.Join(dir, manifestFile), data, 0600); err != nil {
+			resp.Message = i18n.M("无法写入上传会话元数据: %v", err)
+			return resp
+		}
+	}
+	touch(dir)
+
+	received, err := receivedChunks(dir)
+	if err != nil {
+		resp.Message = i18n.M("%v", err)
+		return resp
+	}
+	resp.Success = true
+	resp.UploadID = id
+	resp.ChunkSize = m.ChunkSize
+	resp.TotalChunks = m.totalChunks()
+	resp.Received = received
+	resp.SHA256 = m.SHA256
+	return resp
+}
+
+func (c *Chunks) put(r ChunkRequest) chunkResponse {
+	resp := chunkResponse{Action: "chunk"}
+	dir, unlock, err := c.session(r.UploadID)
+	if err != nil {
+		resp.Message = i18n.M("%v", err)
+		return resp
+	}
+	defer unlock()
+	resp.UploadID = r.UploadID
+	m, err := readManifest(dir)
+	if err != nil {
+		resp.Message = i18n.M("%v", err)
+		return resp
+	}
+	resp.FileName = m.FileName
+	index64, err := parseIntParam("index", r.Index)
+	if err != nil {
+		resp.Message = i18n.M("%v", err)
+		return resp
+	}
+	index := int(index64)
+	if index >= m.totalChunks() {
+		resp.Message = i18n.M("块编号 %d 超出范围 (共 %d 块)", index, m.totalChunks())
+		return resp
+	}
+	if r.Chunk == nil {
+		resp.Message = i18n.M("未接收到块内容 (chunk 字段)")
+		return resp
+	}
+	sum := strings.ToLower(strings.TrimSpace(r.ChunkSHA256))
+	if sum == "" {
+		resp.Message = i18n.M("缺少参数 %s", "chunk_sha256")
+		return resp
+	}
+
+	// 先写入暂存目录内的临时文件，大小正确后再改名为正式块文件，保证块文件存在即完整
+	tmp, err := os.CreateTemp(dir, ".part-*")
+	if err != nil {
+		resp.Message = i18n.M("无法写入块: %v", err)
+		return resp
+	}
+	defer os.Remove(tmp.Name())
+	want := m.expectedChunkSize(index)
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), io.LimitReader(r.Chunk, want+1))
+	if err != nil {
+		tmp.Close()
+		resp.Message = i18n.M("无法写入块: %v", err)
+		return resp
+	}
+	if err := tmp.Close(); err != nil {
+		resp.Message = i18n.M("无法写入块: %v", err)
+		return resp
+	}
+	if n > want {
+		resp.Message = i18n.M("块 %d 大小不正确: 超过 %d 字节", index, want)
+		return resp
+	} else if n != want {
+		resp.Message = i18n.M("块 %d 大小不正确: 收到 %d 字节，应为 %d 字节", index, n, want)
+		return resp
+	}
+	if got := hex.EncodeToString(h.Sum(nil)); got != sum {
+		resp.Message = i18n.M("块 %d 的 SHA-256 校验失败: 期望 %s，实际 %s", index, sum, got)
+		return resp
+	}
+	if err := os.Rename(tmp.Name(), chunkPath(dir, index)); err != nil {
+		resp.Message = i18n.M("无法保存块: %v", err)
+		return resp
+	}
+	touch(dir)
+	if _, _, err := advanceHash(dir, m); err != nil {
+		resp.Message = i18n.M("%v", err)
+		return resp
+	}
+
+	received, err := receivedChunks(dir)
+	if err != nil {
+		resp.Message = i18n.M("%v", err)
+		return resp
+	}
+	resp.Success = true
+	resp.TotalChunks = m.totalChunks()
+	resp.Received = received
+	return resp
+}
+
+func (c *Chunks) status(r ChunkRequest) chunkResponse {
+	resp := chunkResponse{Action: "status"}
+	dir, unlock, err := c.session(r.UploadID)
+	if err != nil {
+		resp.Message = i18n.M("%v", err)
+		return resp
+	}
+	defer unlock()
+	resp.UploadID = r.UploadID
+	m, err := readManifest(dir)
+	if err != nil {
+		resp.Message = i18n.M("%v", err)
+		return resp
+	}
+	received, err := receivedChunks(dir)
+	if err != nil {
+		resp.Message = i18n.M("%v", err)
+		return resp
+	}
+	resp.Success = true
+	resp.FileName = m.FileName
+	resp.ChunkSize = m.ChunkSize
+	resp.TotalChunks = m.totalChunks()
+	resp.Received = received
+	resp.SHA256 = m.SHA256
+	return resp
+}
+
+// finalize 拼接所有块、校验 SHA-256，并交给 storeUpload 放到目标目录
+func (c *Chunks) finalize(r ChunkRequest, uploadDestDir string, extract bool) chunkResponse {
+	resp := chunkResponse{Action: "finalize"}
+	dir, unlock, err := c.session(r.UploadID)
+	if err != nil {
+		resp.Message = i18n.M("%v", err)
+		return resp
+	}
+	defer unlock()
+	resp.UploadID = r.UploadID
+	m, err := readManifest(dir)
+	if err != nil {
+		resp.Message = i18n.M("%v", err)
+		return resp
+	}
+	resp.FileName = m.FileName
+	received, err := receivedChunks(dir)
+	if err != nil {
+		resp.Message = i18n.M("%v", err)
+		return resp
+	}
+	if len(received) != m.totalChunks() {
+		resp.TotalChunks = m.totalChunks()
+		resp.Received = received
+		resp.Message = i18n.M("还缺少 %d 个块，无法完成上传", m.totalChunks()-len(received))
+		return resp
+	}
+
+	h, next, err := advanceHash(dir, m)
+	if err != nil {
+		resp.Message = i18n.M("%v", err)
+		return resp
+	} else if next != m.totalChunks() {
+		resp.Message = i18n.M("还缺少 %d 个块，无法完成上传", m.totalChunks()-next)
+		return resp
+	}
+	resp.SHA256 = hex.EncodeToString(h.Sum(nil))
+
+	assembled, err := os.CreateTemp(dir, ".assembled-*")
+	if err != nil {
+		resp.Message = i18n.M("无法创建拼接文件: %v", err)
+		return resp
+	}
+	defer os.Remove(assembled.Name())
+	assembledHash := sha256.New()
+	w := io.MultiWriter(assembled, assembledHash)
+	for i := 0; i < m.totalChunks(); i++ {
+		in, err := os.Open(chunkPath(dir, i))
+		if err != nil {
+			assembled.Close()
+			resp.Message = i18n.M("无法读取块 %d: %v", i, err)
+			return resp
+		}
+		_, err = io.Copy(w, in)
+		in.Close()
+		if err != nil {
+			assembled.Close()
+			resp.Message = i18n.M("无法拼接块 %d: %v", i, err)
+			return resp
+		}
+	}
+	if err := assembled.Close(); err != nil {
+		resp.Message = i18n.M("无法写入拼接文件: %v", err)
+		return resp
+	}
+
+	if sum := hex.EncodeToString(assembledHash.Sum(nil)); sum != resp.SHA256 {
+		// 拼接结果与接收时累计的不一致，说明暂存的块在接收后被改动
+		os.RemoveAll(dir)
+		resp.Title = i18n.M("上传失败")
+		resp.Message = i18n.M("SHA-256 校验失败: 期望 %s，实际 %s", resp.SHA256, sum)
+		return resp
+	}
+	for _, want := range []string{m.SHA256, strings.ToLower(strings.TrimSpace(r.SHA256))} {
+		if want != "" && resp.SHA256 != want {
+			// 与客户端在 init 或 finalize 时给出的不一致，整个会话作废，客户端需要重新上传
+			os.RemoveAll(dir)
+			resp.Title = i18n.M("上传失败")
+			resp.Message = i18n.M("SHA-256 校验失败: 期望 %s，实际 %s", want, resp.SHA256)
+			return resp
+		}
+	}
+
+	resp.Success, resp.Title, resp.Message = storeUpload(assembled.Name(), m.FileName, uploadDestDir, extract)
+	if resp.Success {
+		os.RemoveAll(dir)
+	}
+	return resp
+}
+
+// UploadChunk 处理分块上传的一个动作，完成的文件放到上传目录，结果以 JSON 写到 w。返回是否成功
+func (s Site) UploadChunk(w io.Writer, c *Chunks, r ChunkRequest, extract bool) bool {
+	var resp chunkResponse
+	switch r.Action {
+	case "init":
+		resp = c.init(r)
+	case "chunk":
+		resp = c.put(r)
+	case "status":
+		resp = c.status(r)
+	case "finalize":
+		resp = c.finalize(r, s.UploadDir, extract)
+	default:
+		resp.Action = r.Action
+		resp.Message = i18n.M("未知的分块上传动作: %q", r.Action)
+	}
+	if !resp.Success && resp.Title.IsZero() {
+		resp.Title = i18n.M("上传失败")
+	}
+
+	p := s.printer()
+	resp.TitleText, resp.MessageText = p.Message(resp.Title), p.Message(resp.Message)
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		fmt.Fprintf(os.Stderr, "Error encoding JSON response: %v\n", err)
+	}
+	return resp.Success
+}
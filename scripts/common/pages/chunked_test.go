@@ -0,0 +1,249 @@
+package pages
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// chunkTest 是一次分块上传测试的环境：上传目录、会话存储和按 chunkSize 切分的文件内容
+type chunkTest struct {
+	t      *testing.T
+	site   Site
+	chunks *Chunks
+}
+
+func newChunkTest(t *testing.T) *chunkTest {
+	work := t.TempDir()
+	dest := filepath.Join(work, "upload")
+	if err := os.Mkdir(dest, 0755); err != nil {
+		t.Fatal(err)
+	}
+	return &chunkTest{
+		t:      t,
+		site:   Site{UploadDir: dest},
+		chunks: &Chunks{StagingDir: filepath.Join(work, "staging"), TTL: time.Hour},
+	}
+}
+
+// do 执行一个动作并解析 JSON 结果
+func (c *chunkTest) do(r ChunkRequest) chunkResponse {
+	c.t.Helper()
+	var out bytes.Buffer
+	ok := c.site.UploadChunk(&out, c.chunks, r, false)
+	var resp chunkResponse
+	if err := json.Unmarshal(out.Bytes(), &resp); err != nil {
+		c.t.Fatalf("无法解析 %s 的结果 %q: %v", r.Action, out.String(), err)
+	}
+	if ok != resp.Success {
+		c.t.Errorf("%s 的返回值 %v 与结果中的 success %v 不一致", r.Action, ok, resp.Success)
+	}
+	return resp
+}
+
+func (c *chunkTest) init(name string, data []byte, chunkSize int) chunkResponse {
+	return c.do(ChunkRequest{Action: "init", FileName: name, TotalSize: strconv.Itoa(len(data)), ChunkSize: strconv.Itoa(chunkSize), FileKey: "key"})
+}
+
+// put 上传 data 中的一块，sum 为空时使用块内容的正确 SHA-256
+func (c *chunkTest) put(id string, index int, chunk []byte, sum string) chunkResponse {
+	if sum == "" {
+		sum = sha256Hex(chunk)
+	}
+	return c.do(ChunkRequest{Action: "chunk", UploadID: id, Index: strconv.Itoa(index), ChunkSHA256: sum, Chunk: bytes.NewReader(chunk)})
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// testFile 返回 10 字节的文件内容，按 4 字节分为 3 块（最后一块 2 字节）
+func testFile() []byte {
+	return []byte("0123456789")
+}
+
+func TestChunkedUpload(t *testing.T) {
+	c := newChunkTest(t)
+	data := testFile()
+	init := c.init("tool.bin", data, 4)
+	if !init.Success || init.TotalChunks != 3 || init.ChunkSize != 4 || len(init.Received) != 0 {
+		t.Fatalf("init = %+v", init)
+	}
+	// 乱序到达
+	for _, index := range []int{2, 0, 1} {
+		end := min((index+1)*4, len(data))
+		if resp := c.put(init.UploadID, index, data[index*4:end], ""); !resp.Success {
+			t.Fatalf("块 %d: %+v", index, resp)
+		}
+	}
+	if resp := c.do(ChunkRequest{Action: "status", UploadID: init.UploadID}); !reflect.DeepEqual(resp.Received, []int{0, 1, 2}) {
+		t.Errorf("status 返回已收到的块 %v", resp.Received)
+	}
+
+	resp := c.do(ChunkRequest{Action: "finalize", UploadID: init.UploadID, SHA256: sha256Hex(data)})
+	if !resp.Success || resp.SHA256 != sha256Hex(data) {
+		t.Fatalf("finalize = %+v", resp)
+	}
+	if got, _ := os.ReadFile(filepath.Join(c.site.UploadDir, "tool.bin")); !bytes.Equal(got, data) {
+		t.Errorf("上传目录中的文件内容为 %q", got)
+	}
+	if _, err := os.Stat(filepath.Join(c.chunks.StagingDir, init.UploadID)); !os.IsNotExist(err) {
+		t.Error("完成后暂存目录仍然存在")
+	}
+}
+
+func TestChunkedResume(t *testing.T) {
+	c := newChunkTest(t)
+	data := testFile()
+	first := c.init("tool.bin", data, 4)
+	c.put(first.UploadID, 1, data[4:8], "")
+
+	// 同一文件再次 init 得到同一个会话和已收到的块
+	again := c.init("tool.bin", data, 4)
+	if again.UploadID != first.UploadID || !reflect.DeepEqual(again.Received, []int{1}) {
+		t.Errorf("续传 init = %+v，应为 %s 且已收到 [1]", again, first.UploadID)
+	}
+	// 块大小变化时旧块无法复用，会话重新开始
+	resized := c.init("tool.bin", data, 5)
+	if resized.UploadID != first.UploadID || len(resized.Received) != 0 || resized.TotalChunks != 2 {
+		t.Errorf("改变块大小后 init = %+v", resized)
+	}
+
+	// 缺少块时不能完成
+	if resp := c.do(ChunkRequest{Action: "finalize", UploadID: first.UploadID}); resp.Success {
+		t.Error("缺少块时 finalize 成功")
+	}
+	if resp := c.do(ChunkRequest{Action: "status", UploadID: "0123456789abcdef0123456789abcdef"}); resp.Success {
+		t.Error("不存在的会话 status 成功")
+	}
+	if resp := c.do(ChunkRequest{Action: "status", UploadID: "../escape"}); resp.Success {
+		t.Error("无效的 upload_id 被接受")
+	}
+}
+
+func TestChunkedRejectsBadChunks(t *testing.T) {
+	c := newChunkTest(t)
+	data := testFile()
+	id := c.init("tool.bin", data, 4).UploadID
+
+	cases := []struct {
+		name  string
+		index int
+		chunk []byte
+		sum   string
+	}{
+		{"SHA-256 不一致", 0, data[0:4], sha256Hex([]byte("xxxx"))},
+		{"缺少 chunk_sha256", 0, data[0:4], " "},
+		{"块太短", 0, data[0:3], ""},
+		{"块太长", 0, data[0:5], ""},
+		{"最后一块大小不对", 2, data[6:10], ""},
+		{"编号超出范围", 3, data[0:4], ""},
+	}
+	for _, tc := range cases {
+		if resp := c.put(id, tc.index, tc.chunk, tc.sum); resp.Success {
+			t.Errorf("%s: 块被接受", tc.name)
+		}
+	}
+	if resp := c.do(ChunkRequest{Action: "status", UploadID: id}); len(resp.Received) != 0 {
+		t.Errorf("被拒绝的块被保存: %v", resp.Received)
+	}
+
+	// 重传同一块覆盖原来的块，不重复计数，整个文件的 SHA-256 仍然正确
+	for i := 0; i < 2; i++ {
+		if resp := c.put(id, 0, data[0:4], ""); !resp.Success || !reflect.DeepEqual(resp.Received, []int{0}) {
+			t.Fatalf("第 %d 次上传块 0: %+v", i+1, resp)
+		}
+	}
+	c.put(id, 1, data[4:8], "")
+	c.put(id, 2, data[8:10], "")
+	if resp := c.do(ChunkRequest{Action: "finalize", UploadID: id}); !resp.Success || resp.SHA256 != sha256Hex(data) {
+		t.Errorf("重传后 finalize = %+v", resp)
+	}
+}
+
+func TestChunkedFinalizeHashMismatch(t *testing.T) {
+	c := newChunkTest(t)
+	data := testFile()
+	id := c.init("tool.bin", data, 4).UploadID
+	c.put(id, 0, data[0:4], "")
+	c.put(id, 1, data[4:8], "")
+	c.put(id, 2, data[8:10], "")
+
+	// 客户端在 finalize 时给出的 SHA-256 不一致：文件不落盘，会话作废
+	if resp := c.do(ChunkRequest{Action: "finalize", UploadID: id, SHA256: sha256Hex([]byte("other"))}); resp.Success {
+		t.Fatal("SHA-256 不一致时 finalize 成功")
+	}
+	if _, err := os.Stat(filepath.Join(c.site.UploadDir, "tool.bin")); !os.IsNotExist(err) {
+		t.Error("校验失败的文件被放入上传目录")
+	}
+	if resp := c.do(ChunkRequest{Action: "status", UploadID: id}); resp.Success {
+		t.Error("校验失败后会话仍然存在")
+	}
+}
+
+func TestChunkedStaleCleanup(t *testing.T) {
+	c := newChunkTest(t)
+	data := testFile()
+	stale := c.init("stale.bin", data, 4).UploadID
+	busy := c.init("busy.bin", data, 4).UploadID
+	old := time.Now().Add(-2 * time.Hour)
+	for _, id := range []string{stale, busy} {
+		os.Chtimes(filepath.Join(c.chunks.StagingDir, id, manifestFile), old, old)
+	}
+
+	// 正在处理请求的会话即使过期也不清理
+	unlock, err := c.chunks.lockSession(busy, filepath.Join(c.chunks.StagingDir, busy))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.init("new.bin", data, 4)
+	unlock()
+
+	if _, err := os.Stat(filepath.Join(c.chunks.StagingDir, stale)); !os.IsNotExist(err) {
+		t.Error("过期的会话没有被清理")
+	}
+	if _, err := os.Stat(filepath.Join(c.chunks.StagingDir, busy)); err != nil {
+		t.Errorf("被锁定的会话被清理: %v", err)
+	}
+	if resp := c.put(stale, 0, data[0:4], ""); resp.Success {
+		t.Error("向已清理的会话上传块成功")
+	}
+}
+
+// TestChunkedSessionsIndependent 检查一个会话被长时间占用（如 finalize 拼接大文件）时，其他会话的请求不受影响
+func TestChunkedSessionsIndependent(t *testing.T) {
+	c := newChunkTest(t)
+	data := testFile()
+	slow := c.init("slow.bin", data, 4).UploadID
+	other := c.init("other.bin", data, 4).UploadID
+
+	unlock, err := c.chunks.lockSession(slow, filepath.Join(c.chunks.StagingDir, slow))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+	done := make(chan chunkResponse, 1)
+	go func() {
+		var out bytes.Buffer
+		c.site.UploadChunk(&out, c.chunks, ChunkRequest{Action: "chunk", UploadID: other, Index: "0", ChunkSHA256: sha256Hex(data[0:4]), Chunk: bytes.NewReader(data[0:4])}, false)
+		var resp chunkResponse
+		json.Unmarshal(out.Bytes(), &resp)
+		done <- resp
+	}()
+	select {
+	case resp := <-done:
+		if !resp.Success {
+			t.Errorf("其他会话的块上传失败: %+v", resp)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("一个会话被锁定时，其他会话的请求被阻塞")
+	}
+}
@@ -0,0 +1,46 @@
+package pages
+
+import (
+	"io"
+
+	"webhook-ui/common/adminhooks"
+	"webhook-ui/common/i18n"
+	"webhook-ui/common/pipeline"
+)
+
+// confirmField 是确认表单中原样重新提交的字段
+type confirmField struct {
+	Name      string
+	Value     string
+	Multiline bool // 多行内容（配置原文）用隐藏的 textarea 提交
+}
+
+// renderAdminConfirm 在修改会删除或改动管理 hook 时显示警告，列出受影响的 hook 和恢复方法，
+// 并提供带原参数的表单：输入 adminhooks.Confirmation 后重新提交到 action
+func (s Site) renderAdminConfirm(w io.Writer, verr *pipeline.ValidationError, action string, fields []confirmField) {
+	var nonEmpty []confirmField
+	for _, field := range fields {
+		if field.Value != "" {
+			nonEmpty = append(nonEmpty, field)
+		}
+	}
+	templateData := struct {
+		Layout
+		Problem  i18n.Message
+		Problems []i18n.Message
+		Recovery string
+		Action   string
+		Fields   []confirmField
+		Phrase   string
+	}{
+		Layout:   s.layout("confirm", i18n.M("需要确认：修改管理 hook"), i18n.M("⚠ 需要确认：修改管理 hook")),
+		Problem:  verr.Title,
+		Problems: verr.Problems,
+		Recovery: verr.Recovery,
+		Action:   action,
+		Fields:   nonEmpty,
+		Phrase:   s.printer().T(adminhooks.Confirmation),
+	}
+
+	s.render(w, "confirm", templateData)
+}
@@ -0,0 +1,261 @@
+package pages
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/history"
+	"webhook-ui/common/i18n"
+)
+
+// detailExecutions 是详情页显示的最近执行记录条数
+const detailExecutions = 20
+
+// DetailRequest 是 hook 详情页的参数
+type DetailRequest struct {
+	ID        string // ?id=<hook id>：要查看的 hook，启用和停用的 hook 都可以查看
+	PublicURL string // 客户端访问 webhook 的地址（如 https://example.com），用于生成调用示例；为空时由页面按当前地址补全
+	Hash      bool   // ?hash=1：计算执行文件的 SHA-256，超过 hashLimit 的文件默认不计算
+}
+
+// hashLimit 是打开详情页时计算执行文件 SHA-256 的大小上限。hook 模式下每次打开详情页都是新进程，缓存不起作用
+const hashLimit = 16 << 20
+
+// executable 是 execute-command 指向的文件的信息
+type executable struct {
+	Path    string
+	Status  config.CommandStatus
+	Size    int64
+	Mode    string
+	ModTime time.Time
+	SHA256  string
+	Kind    string // ELF、脚本的 #! 行，其他文件为空
+	Hashed  bool   // 是否计算了 SHA-256，文件超过 hashLimit 且没有缓存时需要 ?hash=1
+	Err     error  // 无法读取文件内容
+}
+
+// fileHash 是一个文件在某个大小和修改时间下的 SHA-256
+type fileHash struct {
+	size    int64
+	modTime time.Time
+	sum     string
+}
+
+// commandHashes 缓存执行文件的 SHA-256。独立服务中每次打开详情页都会检查执行文件，
+// 文件的大小和修改时间不变时不再读取整个文件
+var commandHashes = struct {
+	sync.Mutex
+	m map[string]fileHash
+}{m: map[string]fileHash{}}
+
+// lookupHash 返回缓存中 path 的 SHA-256，大小和修改时间与缓存不一致时返回 false
+func lookupHash(path string, info os.FileInfo) (string, bool) {
+	commandHashes.Lock()
+	defer commandHashes.Unlock()
+	cached, ok := commandHashes.m[path]
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.sum, true
+	}
+	return "", false
+}
+
+// cachedHash 返回 path 的 SHA-256，大小和修改时间与缓存一致时直接使用缓存
+func cachedHash(path string, f io.Reader, info os.FileInfo) (string, error) {
+	if sum, ok := lookupHash(path, info); ok {
+		return sum, nil
+	}
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	sum := hex.EncodeToString(h.Sum(nil))
+	commandHashes.Lock()
+	commandHashes.m[path] = fileHash{size: info.Size(), modTime: info.ModTime(), sum: sum}
+	commandHashes.Unlock()
+	return sum, nil
+}
+
+// inspectCommand 返回 hook 执行的文件的信息。不含路径分隔符的命令按 PATH 查找。
+// hash 为 false 时不计算超过 hashLimit 的文件的 SHA-256（已有缓存的除外）
+func inspectCommand(h config.Hook, hash bool) *executable {
+	path := h.CommandPath()
+	if path == "" {
+		return nil
+	}
+	e := &executable{Path: path, Status: h.CheckCommand()}
+	if !strings.ContainsRune(path, os.PathSeparator) {
+		found, err := exec.LookPath(path)
+		if err != nil {
+			return e
+		}
+		e.Path = found
+	}
+	info, err := os.Stat(e.Path)
+	if err != nil || info.IsDir() {
+		return e
+	}
+	e.Size = info.Size()
+	e.Mode = info.Mode().String()
+	e.ModTime = info.ModTime()
+
+	f, err := os.Open(e.Path)
+	if err != nil {
+		e.Err = err
+		return e
+	}
+	defer f.Close()
+	head, _ := bufio.NewReader(f).Peek(256)
+	switch {
+	case strings.HasPrefix(string(head), "\x7fELF"):
+		e.Kind = "ELF"
+	case strings.HasPrefix(string(head), "#!"):
+		e.Kind, _, _ = strings.Cut(string(head), "\n")
+		e.Kind = strings.TrimSpace(e.Kind)
+	}
+	if _, cached := lookupHash(e.Path, info); !hash && !cached && info.Size() > hashLimit {
+		return e
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		e.Err = err
+		return e
+	}
+	if e.SHA256, err = cachedHash(e.Path, f, info); err != nil {
+		e.Err = err
+	}
+	e.Hashed = true
+	return e
+}
+
+// Detail 渲染一个 hook 的详情页：完整配置（包括嵌套的触发规则）、原文片段、调用示例、最近的执行记录、
+// 执行文件的信息，以及该 hook 在各个历史版本中的变化
+func (s Site) Detail(w io.Writer, r DetailRequest) error {
+	sources, err := s.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving hooks files: %v\n", err)
+		fmt.Fprint(w, "<h1>Error: Could not load Webhook configuration.</h1><p>Please check server logs.</p>")
+		return err
+	}
+
+	// 1. 按 id 查找 hook：先找启用的，再找停用的
+	var source *config.Source
+	var hook config.Hook
+	var disabled *config.DisabledHook
+	for i := range sources {
+		if h := sources[i].Hooks.Find(r.ID); h != nil {
+			source, hook = &sources[i], *h
+			break
+		}
+	}
+	if source == nil {
+		for i := range sources {
+			if sources[i].Disabled == nil {
+				continue
+			}
+			if d := sources[i].Disabled.Find(r.ID); d != nil {
+				source, hook, disabled = &sources[i], d.Hook, d
+				break
+			}
+		}
+	}
+	if source == nil || r.ID == "" {
+		s.renderResponse(w, response{Title: i18n.M("Hook 详情"), Message: i18n.M("hook %q 不存在", r.ID)})
+		return nil
+	}
+
+	// 定义要传递给模板的数据结构
+	type TemplateData struct {
+		Layout
+		Hook            config.Hook
+		File            string
+		Disabled        *config.DisabledHook
+		Methods         []string
+		Fragment        string
+		Format          string
+		Request         clientRequest
+		Snippets        []snippet
+		Executions      []history.Entry
+		HistoryErr      error
+		HistoryRecorded bool // 执行记录文件存在，即有请求经过 server -proxy 转发
+		Executable      *executable
+		Changes         []hookChange
+		ChangesErr      error
+		EditUrl         string
+		HookUrl         string
+		URLPrefix       string
+		PlaceholderBase string // 调用示例中的占位地址，见 placeholderBase
+		HashLimitMiB    int
+	}
+
+	// 2. 原文片段：停用的 hook 使用停用前保存的原文
+	rendered, _ := config.Expand([]byte(source.Raw))
+	format := config.DetectFormat(source.Path, rendered)
+	fragment := ""
+	if disabled != nil {
+		fragment = disabled.Fragment
+	} else if out, err := hookFragment([]byte(source.Raw), format, r.ID); err != nil {
+		fmt.Fprintf(os.Stderr, "Error extracting hook %s from %s: %v\n", r.ID, source.Path, err)
+	} else {
+		fragment = string(out)
+	}
+
+	// 3. 调用示例，地址与 webhook 的 -urlprefix 一致
+	request := newClientRequest(hook, r.PublicURL, s.URLPrefix)
+
+	templateData := TemplateData{
+		Layout:          s.layout("detail", i18n.M("Hook %s", hook.ID), i18n.M("Hook %s", hook.ID)),
+		Hook:            hook,
+		File:            source.Path,
+		Disabled:        disabled,
+		Methods:         methods(hook),
+		Fragment:        fragment,
+		Format:          strings.ToUpper(string(format)),
+		Request:         request,
+		Snippets:        request.snippets(),
+		PlaceholderBase: placeholderBase,
+		HashLimitMiB:    hashLimit >> 20,
+		Executable:      inspectCommand(hook, r.Hash),
+		EditUrl:         s.EditURL,
+		HookUrl:         s.HookURL,
+		URLPrefix:       s.URLPrefix,
+	}
+
+	// 4. 最近的执行记录，记录文件在第一个 hooks 文件旁（见 history.Path），HISTORY_FILE 为空时不记录
+	if path := history.Path(sources[0].Path); path != "" {
+		_, statErr := os.Stat(path)
+		templateData.HistoryRecorded = statErr == nil
+		if templateData.Executions, err = history.Read(path, hook.ID, detailExecutions); err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading history %s: %v\n", path, err)
+			templateData.HistoryErr = err
+		}
+	}
+
+	// 5. 在各个历史版本中的变化
+	if templateData.Changes, err = hookChanges(source.Path, []byte(source.Raw), hook.ID); err != nil {
+		fmt.Fprintf(os.Stderr, "Error reading versions of %s: %v\n", source.Path, err)
+		templateData.ChangesErr = err
+	}
+
+	if disabled != nil {
+		templateData.flash("notice", i18n.M("已停用：%s，%s，原因：%s", disabled.By, disabled.Time.Local().Format("2006-01-02 15:04:05"), disabled.Reason))
+	}
+	if templateData.Executable != nil {
+		switch templateData.Executable.Status {
+		case config.CommandMissing:
+			templateData.flash("error", i18n.M("命令文件不存在"))
+		case config.CommandNotExecutable:
+			templateData.flash("error", i18n.M("命令文件不可执行"))
+		}
+	}
+
+	// 6. 用布局渲染 templates/detail.html
+	return s.render(w, "detail", templateData)
+}
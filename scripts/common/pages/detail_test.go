@@ -0,0 +1,40 @@
+package pages
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"webhook-ui/common/config"
+)
+
+// TestInspectCommandHashLimit 检查超过 hashLimit 的执行文件只在请求时计算 SHA-256，计算后由缓存提供
+func TestInspectCommandHashLimit(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "small.sh")
+	if err := os.WriteFile(small, []byte("#!/bin/sh\necho ok\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	large := filepath.Join(dir, "large")
+	if err := os.WriteFile(large, []byte("\x7fELF"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Truncate(large, hashLimit+1); err != nil {
+		t.Fatal(err)
+	}
+
+	if e := inspectCommand(config.Hook{ExecuteCommand: small}, false); !e.Hashed || e.SHA256 == "" || e.Kind != "#!/bin/sh" {
+		t.Errorf("小文件: %+v", e)
+	}
+	e := inspectCommand(config.Hook{ExecuteCommand: large}, false)
+	if e.Hashed || e.SHA256 != "" || e.Kind != "ELF" || e.Size != hashLimit+1 {
+		t.Errorf("未请求时计算了大文件的 SHA-256 或缺少其他信息: %+v", e)
+	}
+	requested := inspectCommand(config.Hook{ExecuteCommand: large}, true)
+	if !requested.Hashed || requested.SHA256 == "" {
+		t.Fatalf("请求时没有计算 SHA-256: %+v", requested)
+	}
+	if e := inspectCommand(config.Hook{ExecuteCommand: large}, false); e.SHA256 != requested.SHA256 {
+		t.Errorf("文件不变时没有使用缓存: %+v", e)
+	}
+}
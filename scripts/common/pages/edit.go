@@ -0,0 +1,245 @@
+package pages
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/i18n"
+)
+
+// appendNewHook 在配置内容末尾追加一个执行 command 的新 hook，others 是其他 hooks 文件中的 hook，用于保证 id 不重复。
+// YAML 直接在原文本后追加而不是重新序列化整个配置，以保留用户原有的注释和格式
+func appendNewHook(content, command string, others config.Config, format config.Format) (string, string) {
+	var existing config.Config
+	rendered, err := config.Expand([]byte(content))
+	if err == nil {
+		existing, err = config.ParseFormat(rendered, format)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing config, new hook id may not be unique: %v\n", err)
+	}
+	hook := config.NewHookFor(append(existing, others...), command)
+	if format == config.FormatJSON {
+		return appendJSONHook(content, hook)
+	}
+	snippet, err := config.Marshal([]config.Hook{hook})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating new hook for %s: %v\n", command, err)
+		return content, ""
+	}
+
+	if strings.TrimSpace(content) == "[]" {
+		content = ""
+	}
+	if content != "" && !strings.HasSuffix(content, "\n") {
+		content += "\n"
+	}
+	return content + "## 新建 hook：" + hook.ID + "，请按需修改参数后保存\n" + string(snippet), hook.ID
+}
+
+// appendJSONHook 在 JSON 数组末尾追加 hook，其余 hook 的键顺序保持不变
+func appendJSONHook(content string, hook config.Hook) (string, string) {
+	doc, err := config.ParseDocument([]byte(content), config.FormatJSON)
+	if err == nil {
+		err = doc.Add(hook, "")
+	}
+	var out []byte
+	if err == nil {
+		out, err = doc.Bytes()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error appending new hook to JSON config: %v\n", err)
+		return content, ""
+	}
+	return string(out), hook.ID
+}
+
+// EditRequest 是编辑页的参数
+type EditRequest struct {
+	Title      string
+	File       string // ?file=<路径>：HOOKS 配置了多个文件时要编辑的文件，默认编辑第一个
+	HookID     string // ?id=<hook id>：只编辑一个 hook，未指定文件时编辑该 hook 所在的文件
+	Convert    string // ?convert=json|yaml：把当前文件转换为另一种格式
+	NewCommand string // ?new_command=<文件路径>：从上传页面的"创建 Hook"进入
+}
+
+// Edit 渲染编辑页
+func (s Site) Edit(w io.Writer, r EditRequest) error {
+	// HOOKS 可以是逗号分隔的多个文件或通配符，通过 r.File 选择要编辑的文件，默认编辑第一个
+	sources, err := s.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving hooks files: %v\n", err)
+		fmt.Fprint(w, "<h1>Error: Could not load Webhook configuration.</h1><p>Please check server logs.</p>")
+		return err
+	}
+	// 只编辑一个 hook，未指定文件时编辑该 hook 所在的文件
+	hookID := r.HookID
+	configFilePath := sources[0].Path
+	if hookID != "" {
+		for _, source := range sources {
+			if source.Hooks.Find(hookID) != nil {
+				configFilePath = source.Path
+				break
+			}
+		}
+	}
+	if file := r.File; file != "" {
+		if config.Contains(sources, file) {
+			configFilePath = filepath.Clean(file)
+		} else {
+			// 只允许编辑 HOOKS 中列出的文件，避免通过参数读取任意文件
+			fmt.Fprintf(os.Stderr, "Requested file %s is not a hooks file, editing %s instead.\n", file, configFilePath)
+		}
+	}
+	var others config.Config
+	var files []string
+	for _, source := range sources {
+		files = append(files, source.Path)
+		if source.Path != configFilePath {
+			others = append(others, source.Hooks...)
+		}
+	}
+
+	// 定义要传递给模板的数据结构
+	type TemplateData struct {
+		Layout
+		ConfigContent string
+		HomeUrl       string
+		SaveUrl       string
+		EditUrl       string
+		NewHookID     string
+		File          string
+		Files         []string
+		Format        string // 当前内容的格式，YAML 或 JSON
+		ConvertTo     string // 可以转换成的格式，为空时不提供转换
+		ConvertLabel  string
+		Template      bool
+		Rendered      string // 模板渲染结果，只用于预览
+		RenderError   string
+		HookID        string // 只编辑一个 hook 时的 id
+		Revision      string
+	}
+
+	var configContent string
+	var newHookID, revision string
+	var hookError i18n.Message
+
+	data, err := os.ReadFile(configFilePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// 文件不存在时，提供一个空列表作为初始内容（同时是合法的 YAML 和 JSON）
+			configContent = "[]\n"
+			fmt.Fprintf(os.Stderr, "Config file not found at %s, starting with empty config.\n", configFilePath)
+		} else {
+			// 其他读取错误
+			configContent = fmt.Sprintf("# Error loading config from %s: %v\n# Please check server logs.\n[]\n", configFilePath, err)
+			fmt.Fprintf(os.Stderr, "Error reading config file from %s: %v\n", configFilePath, err)
+			// 这里不直接退出，以便用户可以看到错误信息并在页面中尝试编辑或保存
+		}
+	} else {
+		configContent = string(data) // 将读取到的字节数据直接转换为字符串
+	}
+
+	// 模板模式 (TEMPLATE=true) 下编辑框中始终是模板原文，格式判断和预览使用渲染结果
+	templateMode := config.TemplateEnabled()
+	rendered, _ := config.Expand([]byte(configContent))
+	format := config.DetectFormat(configFilePath, rendered)
+	// .json 文件只能保存 JSON，因此只能从 YAML 转换为 JSON，不能反向转换；
+	// 转换会把模板表达式替换为渲染结果，因此模板模式下不提供转换
+	convertTo := format.Other()
+	if templateMode || (convertTo == config.FormatYAML && strings.EqualFold(filepath.Ext(configFilePath), ".json")) {
+		convertTo = ""
+	}
+
+	// 只编辑一个 hook 时编辑框中是该 hook 的原文片段，保存时只替换这个 hook，不提供格式转换
+	if hookID != "" {
+		convertTo = ""
+		doc, err := config.ParseDocument([]byte(configContent), format)
+		var fragment []byte
+		if err == nil {
+			fragment, err = doc.Fragment(hookID)
+		}
+		if err == nil {
+			revision, err = doc.Revision(hookID)
+		}
+		if err != nil {
+			hookError = i18n.M("无法单独编辑 hook %s，请编辑整个文件: %v", hookID, err)
+			hookID = ""
+		} else {
+			configContent = string(fragment)
+		}
+	}
+
+	// ?convert=json|yaml：把当前文件转换为另一种格式后放入编辑框，保存后生效
+	var converted string
+	var convertError i18n.Message
+	if target := config.Format(strings.ToLower(r.Convert)); target != "" && target != format && hookID == "" {
+		if target != convertTo {
+			convertError = i18n.M("无法把 %s 转换为 %s 格式。", configFilePath, strings.ToUpper(string(target)))
+		} else if out, err := config.Convert([]byte(configContent), target); err != nil {
+			convertError = i18n.M("转换失败，请先修正当前内容: %v", err)
+		} else {
+			configContent = string(out)
+			converted = strings.ToUpper(string(target))
+			format = target
+			convertTo = ""
+		}
+	}
+
+	// 从上传页面的"创建 Hook"进入时（?new_command=<文件路径>），在配置末尾追加一个预填好的新 hook
+	if newCommand := r.NewCommand; newCommand != "" && hookID == "" {
+		configContent, newHookID = appendNewHook(configContent, newCommand, others, format)
+	}
+
+	var renderedContent, renderError string
+	if templateMode {
+		if out, err := config.Render([]byte(configContent)); err != nil {
+			renderError = err.Error()
+		} else {
+			renderedContent = string(out)
+		}
+	}
+
+	saveUrl := s.SaveURL
+	if hookID != "" {
+		saveUrl = s.HookUpdateURL
+	}
+
+	heading := i18n.M("编辑 Webhook 配置")
+	if hookID != "" {
+		heading = i18n.M("编辑 hook %s", hookID)
+	}
+	templateData := TemplateData{
+		Layout:        s.layout("edit", i18n.M("编辑 Webhook 配置"), heading),
+		ConfigContent: configContent,
+		HomeUrl:       s.HomeURL,
+		SaveUrl:       saveUrl,
+		EditUrl:       s.EditURL,
+		NewHookID:     newHookID,
+		File:          configFilePath,
+		Files:         files,
+		Format:        strings.ToUpper(string(format)),
+		ConvertTo:     string(convertTo),
+		ConvertLabel:  strings.ToUpper(string(convertTo)),
+		HookID:        hookID,
+		Revision:      revision,
+		Template:      templateMode,
+		Rendered:      renderedContent,
+		RenderError:   renderError,
+	}
+
+	templateData.flash("error", hookError)
+	if newHookID != "" {
+		templateData.flash("notice", i18n.M("已在配置末尾添加新 hook %s，确认参数后点击 \"保存更改\"。", newHookID))
+	}
+	if converted != "" {
+		templateData.flash("notice", i18n.M("已转换为 %s 格式（YAML 注释不会保留），确认无误后点击 \"保存更改\"。", converted))
+	}
+	templateData.flash("error", convertError)
+	// 用布局渲染 templates/edit.html
+	return s.render(w, "edit", templateData)
+}
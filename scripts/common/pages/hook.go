@@ -0,0 +1,318 @@
+package pages
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/i18n"
+	"webhook-ui/common/pipeline"
+)
+
+// 支持的操作，每个操作在 hooks.yaml 中对应一个 hook
+const (
+	actionCreate    = "create"
+	actionUpdate    = "update"
+	actionDelete    = "delete"
+	actionDuplicate = "duplicate"
+	actionMove      = "move"
+	actionDisable   = "disable"
+	actionEnable    = "enable"
+)
+
+// actionMessages 是各操作失败时的标题和成功时的说明，都是消息目录中的完整键，不要拼接
+var actionMessages = map[string]struct{ failed, done string }{
+	actionCreate:    {"创建失败", "已创建 hook %s（%s）。"},
+	actionUpdate:    {"更新失败", "已更新 hook %s（%s）。"},
+	actionDelete:    {"删除失败", "已删除 hook %s（%s）。"},
+	actionDuplicate: {"复制失败", "已复制 hook %s（%s）。"},
+	actionMove:      {"移动失败", "已移动 hook %s（%s）。"},
+	actionDisable:   {"停用失败", "已停用 hook %s（%s）。"},
+	actionEnable:    {"启用失败", "已启用 hook %s（%s）。"},
+}
+
+// HookActions 返回支持的单个 hook 操作，即 hook-<操作> 中的操作名
+func HookActions() []string {
+	return []string{actionCreate, actionUpdate, actionDelete, actionDuplicate, actionMove, actionDisable, actionEnable}
+}
+
+// HookRequest 是一次单个 hook 操作的参数
+type HookRequest struct {
+	Action   string
+	File     string // hook 所在文件；不指定时按 id 查找，create 默认写入第一个文件
+	ID       string // 要操作的 hook
+	Content  string // create/update 时单个 hook 的 YAML/JSON 片段
+	Revision string // update 时编辑页读取到的 hook 版本，不一致时拒绝覆盖
+	NewID    string // duplicate 时新 hook 的 id，为空时自动生成
+	Position string // move 时的目标位置，up/down/top/bottom 或从 0 开始的序号
+	Reason   string // disable/enable 的原因
+	By       string // 操作人记录，见 Operator
+
+	AllowAdminChanges bool // 已在确认页输入确认文字（见 adminhooks.Confirmed），允许删除或修改管理页面自身使用的 hook
+}
+
+// Operator 返回操作人记录。user 是已验证的用户：管理页面 Basic 认证的用户名，或可信反向代理（见 TrustedProxy）
+// 设置的 X-Forwarded-User 请求头；claimed 是表单中自行填写的名字，无法验证，只在没有 user 时记为 "claimed by <name>"。
+// 两者都附上客户端地址（remoteAddr）
+func Operator(user, claimed, remoteAddr string) string {
+	name := strings.TrimSpace(user)
+	if claimed = strings.TrimSpace(claimed); name == "" && claimed != "" {
+		name = "claimed by " + claimed
+	}
+	addr := strings.TrimSpace(remoteAddr)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	switch {
+	case name != "" && addr != "":
+		return fmt.Sprintf("%s (%s)", name, addr)
+	case name != "":
+		return name
+	case addr != "":
+		return addr
+	}
+	return "unknown"
+}
+
+// TrustedProxy 返回 remoteAddr 是否是 TRUSTED_PROXIES（逗号分隔的 IP 或 CIDR，默认为空）中的反向代理。
+// 只有来自这些地址的请求中的 X-Forwarded-User 由代理认证后设置，其他请求中的可以由客户端任意填写
+func TrustedProxy(remoteAddr string) bool {
+	host := strings.TrimSpace(remoteAddr)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, entry := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if prefix, err := netip.ParsePrefix(entry); err == nil && prefix.Contains(addr) {
+			return true
+		}
+		if trusted, err := netip.ParseAddr(entry); err == nil && trusted.Unmap() == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// findSource 返回包含 id 的 hooks 文件，包括 id 被停用的文件
+func findSource(sources []config.Source, id string) (string, error) {
+	for _, source := range sources {
+		if source.Hooks.Find(id) != nil {
+			return source.Path, nil
+		}
+	}
+	if path, ok := config.DisabledIDs(sources)[id]; ok {
+		return path, nil
+	}
+	return "", i18n.Errorf("hook %q 不存在", id)
+}
+
+// apply 在 hooks 文件的当前内容上执行操作，只改动涉及的 hook，其余 hook 的注释和格式保持不变。
+// disable/enable 同时修改 disabled 中记录的停用状态
+func (r HookRequest) apply(path string, all config.Config, current []byte, disabled *config.DisabledState) ([]byte, string, error) {
+	format := config.DetectFormat(path, current)
+	doc, err := config.ParseDocument(current, format)
+	if err != nil {
+		return nil, "", i18n.Errorf("无法解析 %s，请通过编辑整个文件修改: %v", path, err)
+	}
+	id := r.ID
+	switch r.Action {
+	case actionCreate:
+		id, err = doc.Insert([]byte(r.Content))
+	case actionUpdate:
+		if r.Revision != "" {
+			revision, rerr := doc.Revision(r.ID)
+			if rerr == nil && revision != r.Revision {
+				return nil, "", i18n.Errorf("hook %q 在打开编辑页后已被修改，请刷新后重新编辑", r.ID)
+			}
+		}
+		id, err = doc.Replace(r.ID, []byte(r.Content))
+	case actionDelete:
+		err = doc.Remove(r.ID)
+	case actionDuplicate:
+		id = r.NewID
+		if id == "" {
+			id = all.UniqueID(r.ID + "-copy")
+		}
+		err = doc.Duplicate(r.ID, id)
+	case actionMove:
+		var index int
+		if index, err = r.targetIndex(doc); err == nil {
+			err = doc.Move(r.ID, index)
+		}
+	case actionDisable:
+		err = r.disable(doc, disabled)
+	case actionEnable:
+		err = r.enable(doc, disabled)
+	default:
+		err = i18n.Errorf("未知的操作 %q", r.Action)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	data, err := doc.Bytes()
+	return data, id, err
+}
+
+// disable 把 hook 的原文和位置移到旁路文件中，webhook 重新加载后不再提供这个 hook
+func (r HookRequest) disable(doc *config.Document, disabled *config.DisabledState) error {
+	fragment, err := doc.Fragment(r.ID)
+	if err != nil {
+		return err
+	}
+	if disabled.Find(r.ID) != nil {
+		return i18n.Errorf("hook %q 已经被停用", r.ID)
+	}
+	index := 0
+	for i, id := range doc.IDs() {
+		if id == r.ID {
+			index = i
+		}
+	}
+	if err := doc.Remove(r.ID); err != nil {
+		return err
+	}
+	disabled.Disable(config.DisabledHook{ID: r.ID, Index: index, Fragment: string(fragment), By: r.By, Reason: r.Reason, Time: time.Now()})
+	return nil
+}
+
+// enable 把停用的 hook 原样放回停用前的位置
+func (r HookRequest) enable(doc *config.Document, disabled *config.DisabledState) error {
+	hook, err := disabled.Enable(r.ID, r.By, r.Reason, time.Now())
+	if err != nil {
+		return err
+	}
+	id, err := doc.Insert([]byte(hook.Fragment))
+	if err != nil {
+		return i18n.Errorf("无法启用 hook %q: %v", r.ID, err)
+	}
+	return doc.Move(id, hook.Index)
+}
+
+// targetIndex 把 HOOK_POSITION 转换为列表中的序号
+func (r HookRequest) targetIndex(doc *config.Document) (int, error) {
+	ids := doc.IDs()
+	current := -1
+	for i, id := range ids {
+		if id == r.ID {
+			current = i
+		}
+	}
+	switch r.Position {
+	case "up":
+		return current - 1, nil
+	case "down":
+		return current + 1, nil
+	case "top":
+		return 0, nil
+	case "bottom":
+		return len(ids), nil
+	}
+	index, err := strconv.Atoi(r.Position)
+	if err != nil {
+		return 0, i18n.Errorf("无效的位置 %q，应为 up、down、top、bottom 或序号", r.Position)
+	}
+	return index, nil
+}
+
+// Hook 执行一次单个 hook 操作，返回新配置是否已生效
+func (s Site) Hook(w io.Writer, r HookRequest) bool {
+	r.ID = strings.TrimSpace(r.ID)
+	r.NewID = strings.TrimSpace(r.NewID)
+	r.Position = strings.ToLower(strings.TrimSpace(r.Position))
+	r.Reason = strings.TrimSpace(r.Reason)
+	messages, ok := actionMessages[r.Action]
+	if !ok {
+		s.renderResponse(w, response{Title: i18n.M("错误"), Message: i18n.M("未知的操作 %s，请检查 -action 参数。", r.Action)})
+		return false
+	}
+	fail := func(message i18n.Message) bool {
+		s.renderResponse(w, response{Title: i18n.M(messages.failed), Message: message})
+		return false
+	}
+	if r.Action != actionCreate && r.ID == "" {
+		return fail(i18n.M("未在请求中找到 'id' 字段。"))
+	}
+	if (r.Action == actionCreate || r.Action == actionUpdate) && strings.TrimSpace(r.Content) == "" {
+		return fail(i18n.M("未在请求中找到 'content' 字段内容。"))
+	}
+	if r.Action == actionDisable && r.Reason == "" {
+		return fail(i18n.M("请填写停用原因（'reason' 字段）。"))
+	}
+
+	sources, err := s.Load()
+	if err != nil {
+		return fail(i18n.M("%v", err))
+	}
+	// 新建时写入 r.File 指定的文件（默认第一个），其他操作写入 hook 所在的文件
+	file := r.File
+	if file == "" && r.Action != actionCreate {
+		if file, err = findSource(sources, r.ID); err != nil {
+			return fail(i18n.M("%v", err))
+		}
+	}
+	path, err := pipeline.Target(sources, file)
+	if err != nil {
+		return fail(i18n.M("%v", err))
+	}
+
+	// 停用状态保存在 hooks 文件旁的隐藏文件中，在文件锁内读取最新内容，新配置生效后再写入
+	var id string
+	var disabled *config.DisabledState
+	toggle := r.Action == actionDisable || r.Action == actionEnable
+	if r.Action == actionEnable {
+		// 启用的 hook 不再占用停用的 id，校验时排除它
+		if state, err := config.LoadDisabled(path); err == nil {
+			state.Enable(r.ID, r.By, r.Reason, time.Now())
+			sources = config.ReplaceDisabled(sources, path, state)
+		}
+	}
+	var done func() error
+	if toggle {
+		done = func() error {
+			if err := pipeline.WriteDisabled(path, disabled); err != nil {
+				return i18n.Errorf("hooks 文件已更新，但无法写入停用记录 %s: %v", config.DisabledPath(path), err)
+			}
+			return nil
+		}
+	}
+	result, err := pipeline.CommitOptions(sources, path, func(current []byte) ([]byte, error) {
+		var data []byte
+		var err error
+		if disabled, err = config.LoadDisabled(path); err != nil {
+			return nil, err
+		}
+		data, id, err = r.apply(path, config.Merge(sources), current, disabled)
+		return data, err
+	}, pipeline.Options{Done: done, AllowAdminChanges: r.AllowAdminChanges})
+	if verr, ok := err.(*pipeline.ValidationError); ok && verr.Admin {
+		s.renderAdminConfirm(w, verr, s.HookURL+r.Action, []confirmField{
+			{Name: "file", Value: r.File},
+			{Name: "id", Value: r.ID},
+			{Name: "content", Value: r.Content, Multiline: true},
+			{Name: "revision", Value: r.Revision},
+			{Name: "new_id", Value: r.NewID},
+			{Name: "position", Value: r.Position},
+			{Name: "reason", Value: r.Reason},
+		})
+		return false
+	} else if ok {
+		s.renderInvalid(w, i18n.M(messages.failed), verr)
+		return false
+	} else if err != nil {
+		return fail(i18n.M("%v", err))
+	}
+
+	s.renderResponse(w, response{Title: result.Title, Message: i18n.M(messages.done, id, path), Sections: result.Sections})
+	return result.OK
+}
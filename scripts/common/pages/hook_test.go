@@ -0,0 +1,154 @@
+package pages
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/reload"
+)
+
+// toggleHooks 中 b 带有上方注释和行尾注释，启用后应原样回到第二个位置
+const toggleHooks = `# 测试配置
+
+- id: a
+  execute-command: /bin/true
+
+# 构建
+- id: b # 每晚运行
+  execute-command: /bin/true
+  x-owner: ci
+
+- id: c
+  execute-command: /bin/true
+`
+
+// setupToggle 在临时目录中写入 toggleHooks，用模拟的 webhook 服务（提供文件中当前的 hook）完成重载和探测
+func setupToggle(t *testing.T) (Site, string) {
+	work := t.TempDir()
+	path := filepath.Join(work, "hooks.yaml")
+	if err := os.WriteFile(path, []byte(toggleHooks), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := os.ReadFile(path)
+		hooks, _ := config.Parse(data)
+		if r.Method == reload.ProbeMethod && hooks.Find(strings.TrimPrefix(r.URL.Path, "/hooks/")) != nil {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("WEBHOOK_URL", server.URL+"/hooks")
+	t.Setenv("RELOAD_METHOD", "command")
+	t.Setenv("RELOAD_COMMAND", "true")
+	t.Setenv("RELOAD_CONFIRM_TIMEOUT", "500ms")
+	t.Setenv("BACKUP_DIR", filepath.Join(work, "backup"))
+	t.Setenv("ADMIN_RECOVERY_FILE", "")
+	return Site{Load: func() ([]config.Source, error) { return config.LoadAll(path) }, URLPrefix: "/hooks"}, path
+}
+
+func TestHookDisableEnable(t *testing.T) {
+	site, path := setupToggle(t)
+
+	var out bytes.Buffer
+	by := Operator("", "alice", "10.0.0.1:5000")
+	if !site.Hook(&out, HookRequest{Action: actionDisable, ID: "b", Reason: "排查问题", By: by}) {
+		t.Fatalf("停用失败:\n%s", out.String())
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "id: b") || strings.Contains(string(data), "每晚运行") {
+		t.Errorf("停用后 hooks 文件中仍有 b:\n%s", data)
+	}
+	state, err := config.LoadDisabled(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Hooks) != 1 {
+		t.Fatalf("旁路文件中有 %d 个停用的 hook，应为 1", len(state.Hooks))
+	}
+	disabled := state.Hooks[0]
+	if disabled.ID != "b" || disabled.Index != 1 || disabled.By != "claimed by alice (10.0.0.1)" || disabled.Reason != "排查问题" {
+		t.Errorf("停用记录为 %+v", disabled)
+	}
+	if !strings.Contains(disabled.Fragment, "- id: b # 每晚运行") || !strings.Contains(disabled.Fragment, "x-owner: ci") {
+		t.Errorf("旁路文件中的原文不完整:\n%s", disabled.Fragment)
+	}
+	if disabled.Hook.ExecuteCommand != "/bin/true" {
+		t.Errorf("旁路文件中的定义解析为 %+v", disabled.Hook)
+	}
+
+	// 再次停用同一个 hook 被拒绝，文件不变
+	out.Reset()
+	if site.Hook(&out, HookRequest{Action: actionDisable, ID: "b", Reason: "again", By: by}) {
+		t.Error("重复停用没有报错")
+	}
+
+	out.Reset()
+	if !site.Hook(&out, HookRequest{Action: actionEnable, ID: "b", By: Operator("bob", "", "10.0.0.2")}) {
+		t.Fatalf("启用失败:\n%s", out.String())
+	}
+	if data, _ := os.ReadFile(path); string(data) != toggleHooks {
+		t.Errorf("启用后 hooks 文件与停用前不同:\n%s", data)
+	}
+	if state, err = config.LoadDisabled(path); err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Hooks) != 0 {
+		t.Errorf("启用后旁路文件中仍有 %v", state.Hooks)
+	}
+	if len(state.History) != 2 {
+		t.Fatalf("旁路文件中有 %d 条记录，应为 2: %+v", len(state.History), state.History)
+	}
+	if r := state.History[0]; r.Action != "disable" || r.ID != "b" || r.By != "claimed by alice (10.0.0.1)" || r.Reason != "排查问题" {
+		t.Errorf("停用记录为 %+v", r)
+	}
+	if r := state.History[1]; r.Action != "enable" || r.ID != "b" || r.By != "bob (10.0.0.2)" {
+		t.Errorf("启用记录为 %+v", r)
+	}
+}
+
+func TestOperator(t *testing.T) {
+	cases := []struct {
+		user, claimed, addr, want string
+	}{
+		{"alice", "", "10.0.0.1:5000", "alice (10.0.0.1)"},
+		{"alice", "mallory", "10.0.0.1:5000", "alice (10.0.0.1)"},
+		{"", "mallory", "10.0.0.1:5000", "claimed by mallory (10.0.0.1)"},
+		{"", " ", "[::1]:5000", "::1"},
+		{"", "", "", "unknown"},
+	}
+	for _, tc := range cases {
+		if got := Operator(tc.user, tc.claimed, tc.addr); got != tc.want {
+			t.Errorf("Operator(%q, %q, %q) = %q，应为 %q", tc.user, tc.claimed, tc.addr, got, tc.want)
+		}
+	}
+}
+
+func TestTrustedProxy(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "127.0.0.1, 10.1.0.0/16,::1")
+	cases := map[string]bool{
+		"127.0.0.1:5000":        true,
+		"[::ffff:127.0.0.1]:80": true,
+		"10.1.2.3:80":           true,
+		"[::1]:80":              true,
+		"10.2.0.1:80":           false,
+		"192.168.1.1":           false,
+		"":                      false,
+	}
+	for addr, want := range cases {
+		if got := TrustedProxy(addr); got != want {
+			t.Errorf("TrustedProxy(%q) = %v，应为 %v", addr, got, want)
+		}
+	}
+	t.Setenv("TRUSTED_PROXIES", "")
+	if TrustedProxy("127.0.0.1:5000") {
+		t.Error("未设置 TRUSTED_PROXIES 时信任了 127.0.0.1")
+	}
+}
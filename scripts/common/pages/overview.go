@@ -0,0 +1,225 @@
+package pages
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"webhook-ui/common/config"
+)
+
+// Overview 是主页的搜索、筛选、排序和展示方式。全部来自查询参数，复制链接即可分享同一视图
+type Overview struct {
+	Search    string // ?q=：在 id、执行命令和传递给命令的参数中搜索，不区分大小写
+	Method    string // ?method=：只显示接受该 HTTP 方法的 hook，未限制 http-methods 的 hook 接受所有方法
+	Rule      string // ?rule=with|without：有或没有 trigger-rule
+	File      string // ?file=：只显示这个 hooks 文件
+	State     string // ?state=enabled|disabled：只显示启用或停用的 hook
+	Sort      string // ?sort=id|command：排序方式，默认按配置中的顺序
+	View      string // ?view=table：表格视图，默认为卡片
+	Collapsed bool   // ?collapsed=1：卡片默认折叠，只显示 id 和执行命令
+}
+
+// ParseOverview 从查询参数读取主页的视图，无法识别的值按默认值处理
+func ParseOverview(query url.Values) Overview {
+	oneOf := func(value string, allowed ...string) string {
+		for _, a := range allowed {
+			if value == a {
+				return value
+			}
+		}
+		return ""
+	}
+	return Overview{
+		Search:    strings.TrimSpace(query.Get("q")),
+		Method:    strings.ToUpper(strings.TrimSpace(query.Get("method"))),
+		Rule:      oneOf(query.Get("rule"), "with", "without"),
+		File:      query.Get("file"),
+		State:     oneOf(query.Get("state"), "enabled", "disabled"),
+		Sort:      oneOf(query.Get("sort"), "id", "command"),
+		View:      oneOf(query.Get("view"), "table"),
+		Collapsed: query.Get("collapsed") == "1",
+	}
+}
+
+// Values 把视图转换回查询参数，省略默认值
+func (o Overview) Values() url.Values {
+	values := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			values.Set(key, value)
+		}
+	}
+	set("q", o.Search)
+	set("method", o.Method)
+	set("rule", o.Rule)
+	set("file", o.File)
+	set("state", o.State)
+	set("sort", o.Sort)
+	set("view", o.View)
+	if o.Collapsed {
+		values.Set("collapsed", "1")
+	}
+	return values
+}
+
+// WithView 返回切换到卡片（view 为空）或表格视图、其余条件不变的查询字符串，供页面中的链接使用
+func (o Overview) WithView(view string) string {
+	o.View = view
+	return "?" + o.Values().Encode()
+}
+
+// WithoutFilters 返回清除搜索和筛选条件、保留排序和展示方式的查询字符串
+func (o Overview) WithoutFilters() string {
+	return Overview{Sort: o.Sort, View: o.View, Collapsed: o.Collapsed}.WithView(o.View)
+}
+
+// Filtered 返回是否设置了任一筛选条件
+func (o Overview) Filtered() bool {
+	return o.Search != "" || o.Method != "" || o.Rule != "" || o.File != "" || o.State != ""
+}
+
+// methods 返回 hook 限制的 HTTP 方法（webhook 的配置中常写成 "GET "，这里去掉空格并转为大写）
+func methods(h config.Hook) []string {
+	var list []string
+	for _, m := range h.HTTPMethods {
+		if m = strings.ToUpper(strings.TrimSpace(m)); m != "" {
+			list = append(list, m)
+		}
+	}
+	return list
+}
+
+// match 返回 hook 是否满足搜索和筛选条件（文件和启用状态由调用方处理）
+func (o Overview) match(h config.Hook) bool {
+	if o.Search != "" {
+		text := []string{h.ID, h.ExecuteCommand}
+		for _, arg := range h.PassArgumentsToCommand {
+			text = append(text, arg.Name, arg.EnvName)
+		}
+		if !strings.Contains(strings.ToLower(strings.Join(text, "\n")), strings.ToLower(o.Search)) {
+			return false
+		}
+	}
+	if o.Method != "" {
+		if list := methods(h); len(list) > 0 && !contains(list, o.Method) {
+			return false
+		}
+	}
+	switch o.Rule {
+	case "with":
+		return h.TriggerRule != nil
+	case "without":
+		return h.TriggerRule == nil
+	}
+	return true
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// less 按视图的排序方式比较两个 hook，返回 false 时保持配置中的顺序（配合 sort.SliceStable 使用）
+func (o Overview) less(a, b config.Hook) bool {
+	switch o.Sort {
+	case "id":
+		return a.ID < b.ID
+	case "command":
+		if a.ExecuteCommand != b.ExecuteCommand {
+			return a.ExecuteCommand < b.ExecuteCommand
+		}
+		return a.ID < b.ID
+	}
+	return false
+}
+
+// overviewSource 是主页中的一个 hooks 文件，Shown 和 ShownDisabled 是满足视图条件的 hook
+type overviewSource struct {
+	config.Source
+	Shown         []config.Hook
+	ShownDisabled []config.DisabledHook
+}
+
+// overviewRow 是表格视图中的一行
+type overviewRow struct {
+	File     string
+	Hook     config.Hook
+	Disabled *config.DisabledHook // 停用的 hook，启用的 hook 为 nil
+}
+
+// Methods 返回表格中显示的 HTTP 方法，未限制时为空
+func (r overviewRow) Methods() []string {
+	return methods(r.Hook)
+}
+
+// apply 按视图筛选和排序 sources 中的 hook，返回卡片视图的分组和表格视图的行（表格跨文件统一排序）
+func (o Overview) apply(sources []config.Source) ([]overviewSource, []overviewRow) {
+	var groups []overviewSource
+	var rows []overviewRow
+	for _, source := range sources {
+		if o.File != "" && source.Path != o.File {
+			continue
+		}
+		group := overviewSource{Source: source}
+		if o.State != "disabled" {
+			for _, h := range source.Hooks {
+				if o.match(h) {
+					group.Shown = append(group.Shown, h)
+				}
+			}
+		}
+		if o.State != "enabled" && source.Disabled != nil {
+			for _, d := range source.Disabled.Hooks {
+				if o.match(d.Hook) {
+					group.ShownDisabled = append(group.ShownDisabled, d)
+				}
+			}
+		}
+		sort.SliceStable(group.Shown, func(i, j int) bool { return o.less(group.Shown[i], group.Shown[j]) })
+		sort.SliceStable(group.ShownDisabled, func(i, j int) bool {
+			return o.less(group.ShownDisabled[i].Hook, group.ShownDisabled[j].Hook)
+		})
+		for _, h := range group.Shown {
+			rows = append(rows, overviewRow{File: source.Path, Hook: h})
+		}
+		for i := range group.ShownDisabled {
+			rows = append(rows, overviewRow{File: source.Path, Hook: group.ShownDisabled[i].Hook, Disabled: &group.ShownDisabled[i]})
+		}
+		groups = append(groups, group)
+	}
+	if o.Sort != "" {
+		sort.SliceStable(rows, func(i, j int) bool { return o.less(rows[i].Hook, rows[j].Hook) })
+	}
+	return groups, rows
+}
+
+// allMethods 返回所有 hook 限制的 HTTP 方法，用于筛选的下拉列表
+func allMethods(sources []config.Source) []string {
+	seen := map[string]bool{}
+	var list []string
+	add := func(h config.Hook) {
+		for _, m := range methods(h) {
+			if !seen[m] {
+				seen[m] = true
+				list = append(list, m)
+			}
+		}
+	}
+	for _, source := range sources {
+		for _, h := range source.Hooks {
+			add(h)
+		}
+		if source.Disabled != nil {
+			for _, d := range source.Disabled.Hooks {
+				add(d.Hook)
+			}
+		}
+	}
+	sort.Strings(list)
+	return list
+}
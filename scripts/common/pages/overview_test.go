@@ -0,0 +1,116 @@
+package pages
+
+import (
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"webhook-ui/common/config"
+)
+
+func TestParseOverview(t *testing.T) {
+	cases := []struct {
+		query string
+		want  Overview
+	}{
+		{"", Overview{}},
+		{"q=+Deploy+&method=post&rule=with&file=%2Fetc%2Fhooks.yaml&state=disabled&sort=command&view=table&collapsed=1",
+			Overview{Search: "Deploy", Method: "POST", Rule: "with", File: "/etc/hooks.yaml", State: "disabled", Sort: "command", View: "table", Collapsed: true}},
+		// 无法识别的值按默认值处理
+		{"rule=maybe&state=all&sort=time&view=grid&collapsed=yes", Overview{}},
+		{"sort=ID&view=TABLE", Overview{}},
+	}
+	for _, tc := range cases {
+		query, err := url.ParseQuery(tc.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ParseOverview(query); got != tc.want {
+			t.Errorf("ParseOverview(%q) = %+v，应为 %+v", tc.query, got, tc.want)
+		}
+	}
+}
+
+// TestOverviewValuesRoundTrip 检查视图转换为查询参数再解析后不变，默认值不出现在链接中
+func TestOverviewValuesRoundTrip(t *testing.T) {
+	views := []Overview{
+		{},
+		{Search: "a&b=c #x", Method: "GET", Rule: "without", File: "/etc/webhook/hooks d.yaml", State: "enabled", Sort: "id", View: "table", Collapsed: true},
+		{Sort: "command", Collapsed: true},
+	}
+	for _, o := range views {
+		if got := ParseOverview(o.Values()); got != o {
+			t.Errorf("%+v 转换后解析为 %+v", o, got)
+		}
+	}
+	if got := (Overview{}).WithView(""); got != "?" {
+		t.Errorf("默认视图的链接为 %q", got)
+	}
+	o := Overview{Search: "deploy", Method: "POST", Sort: "id", View: "table", Collapsed: true}
+	if got := o.WithView(""); strings.Contains(got, "view=") || !strings.Contains(got, "q=deploy") {
+		t.Errorf("切换到卡片视图的链接为 %q", got)
+	}
+	cleared, _ := url.ParseQuery(strings.TrimPrefix(o.WithoutFilters(), "?"))
+	if got := ParseOverview(cleared); got != (Overview{Sort: "id", View: "table", Collapsed: true}) || got.Filtered() {
+		t.Errorf("清除筛选后的视图为 %+v", got)
+	}
+	if !o.Filtered() {
+		t.Error("设置了搜索时 Filtered 为 false")
+	}
+}
+
+func TestOverviewApply(t *testing.T) {
+	rule := &config.Rules{Match: &config.MatchRule{Type: "value"}}
+	sources := []config.Source{
+		{Path: "a.yaml", Hooks: config.Config{
+			{ID: "zeta", ExecuteCommand: "/opt/b.sh", HTTPMethods: []string{"post "}},
+			{ID: "alpha", ExecuteCommand: "/opt/c.sh", TriggerRule: rule},
+			{ID: "mid", ExecuteCommand: "/opt/a.sh", PassArgumentsToCommand: []config.Argument{{Source: "payload", Name: "Deploy-Target"}}},
+		}, Disabled: &config.DisabledState{Hooks: []config.DisabledHook{
+			{ID: "off", Hook: config.Hook{ID: "off", ExecuteCommand: "/opt/0.sh", HTTPMethods: []string{"GET"}}},
+		}}},
+		{Path: "b.yaml", Hooks: config.Config{{ID: "beta", ExecuteCommand: "/opt/a.sh", HTTPMethods: []string{"GET"}}}},
+	}
+	ids := func(rows []overviewRow) []string {
+		var list []string
+		for _, r := range rows {
+			list = append(list, r.Hook.ID)
+		}
+		return list
+	}
+	cases := []struct {
+		query string
+		want  []string
+	}{
+		{"", []string{"zeta", "alpha", "mid", "off", "beta"}}, // 配置中的顺序，停用的排在各文件最后
+		{"sort=id", []string{"alpha", "beta", "mid", "off", "zeta"}},
+		{"sort=command", []string{"off", "beta", "mid", "zeta", "alpha"}}, // 命令相同时按 id
+		{"q=deploy-target", []string{"mid"}},                              // 搜索传递给命令的参数，不区分大小写
+		{"q=A.SH", []string{"mid", "beta"}},
+		{"method=post", []string{"zeta", "alpha", "mid"}}, // 未限制方法的 hook 接受所有方法
+		{"method=GET", []string{"alpha", "mid", "off", "beta"}},
+		{"rule=with", []string{"alpha"}},
+		{"rule=without&state=enabled", []string{"zeta", "mid", "beta"}},
+		{"state=disabled", []string{"off"}},
+		{"file=b.yaml", []string{"beta"}},
+		{"q=nothing", nil},
+	}
+	for _, tc := range cases {
+		query, _ := url.ParseQuery(tc.query)
+		groups, rows := ParseOverview(query).apply(sources)
+		if got := ids(rows); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%q: 显示 %v，应为 %v", tc.query, got, tc.want)
+		}
+		shown := 0
+		for _, g := range groups {
+			shown += len(g.Shown) + len(g.ShownDisabled)
+		}
+		if shown != len(tc.want) {
+			t.Errorf("%q: 卡片视图显示 %d 个 hook，表格中有 %d 个", tc.query, shown, len(tc.want))
+		}
+	}
+	if got := allMethods(sources); !reflect.DeepEqual(got, []string{"GET", "POST"}) {
+		t.Errorf("allMethods = %v", got)
+	}
+}
@@ -0,0 +1,63 @@
+// Package pages 实现各个页面和表单处理：主页、编辑页、保存、单个 hook 操作、上传页和上传。
+// 每个 scripts 下的脚本读取 webhook 传入的参数和环境变量后调用这里的方法，把页面写到标准输出；
+// 独立服务（scripts/server）从 HTTP 请求中读取相同的参数，调用相同的方法写到响应中
+package pages
+
+import (
+	"io"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/i18n"
+	"webhook-ui/common/pipeline"
+)
+
+// Site 是所有页面共用的设置
+type Site struct {
+	Load      func() ([]config.Source, error) // 读取 hooks 文件：脚本每次调用 config.LoadAll，独立服务使用 config.Cache
+	UploadDir string                          // 上传目录
+
+	// 各页面的链接，已包含 URL 前缀，如 /hooks/ui
+	HomeURL        string
+	EditURL        string
+	SaveURL        string
+	UploadURL      string
+	HookURL        string // 单个 hook 操作的前缀，<HookURL>delete、<HookURL>move 等
+	DetailURL      string // hook 详情页，<DetailURL>?id=<hook id>
+	HookUpdateURL  string // 编辑页只编辑一个 hook 时的保存地址
+	UploadChunkURL string
+	UploadRawURL   string
+	URLPrefix      string
+
+	Lang string // 页面语言，由 i18n.Negotiate 根据 Cookie 和 Accept-Language 请求头选择，为空时使用 i18n.Default
+}
+
+// response 是结果页 templates/result.html 的内容，用于保存、单个 hook 操作和上传。
+// 所有字段都是纯文本（包括文件名、解析错误等用户输入），由模板按所在位置转义，不要拼接 HTML
+type response struct {
+	Title    i18n.Message
+	Message  i18n.Message       // 说明，多行时逐行显示
+	Detail   string             // 错误详情，如 YAML 解析错误，原样显示在 <pre> 中
+	Sections []pipeline.Section // 保存后的重载和健康检查结果
+}
+
+// renderResponse 向客户端返回结果页，返回按钮回到主页
+func (s Site) renderResponse(w io.Writer, r response) {
+	s.render(w, "result", struct {
+		Layout
+		Message  i18n.Message
+		Detail   string
+		Sections []pipeline.Section
+		BackURL  string
+	}{
+		Layout:   s.layout("result", r.Title, r.Title),
+		Message:  r.Message,
+		Detail:   r.Detail,
+		Sections: r.Sections,
+		BackURL:  s.HomeURL,
+	})
+}
+
+// renderInvalid 返回新内容没有通过检查时的结果页，错误详情单独显示
+func (s Site) renderInvalid(w io.Writer, title i18n.Message, verr *pipeline.ValidationError) {
+	s.renderResponse(w, response{Title: title, Message: i18n.M("%s，请检查:", verr.Title), Detail: verr.Detail})
+}
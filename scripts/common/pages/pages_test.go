@@ -0,0 +1,181 @@
+package pages
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/history"
+)
+
+// 用 testdata 中的恶意文件名和会产生错误信息的配置内容渲染上传、保存的结果页和 hook 详情页，
+// 与使用普通输入的同一页面比较危险片段出现的次数，多出来的片段说明用户输入未经转义进入了页面。
+
+// dangerous 是注入成功时页面中会多出来的片段，比较时不区分大小写：新的标签或注释，以及跳出属性值后的事件属性。
+// 转义后的文字中仍会有 onerror= 等字样，但前面的 < 和引号已被转义，不会生效
+var dangerous = []string{
+	"<img", "<script", "<svg", "<iframe", "<body", "<a href", "<style>body", "<!--",
+	`" on`, `' on`, `"on`, `'on`,
+}
+
+// readCorpus 读取语料文件，去掉空行和 # 开头的注释行
+func readCorpus(t *testing.T, name string) []string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) != "" && !strings.HasPrefix(line, "#") {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// splitCases 把 hostile_configs.txt 的内容按 "=== 说明" 行分成用例，返回说明和内容
+func splitCases(lines []string) ([]string, []string) {
+	var names, contents []string
+	for _, line := range lines {
+		if name, ok := strings.CutPrefix(line, "=== "); ok {
+			names = append(names, name)
+			contents = append(contents, "")
+			continue
+		}
+		if len(contents) > 0 {
+			contents[len(contents)-1] += line + "\n"
+		}
+	}
+	return names, contents
+}
+
+// countDangerous 统计页面中各危险片段出现的次数
+func countDangerous(page string) map[string]int {
+	page = strings.ToLower(page)
+	counts := map[string]int{}
+	for _, token := range dangerous {
+		counts[token] = strings.Count(page, token)
+	}
+	return counts
+}
+
+// escapeSite 是在临时目录中准备了 hooks 文件、上传目录和执行记录的 Site
+type escapeSite struct {
+	work        string
+	historyFile string
+	site        Site
+}
+
+func newEscapeSite(t *testing.T) *escapeSite {
+	work := t.TempDir()
+	hooksFile := filepath.Join(work, "hooks.yaml")
+	if err := os.WriteFile(hooksFile, []byte("[]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 校验失败时不会写入配置，也不需要管理 hook 的恢复文件
+	t.Setenv("ADMIN_RECOVERY_FILE", "")
+	t.Setenv("RELOAD_METHOD", "none")
+	historyFile := filepath.Join(work, "history.jsonl")
+	t.Setenv("HISTORY_FILE", historyFile)
+	return &escapeSite{
+		work:        work,
+		historyFile: historyFile,
+		site: Site{
+			Load:      func() ([]config.Source, error) { return config.LoadAll(hooksFile) },
+			UploadDir: filepath.Join(work, "upload"),
+			URLPrefix: "/hooks",
+		},
+	}
+}
+
+// upload 上传一个名为 name 的文件，返回结果页
+func (e *escapeSite) upload(name string) string {
+	src := filepath.Join(e.work, "incoming")
+	os.WriteFile(src, []byte("#!/bin/sh\n"), 0644)
+	var buf bytes.Buffer
+	e.site.Upload(&buf, "html", src, name, true)
+	return buf.String()
+}
+
+// save 提交配置内容 content，返回结果页
+func (e *escapeSite) save(content string) string {
+	var buf bytes.Buffer
+	e.site.Save(&buf, "", content, false)
+	return buf.String()
+}
+
+// detail 把 name 写成一个 hook 的 id、命令、触发规则和参数，并记录一次请求，渲染这个 hook 的详情页。
+// 没有找到 hook 或执行记录时返回空字符串，避免只检查了 "hook 不存在" 的提示页
+func (e *escapeSite) detail(name string) string {
+	detailFile := filepath.Join(e.work, "detail.yaml")
+	hook := config.Hook{
+		ID:                     name,
+		ExecuteCommand:         name,
+		ResponseMessage:        name,
+		PassArgumentsToCommand: []config.Argument{{Source: "payload", Name: name}, {Source: "header", Name: name}},
+		TriggerRule: &config.Rules{And: &config.AndRule{
+			{Match: &config.MatchRule{Type: "value", Value: name, Parameter: config.Argument{Source: "url", Name: name}}},
+			{Match: &config.MatchRule{Type: "regex", Regex: name, Parameter: config.Argument{Source: "header", Name: name}}},
+		}},
+	}
+	data, _ := config.Marshal([]config.Hook{hook})
+	os.WriteFile(detailFile, data, 0644)
+	line, _ := json.Marshal(history.Entry{RequestID: name, Time: time.Now(), Hook: name, Method: "POST", Path: "/hooks/" + name, Query: name, RemoteAddr: name, Body: name, Response: name, Error: name})
+	os.WriteFile(e.historyFile, append(line, '\n'), 0644)
+
+	site := e.site
+	site.Load = func() ([]config.Source, error) { return config.LoadAll(detailFile) }
+	var buf bytes.Buffer
+	site.Detail(&buf, DetailRequest{ID: name})
+	if !strings.Contains(buf.String(), `class="executions"`) {
+		return ""
+	}
+	return buf.String()
+}
+
+func TestHostileInputIsEscaped(t *testing.T) {
+	e := newEscapeSite(t)
+	names := readCorpus(t, "hostile_filenames.txt")
+	caseNames, configs := splitCases(readCorpus(t, "hostile_configs.txt"))
+
+	type testCase struct {
+		kind, name string
+		render     func() string
+	}
+	var cases []testCase
+	for _, name := range names {
+		cases = append(cases, testCase{"upload", name, func() string { return e.upload(name) }})
+	}
+	for i, content := range configs {
+		cases = append(cases, testCase{"save", caseNames[i], func() string { return e.save(content) }})
+	}
+	for _, name := range names {
+		cases = append(cases, testCase{"detail", name, func() string { return e.detail(name) }})
+	}
+	baselines := map[string]map[string]int{
+		"upload": countDangerous(e.upload("deploy.sh")),
+		"save":   countDangerous(e.save("- id: a\n  execute-command: /bin/true\n- id: a\n  execute-command: /bin/true\n")),
+		"detail": countDangerous(e.detail("deploy.sh")),
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.kind+"/"+tc.name, func(t *testing.T) {
+			page := tc.render()
+			if !strings.Contains(page, "</html>") {
+				t.Fatalf("页面不完整:\n%s", page)
+			}
+			baseline := baselines[tc.kind]
+			for token, n := range countDangerous(page) {
+				if n > baseline[token] {
+					t.Errorf("%s 多出 %d 处，用户输入未经转义", token, n-baseline[token])
+				}
+			}
+		})
+	}
+}
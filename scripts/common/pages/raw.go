@@ -0,0 +1,173 @@
+package pages
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"webhook-ui/common/i18n"
+)
+
+// 直接上传（upload-raw）：不经过 base64，请求体就是上传内容。
+// 通过 webhook 调用时，webhook 把原始请求体通过 raw-request-body 写入 UPLOADED_BODY_PATH；独立服务直接读取请求体。
+//   - Content-Type 为 multipart/form-data 时，逐个 part 流式写到磁盘，支持一次上传多个文件
+//   - 其他 Content-Type 时，整个请求体就是文件内容，文件名来自 X-File-Name 请求头
+//
+// 每个文件都与普通上传一样交给 storeUpload 处理（包括压缩包解压）。
+
+// headerFileName 解码请求头中的文件名。浏览器端用 encodeURIComponent 编码非 ASCII 文件名
+func headerFileName(value string) string {
+	if decoded, err := url.PathUnescape(value); err == nil {
+		return decoded
+	}
+	return value
+}
+
+// storeMultipart 逐个读取 multipart 的文件 part，先流式写入目标目录下的临时文件，再交给 storeUpload
+func storeMultipart(body io.Reader, boundary, uploadDestDir string, extract bool) ([]uploadResult, error) {
+	var results []uploadResult
+	mr := multipart.NewReader(body, boundary)
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return results, i18n.Errorf("脚本：无法解析 multipart 请求体: %v", err)
+		}
+		name := part.FileName()
+		if name == "" {
+			// 普通表单字段，忽略
+			part.Close()
+			continue
+		}
+
+		result := uploadResult{FileName: name}
+		tmp, err := spool(part, uploadDestDir)
+		part.Close()
+		if err != nil {
+			result.Title = i18n.M("上传失败")
+			result.Message = i18n.M("%v", err)
+			results = append(results, result)
+			continue
+		}
+
+		result.Success, result.Title, result.Message = storeUpload(tmp, name, uploadDestDir, extract)
+		os.Remove(tmp) // storeUpload 成功时临时文件已被移走，失败时在此清理
+		results = append(results, result)
+	}
+	if len(results) == 0 {
+		return nil, i18n.Errorf("脚本：multipart 请求中没有文件")
+	}
+	return results, nil
+}
+
+// spool 把 r 的内容写入 dir 中的临时文件并返回其路径。临时文件放在目标目录中，保证后续 os.Rename 不会跨设备
+func spool(r io.Reader, dir string) (string, error) {
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return "", i18n.Errorf("脚本：无法创建临时文件: %v", err)
+	}
+	_, copyErr := io.Copy(tmp, r)
+	closeErr := tmp.Close()
+	if copyErr != nil || closeErr != nil {
+		os.Remove(tmp.Name())
+		return "", i18n.Errorf("脚本：无法写入文件内容: %v", firstErr(copyErr, closeErr))
+	}
+	return tmp.Name(), nil
+}
+
+func firstErr(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renderResults 输出直接上传的结果：json 时输出 uploadJSON 数组，否则输出一个汇总的 HTML 页面
+func (s Site) renderResults(w io.Writer, format string, results []uploadResult) {
+	if format == "json" {
+		p := s.printer()
+		out := make([]uploadJSON, len(results))
+		for i, r := range results {
+			out[i] = r.json(p)
+		}
+		if err := json.NewEncoder(w).Encode(out); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON response: %v\n", err)
+		}
+		return
+	}
+	if len(results) == 1 {
+		s.renderResponse(w, response{Title: results[0].Title, Message: results[0].Message})
+		return
+	}
+	// 每个文件一行“标题：说明”，各行分别翻译
+	title := i18n.M("上传成功")
+	var lines []string
+	var args []any
+	for _, r := range results {
+		if !r.Success {
+			title = i18n.M("部分文件上传失败")
+		}
+		lines = append(lines, "%s：%s")
+		args = append(args, r.Title, r.Message)
+	}
+	s.renderResponse(w, response{Title: title, Message: i18n.M(strings.Join(lines, "\n"), args...)})
+}
+
+// UploadRaw 处理一次直接上传请求，body 是请求体（没有时为 nil），contentType 和 fileName 来自
+// Content-Type 和 X-File-Name 请求头。返回是否全部成功
+func (s Site) UploadRaw(w io.Writer, format string, body io.Reader, contentType, fileName string, extract bool) bool {
+	fail := func(message i18n.Message) bool {
+		s.renderResults(w, format, []uploadResult{{Title: i18n.M("上传失败"), Message: message}})
+		return false
+	}
+	if body == nil {
+		return fail(i18n.M("脚本：未接收到请求体。"))
+	}
+	if err := os.MkdirAll(s.UploadDir, 0755); err != nil {
+		return fail(i18n.M("脚本：无法创建目标目录 %s: %v", s.UploadDir, err))
+	}
+
+	var results []uploadResult
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if mediaType == "multipart/form-data" {
+		var err error
+		results, err = storeMultipart(body, params["boundary"], s.UploadDir, extract)
+		if err != nil {
+			if len(results) == 0 {
+				return fail(i18n.M("%v", err))
+			}
+			results = append(results, uploadResult{Title: i18n.M("上传失败"), Message: i18n.M("%v", err)})
+		}
+	} else {
+		name := headerFileName(fileName)
+		if name == "" {
+			return fail(i18n.M("脚本：缺少文件名，请通过 X-File-Name 请求头提供。"))
+		}
+		tmp, err := spool(body, s.UploadDir)
+		if err != nil {
+			return fail(i18n.M("%v", err))
+		}
+		result := uploadResult{FileName: name}
+		result.Success, result.Title, result.Message = storeUpload(tmp, filepath.Base(name), s.UploadDir, extract)
+		os.Remove(tmp)
+		results = append(results, result)
+	}
+
+	s.renderResults(w, format, results)
+	for _, r := range results {
+		if !r.Success {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package pages
+
+import (
+	"io"
+
+	"webhook-ui/common/i18n"
+	"webhook-ui/common/pipeline"
+)
+
+// Save 把编辑页提交的 content 保存到 file（默认第一个 hooks 文件），返回新配置是否已生效。
+// 删除或改动管理 hook 时显示确认页，allowAdminChanges 表示已在确认页输入确认文字，见 adminhooks.Confirmed
+func (s Site) Save(w io.Writer, file, content string, allowAdminChanges bool) bool {
+	if content == "" {
+		s.renderResponse(w, response{Title: i18n.M("错误"), Message: i18n.M("未在请求中找到 'config' 字段内容。\n请确认webhook配置正确传递了'-config'参数。")})
+		return false
+	}
+
+	// HOOKS 可以是逗号分隔的多个文件或通配符，file 是编辑页提交的文件，默认保存到第一个文件
+	sources, err := s.Load()
+	if err != nil {
+		s.renderResponse(w, response{Title: i18n.M("保存失败"), Message: i18n.M("%v", err)})
+		return false
+	}
+	configFilePath, err := pipeline.Target(sources, file)
+	if err != nil {
+		s.renderResponse(w, response{Title: i18n.M("保存失败"), Message: i18n.M("%v", err)})
+		return false
+	}
+
+	// 校验（模板、YAML/JSON 语法、重复 id）、备份、原子写入，然后通知 webhook 重新加载并检查新配置是否生效
+	result, err := pipeline.CommitOptions(sources, configFilePath, func([]byte) ([]byte, error) {
+		return []byte(content), nil
+	}, pipeline.Options{AllowAdminChanges: allowAdminChanges})
+	if verr, ok := err.(*pipeline.ValidationError); ok && verr.Admin {
+		s.renderAdminConfirm(w, verr, s.SaveURL, []confirmField{{Name: "file", Value: file}, {Name: "config", Value: content, Multiline: true}})
+		return false
+	} else if ok {
+		s.renderInvalid(w, i18n.M("保存失败"), verr)
+		return false
+	} else if err != nil {
+		s.renderResponse(w, response{Title: i18n.M("保存失败"), Message: i18n.M("%v", err)})
+		return false
+	}
+
+	// 返回响应
+	if !result.OK {
+		s.renderResponse(w, response{Title: result.Title, Sections: result.Sections})
+		return false
+	}
+	s.renderResponse(w, response{Title: result.Title, Message: i18n.M("Webhook 配置已成功更新！"), Sections: result.Sections})
+	return true
+}
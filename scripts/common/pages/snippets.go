@@ -0,0 +1,319 @@
+package pages
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/i18n"
+)
+
+// placeholderBase 是没有设置 PUBLIC_URL 时调用示例中 webhook 的地址，详情页的脚本把它替换为当前页面的地址
+const placeholderBase = "https://webhook.example.com"
+
+// param 是请求中的一个参数
+type param struct {
+	Name  string
+	Value string
+}
+
+// clientSignature 是触发规则要求的签名请求头，值为 <Algorithm>=<请求体的 HMAC>
+type clientSignature struct {
+	Header    string
+	Algorithm string // sha1、sha256 或 sha512
+}
+
+// clientRequest 是按 hook 的配置推测出的、能够触发它的请求，用于生成调用示例。
+// 参数值取自触发规则中的 value 条件，其他参数用 <参数名> 占位，签名的密钥从不写入示例
+type clientRequest struct {
+	Method      string
+	URL         string // 不含查询参数
+	Placeholder bool   // URL 中是占位地址 placeholderBase
+	Query       []param
+	Headers     []param
+	ContentType string
+	Body        string
+	Signature   *clientSignature
+	Notes       []i18n.Message // 示例无法满足的条件，由模板用 T 翻译
+}
+
+// snippet 是一段调用示例
+type snippet struct {
+	Name string
+	Code string
+}
+
+// params 按出现顺序记录参数，同名参数只保留第一个
+type params []param
+
+func (p *params) set(name, value string) {
+	for _, item := range *p {
+		if item.Name == name {
+			return
+		}
+	}
+	*p = append(*p, param{Name: name, Value: value})
+}
+
+// newClientRequest 生成触发 hook h 的请求。base 是客户端访问 webhook 的地址，prefix 是 hook 的 URL 前缀
+func newClientRequest(h config.Hook, base, prefix string) clientRequest {
+	c := clientRequest{Method: "POST"}
+	if list := methods(h); len(list) > 0 {
+		c.Method = list[0]
+	}
+	if base == "" {
+		base, c.Placeholder = placeholderBase, true
+	}
+	c.URL = strings.TrimRight(base, "/") + prefix + "/" + url.PathEscape(h.ID)
+
+	var query, headers, payload params
+	wholeBody := false
+	add := func(arg config.Argument, value string) {
+		if value == "" {
+			value = "<" + arg.Name + ">"
+		}
+		switch arg.Source {
+		case "header":
+			headers.set(arg.Name, value)
+		case "url", "query":
+			query.set(arg.Name, value)
+		case "payload":
+			payload.set(arg.Name, value)
+		case "entire-payload", "raw-request-body":
+			wholeBody = true
+		}
+	}
+
+	// 先满足触发规则，再补上传递给命令的参数
+	if h.TriggerRule != nil {
+		c.walk(*h.TriggerRule, add)
+	}
+	for _, list := range [][]config.Argument{h.PassArgumentsToCommand, h.PassEnvironmentToCommand, h.PassFileToCommand, h.JSONStringParameters} {
+		for _, arg := range list {
+			add(arg, "")
+		}
+	}
+
+	c.Query, c.Headers = query, headers
+	if len(payload) > 0 || wholeBody {
+		c.ContentType = h.IncomingPayloadContentType
+		if c.ContentType == "" {
+			c.ContentType = "application/json"
+		}
+		if strings.Contains(c.ContentType, "x-www-form-urlencoded") {
+			c.Body = encodeQuery(payload, true)
+		} else {
+			c.Body = jsonBody(payload)
+		}
+	}
+	if c.Body != "" && !bodyAllowed(c.Method) {
+		c.Notes = append(c.Notes, i18n.M("fetch 不允许 %s 请求带请求体，JavaScript 示例没有发送请求体", c.Method))
+	}
+	return c
+}
+
+// bodyAllowed 返回 fetch 是否允许 method 请求带请求体
+func bodyAllowed(method string) bool {
+	return method != "GET" && method != "HEAD"
+}
+
+// walk 按触发规则添加参数：and 中的条件都要满足；or 只按第一个条件生成；not 中的条件不处理
+func (c *clientRequest) walk(rules config.Rules, add func(config.Argument, string)) {
+	if rules.And != nil {
+		for _, r := range *rules.And {
+			c.walk(r, add)
+		}
+	}
+	if rules.Or != nil && len(*rules.Or) > 0 {
+		if len(*rules.Or) > 1 {
+			c.Notes = append(c.Notes, i18n.M("示例只满足 or 中的第一个条件"))
+		}
+		c.walk((*rules.Or)[0], add)
+	}
+	if rules.Not != nil {
+		c.Notes = append(c.Notes, i18n.M("示例没有考虑 not 中的条件"))
+	}
+	m := rules.Match
+	if m == nil {
+		return
+	}
+	switch m.Type {
+	case "value":
+		add(m.Parameter, m.Value)
+	case "regex":
+		add(m.Parameter, "")
+		c.Notes = append(c.Notes, i18n.M("参数 %s 需要匹配正则表达式 %s", m.Parameter.Name, m.Regex))
+	case "payload-hmac-sha1", "payload-hash-sha1", "payload-hmac-sha256", "payload-hash-sha256", "payload-hmac-sha512", "payload-hash-sha512":
+		algorithm := m.Type[strings.LastIndex(m.Type, "-")+1:]
+		if m.Parameter.Source == "header" && c.Signature == nil {
+			c.Signature = &clientSignature{Header: m.Parameter.Name, Algorithm: algorithm}
+		} else {
+			c.Notes = append(c.Notes, i18n.M("参数 %s 需要是请求体的 HMAC-%s 签名，示例中没有计算", m.Parameter.Name, strings.ToUpper(algorithm)))
+		}
+	case "ip-whitelist":
+		c.Notes = append(c.Notes, i18n.M("请求需要来自 %s", m.IPRange))
+	case "scalr-signature":
+		c.Notes = append(c.Notes, i18n.M("需要 Scalr 签名（X-Signature 和 Date 请求头），示例中没有计算"))
+	}
+}
+
+// encodeQuery 把参数编码为查询字符串。escapePlaceholders 为 false 时 <参数名> 占位不转义，便于在示例中替换
+func encodeQuery(list params, escapePlaceholders bool) string {
+	var parts []string
+	for _, p := range list {
+		value := p.Value
+		if escapePlaceholders || !isPlaceholder(p) {
+			value = url.QueryEscape(value)
+		}
+		parts = append(parts, url.QueryEscape(p.Name)+"="+value)
+	}
+	return strings.Join(parts, "&")
+}
+
+func isPlaceholder(p param) bool {
+	return p.Value == "<"+p.Name+">"
+}
+
+// jsonBody 把 payload 参数组合成 JSON 请求体，webhook 用 . 分隔嵌套的字段，如 repository.name
+func jsonBody(list params) string {
+	body := map[string]interface{}{}
+	for _, p := range list {
+		obj := body
+		keys := strings.Split(p.Name, ".")
+		for _, key := range keys[:len(keys)-1] {
+			next, ok := obj[key].(map[string]interface{})
+			if !ok {
+				next = map[string]interface{}{}
+				obj[key] = next
+			}
+			obj = next
+		}
+		if _, ok := obj[keys[len(keys)-1]].(map[string]interface{}); !ok {
+			obj[keys[len(keys)-1]] = p.Value
+		}
+	}
+	return quoteJSON(body)
+}
+
+// quoteJSON 返回 v 的紧凑 JSON，不转义 <、> 和 &。字符串的 JSON 写法同时也是合法的 Python 和 JavaScript 字符串
+func quoteJSON(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.Encode(v)
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// quoteShell 返回 shell 中的单引号字符串
+func quoteShell(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// headers 返回请求头，包括 Content-Type，按名称排序
+func (c clientRequest) headers() params {
+	list := append(params{}, c.Headers...)
+	if c.ContentType != "" {
+		list.set("Content-Type", c.ContentType)
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// snippets 返回 curl、Python (requests) 和 JavaScript (fetch) 的调用示例
+func (c clientRequest) snippets() []snippet {
+	return []snippet{
+		{Name: "curl", Code: c.curl()},
+		{Name: "Python", Code: c.python()},
+		{Name: "JavaScript", Code: c.javascript()},
+	}
+}
+
+func (c clientRequest) curl() string {
+	var b strings.Builder
+	target := c.URL
+	if len(c.Query) > 0 {
+		target += "?" + encodeQuery(c.Query, false)
+	}
+	if c.Signature != nil {
+		fmt.Fprintf(&b, "BODY=%s\n", quoteShell(c.Body))
+		fmt.Fprintf(&b, "SIGNATURE=$(printf '%%s' \"$BODY\" | openssl dgst -%s -hmac \"$WEBHOOK_SECRET\" | sed 's/^.* //')\n", c.Signature.Algorithm)
+	}
+	fmt.Fprintf(&b, "curl -X %s %s", c.Method, quoteShell(target))
+	for _, h := range c.headers() {
+		fmt.Fprintf(&b, " \\\n  -H %s", quoteShell(h.Name+": "+h.Value))
+	}
+	switch {
+	case c.Signature != nil:
+		fmt.Fprintf(&b, " \\\n  -H %s\"$SIGNATURE\"", quoteShell(c.Signature.Header+": "+c.Signature.Algorithm+"="))
+		b.WriteString(" \\\n  --data-binary \"$BODY\"")
+	case c.Body != "":
+		fmt.Fprintf(&b, " \\\n  --data-binary %s", quoteShell(c.Body))
+	}
+	return b.String()
+}
+
+// pythonDict 返回 Python 字典的写法
+func pythonDict(list params) string {
+	var items []string
+	for _, p := range list {
+		items = append(items, quoteJSON(p.Name)+": "+quoteJSON(p.Value))
+	}
+	return "{" + strings.Join(items, ", ") + "}"
+}
+
+func (c clientRequest) python() string {
+	var b strings.Builder
+	if c.Signature != nil {
+		b.WriteString("import hashlib\nimport hmac\nimport os\n\n")
+	}
+	b.WriteString("import requests\n\n")
+	fmt.Fprintf(&b, "url = %s\n", quoteJSON(c.URL))
+	fmt.Fprintf(&b, "params = %s\n", pythonDict(c.Query))
+	fmt.Fprintf(&b, "headers = %s\n", pythonDict(c.headers()))
+	fmt.Fprintf(&b, "body = %s.encode()\n", quoteJSON(c.Body))
+	if c.Signature != nil {
+		fmt.Fprintf(&b, "secret = os.environ[\"WEBHOOK_SECRET\"].encode()\n")
+		fmt.Fprintf(&b, "headers[%s] = \"%s=\" + hmac.new(secret, body, hashlib.%s).hexdigest()\n", quoteJSON(c.Signature.Header), c.Signature.Algorithm, c.Signature.Algorithm)
+	}
+	fmt.Fprintf(&b, "\nresponse = requests.request(%s, url, params=params, headers=headers, data=body)\n", quoteJSON(c.Method))
+	b.WriteString("print(response.status_code, response.text)")
+	return b.String()
+}
+
+func (c clientRequest) javascript() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "const url = new URL(%s);\n", quoteJSON(c.URL))
+	for _, p := range c.Query {
+		fmt.Fprintf(&b, "url.searchParams.set(%s, %s);\n", quoteJSON(p.Name), quoteJSON(p.Value))
+	}
+	var items []string
+	for _, p := range c.headers() {
+		items = append(items, quoteJSON(p.Name)+": "+quoteJSON(p.Value))
+	}
+	fmt.Fprintf(&b, "const headers = {%s};\n", strings.Join(items, ", "))
+	// fetch 不允许 GET 和 HEAD 请求带请求体，签名按实际发送的空请求体计算
+	withBody := c.Body != "" && bodyAllowed(c.Method)
+	if withBody {
+		fmt.Fprintf(&b, "const body = %s;\n", quoteJSON(c.Body))
+	} else if c.Signature != nil {
+		b.WriteString("const body = \"\";\n")
+	}
+	if c.Signature != nil {
+		hash := map[string]string{"sha1": "SHA-1", "sha256": "SHA-256", "sha512": "SHA-512"}[c.Signature.Algorithm]
+		b.WriteString("const encoder = new TextEncoder();\n")
+		fmt.Fprintf(&b, "const key = await crypto.subtle.importKey(\"raw\", encoder.encode(process.env.WEBHOOK_SECRET), { name: \"HMAC\", hash: %s }, false, [\"sign\"]);\n", quoteJSON(hash))
+		b.WriteString("const mac = new Uint8Array(await crypto.subtle.sign(\"HMAC\", key, encoder.encode(body)));\n")
+		fmt.Fprintf(&b, "headers[%s] = \"%s=\" + Array.from(mac, (x) => x.toString(16).padStart(2, \"0\")).join(\"\");\n", quoteJSON(c.Signature.Header), c.Signature.Algorithm)
+	}
+	options := fmt.Sprintf("method: %s, headers", quoteJSON(c.Method))
+	if withBody {
+		options += ", body"
+	}
+	fmt.Fprintf(&b, "\nconst response = await fetch(url, { %s });\n", options)
+	b.WriteString("console.log(response.status, await response.text());")
+	return b.String()
+}
@@ -0,0 +1,158 @@
+package pages
+
+import (
+	"encoding/json"
+	"net/url"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"webhook-ui/common/config"
+)
+
+// hostile 是含有 shell、JSON 和 JavaScript 特殊字符的 id、参数名和值
+var hostile = []string{
+	`a'b`,
+	`"; rm -rf / #`,
+	`$(touch /tmp/pwned)`,
+	"`id`",
+	`\" + alert(1) + \"`,
+	"line\nbreak",
+	`</script><script>alert(1)</script>`,
+	"中文 'quote'",
+}
+
+// runShell 用 sh 执行 script，返回标准输出。没有 sh 时跳过测试
+func runShell(t *testing.T, script string) string {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("没有 sh")
+	}
+	out, err := exec.Command("sh", "-c", script).Output()
+	if err != nil {
+		t.Fatalf("执行失败: %v\n%s", err, script)
+	}
+	return string(out)
+}
+
+func TestQuoteShell(t *testing.T) {
+	for _, s := range hostile {
+		if got := runShell(t, "printf '%s' "+quoteShell(s)); got != s {
+			t.Errorf("quoteShell(%q) 在 shell 中得到 %q", s, got)
+		}
+	}
+}
+
+func TestQuoteJSON(t *testing.T) {
+	for _, s := range hostile {
+		var got string
+		if err := json.Unmarshal([]byte(quoteJSON(s)), &got); err != nil || got != s {
+			t.Errorf("quoteJSON(%q) = %s，解析得到 %q (%v)", s, quoteJSON(s), got, err)
+		}
+	}
+}
+
+// hostileHook 返回 id、参数名和值都来自 name 的 hook：请求头和查询参数的 value 条件，以及传给命令的 payload 参数
+func hostileHook(name string) config.Hook {
+	header := config.Rules{Match: &config.MatchRule{Type: "value", Value: name, Parameter: config.Argument{Source: "header", Name: "X-" + name}}}
+	query := config.Rules{Match: &config.MatchRule{Type: "value", Value: name, Parameter: config.Argument{Source: "url", Name: name}}}
+	return config.Hook{
+		ID:                     name,
+		ExecuteCommand:         "/bin/true",
+		TriggerRule:            &config.Rules{And: &config.AndRule{header, query}},
+		PassArgumentsToCommand: []config.Argument{{Source: "payload", Name: name}},
+	}
+}
+
+// TestCurlHostileInput 用 shell 函数代替 curl 执行生成的命令，检查每个参数都原样到达，没有被 shell 解释
+func TestCurlHostileInput(t *testing.T) {
+	for _, name := range hostile {
+		c := newClientRequest(hostileHook(name), "https://example.com", "/hooks")
+		script := `curl() { for arg in "$@"; do printf '%s\0' "$arg"; done; }` + "\n" + c.curl()
+		args := strings.Split(strings.TrimSuffix(runShell(t, script), "\x00"), "\x00")
+		want := []string{
+			"-X", "POST", c.URL + "?" + encodeQuery(c.Query, false),
+			"-H", "Content-Type: application/json",
+			"-H", "X-" + name + ": " + name,
+			"--data-binary", c.Body,
+		}
+		if strings.Join(args, "\x00") != strings.Join(want, "\x00") {
+			t.Errorf("%q: curl 收到的参数为 %q，应为 %q", name, args, want)
+		}
+		var body map[string]string
+		if err := json.Unmarshal([]byte(c.Body), &body); err != nil || body[name] != "<"+name+">" {
+			t.Errorf("%q: 请求体 %s 不是合法的 JSON 或缺少参数 (%v)", name, c.Body, err)
+		}
+		escaped := strings.TrimPrefix(c.URL, "https://example.com/hooks/")
+		if id, err := url.PathUnescape(escaped); err != nil || id != name || strings.ContainsAny(escaped, "/ '\"\n") {
+			t.Errorf("%q: 地址 %s 中的 id 没有转义", name, c.URL)
+		}
+	}
+}
+
+// TestSnippetsQuoteStrings 检查 Python 和 JavaScript 示例中所有来自配置的文字都以 JSON 字符串写入
+func TestSnippetsQuoteStrings(t *testing.T) {
+	for _, name := range hostile {
+		c := newClientRequest(hostileHook(name), "https://example.com", "/hooks")
+		for lang, code := range map[string]string{"Python": c.python(), "JavaScript": c.javascript()} {
+			for _, s := range []string{c.URL, name, "X-" + name, c.Body} {
+				if !strings.Contains(code, quoteJSON(s)) {
+					t.Errorf("%q: %s 示例中没有 %s:\n%s", name, lang, quoteJSON(s), code)
+				}
+			}
+			if strings.Contains(name, "\n") && strings.Contains(code, name) {
+				t.Errorf("%q: %s 示例中有未转义的换行:\n%s", name, lang, code)
+			}
+		}
+	}
+}
+
+// signedHook 返回用 method 调用、要求 X-Hub-Signature-256 签名的 hook
+func signedHook(method string) config.Hook {
+	return config.Hook{
+		ID:             "deploy",
+		ExecuteCommand: "/bin/true",
+		HTTPMethods:    []string{method},
+		TriggerRule: &config.Rules{And: &config.AndRule{
+			{Match: &config.MatchRule{Type: "payload-hmac-sha256", Secret: "s3cret", Parameter: config.Argument{Source: "header", Name: "X-Hub-Signature-256"}}},
+			{Match: &config.MatchRule{Type: "value", Value: "refs/heads/main", Parameter: config.Argument{Source: "payload", Name: "ref"}}},
+		}},
+	}
+}
+
+func TestSnippetsSignature(t *testing.T) {
+	c := newClientRequest(signedHook("POST"), "", "/hooks")
+	if c.Signature == nil || c.Signature.Header != "X-Hub-Signature-256" || c.Signature.Algorithm != "sha256" || len(c.Notes) != 0 {
+		t.Fatalf("签名为 %+v，提示为 %v", c.Signature, c.Notes)
+	}
+	if c.Body != `{"ref":"refs/heads/main"}` || !c.Placeholder {
+		t.Errorf("请求体为 %s，占位地址 %v", c.Body, c.Placeholder)
+	}
+	for lang, code := range map[string]string{"curl": c.curl(), "Python": c.python(), "JavaScript": c.javascript()} {
+		if strings.Contains(code, "s3cret") {
+			t.Errorf("%s 示例中写入了密钥:\n%s", lang, code)
+		}
+		if !strings.Contains(code, "WEBHOOK_SECRET") || !strings.Contains(code, "X-Hub-Signature-256") {
+			t.Errorf("%s 示例没有计算签名:\n%s", lang, code)
+		}
+	}
+}
+
+// TestJavaScriptGetSignature 检查 GET 请求的 JavaScript 示例：fetch 不能发送请求体，签名仍然写入（按空请求体计算），并给出提示
+func TestJavaScriptGetSignature(t *testing.T) {
+	c := newClientRequest(signedHook("GET"), "", "/hooks")
+	code := c.javascript()
+	if !strings.Contains(code, `const body = "";`) || !strings.Contains(code, `headers["X-Hub-Signature-256"]`) {
+		t.Errorf("GET 请求的 JavaScript 示例没有签名:\n%s", code)
+	}
+	if strings.Contains(code, ", body }") {
+		t.Errorf("GET 请求的 JavaScript 示例发送了请求体:\n%s", code)
+	}
+	found := false
+	for _, note := range c.Notes {
+		found = found || strings.Contains(note.String(), "JavaScript")
+	}
+	if !found {
+		t.Errorf("没有提示 JavaScript 示例不发送请求体: %v", c.Notes)
+	}
+}
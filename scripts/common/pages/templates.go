@@ -0,0 +1,231 @@
+package pages
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"webhook-ui/common/assets"
+	"webhook-ui/common/i18n"
+)
+
+// 页面模板随程序嵌入：templates/layout.html 是所有页面共用的布局（导航、标题、提示消息和页脚），
+// templates/partials/ 下是布局引用的片段，templates/<页面>.html 定义各页面的 style、content 和 script
+//
+//go:embed templates
+var embedded embed.FS
+
+// OverrideDir 返回模板覆盖目录（环境变量 UI_OVERRIDE_DIR），为空时只使用嵌入的模板。
+// 目录中与嵌入模板同名的文件（如 partials/footer.html、layout.html、ui.html）会替换嵌入的版本，
+// partials/ 下新增的文件也会被加载，用于定制品牌而不修改 Go 代码
+func OverrideDir() string {
+	return os.Getenv("UI_OVERRIDE_DIR")
+}
+
+// NavLink 是导航栏中的一个链接
+type NavLink struct {
+	Label   string
+	URL     string
+	Current bool // 当前页面
+}
+
+// Flash 是显示在页面标题下方的提示消息，Kind 为 error 或 notice
+type Flash struct {
+	Kind    string
+	Message i18n.Message
+}
+
+// LangLink 是导航栏中的语言切换
+type LangLink struct {
+	Code    string
+	Name    string
+	Current bool
+}
+
+// Layout 是布局模板使用的数据，嵌入到各页面的模板数据中。文字都是原文，由模板用 T 翻译
+type Layout struct {
+	Page      string       // 页面名，对应 templates/<Page>.html，也用作 body 的 class
+	Lang      string       // 页面语言，见 i18n.Negotiate
+	Title     i18n.Message // <title>
+	Heading   i18n.Message // 页面标题 <h1>
+	Nav       []NavLink
+	Languages []LangLink
+	Flashes   []Flash
+	Text      map[string]string // 共用脚本中文字的译文，见 scriptText
+}
+
+// scriptText 是 assets/static/app.js 中用 t() 翻译的文字
+var scriptText = []string{
+	"停用 hook %s 的原因：",
+	"启用 hook %s 的原因（可选）：",
+	"操作人：",
+}
+
+// layout 生成页面 page 的布局数据。只有部分链接的脚本（如 save）按 URL 前缀和默认 hook 名补全导航链接
+func (s Site) layout(page string, title, heading i18n.Message) Layout {
+	link := func(url, hook string) string {
+		if url != "" {
+			return url
+		}
+		return s.URLPrefix + hook
+	}
+	p := s.printer()
+	l := Layout{
+		Page:    page,
+		Lang:    p.Lang(),
+		Title:   title,
+		Heading: heading,
+		Nav: []NavLink{
+			{Label: "Hook 列表", URL: link(s.HomeURL, "/ui"), Current: page == "ui" || page == "detail"},
+			{Label: "编辑配置", URL: link(s.EditURL, "/edit_form"), Current: page == "edit"},
+			{Label: "上传文件", URL: link(s.UploadURL, "/upload_form"), Current: page == "upload_form"},
+		},
+		Text: map[string]string{},
+	}
+	for _, code := range i18n.Languages {
+		l.Languages = append(l.Languages, LangLink{Code: code, Name: i18n.Names[code], Current: code == p.Lang()})
+	}
+	for _, text := range scriptText {
+		l.Text[text] = p.T(text)
+	}
+	return l
+}
+
+// printer 返回页面语言的 Printer
+func (s Site) printer() i18n.Printer {
+	return i18n.For(s.Lang)
+}
+
+// flash 添加一条提示消息，message 为空时忽略
+func (l *Layout) flash(kind string, message i18n.Message) {
+	if !message.IsZero() {
+		l.Flashes = append(l.Flashes, Flash{Kind: kind, Message: message})
+	}
+}
+
+// 没有覆盖目录时解析结果不会变化，按页面缓存；设置了覆盖目录时每次重新解析，修改模板后无需重启
+var (
+	templateMu    sync.Mutex
+	templateCache = map[string]*template.Template{}
+)
+
+// readTemplate 读取模板文件 name（相对 templates/ 的路径），覆盖目录中有同名文件时优先使用
+func readTemplate(name string) ([]byte, error) {
+	if dir := OverrideDir(); dir != "" {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+		if err == nil || !errors.Is(err, fs.ErrNotExist) {
+			return data, err
+		}
+	}
+	return embedded.ReadFile(path.Join("templates", name))
+}
+
+// partials 返回 partials/ 下的模板文件名：嵌入的片段加上覆盖目录中新增的片段
+func partials() ([]string, error) {
+	names := map[string]bool{}
+	entries, err := embedded.ReadDir("templates/partials")
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		names[entry.Name()] = true
+	}
+	if dir := OverrideDir(); dir != "" {
+		entries, err := os.ReadDir(filepath.Join(dir, "partials"))
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".html") {
+				names[entry.Name()] = true
+			}
+		}
+	}
+	var list []string
+	for name := range names {
+		list = append(list, "partials/"+name)
+	}
+	sort.Strings(list)
+	return list, nil
+}
+
+// translateFuncs 返回模板中的翻译函数：T 翻译固定的原文或 i18n.Message，
+// Tf 先翻译格式字符串再代入参数（如 {{ Tf "%d 个 hook" (len .Hooks) }}），参数中的用户数据不翻译
+func translateFuncs(p i18n.Printer) template.FuncMap {
+	return template.FuncMap{"T": p.Text, "Tf": p.Sprintf}
+}
+
+// parsePage 解析布局、片段和页面 page 的模板。页面模板最后解析，其中的 define 替换布局中的同名 block
+func parsePage(page string) (*template.Template, error) {
+	names, err := partials()
+	if err != nil {
+		return nil, err
+	}
+	names = append([]string{"layout.html"}, names...)
+	names = append(names, page+".html")
+	// T、Tf 在渲染时替换为页面语言的翻译，见 render
+	tmpl := template.New(page).Funcs(assets.FuncMap()).Funcs(translateFuncs(i18n.For(i18n.Default)))
+	for _, name := range names {
+		data, err := readTemplate(name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := tmpl.New(name).Parse(string(data)); err != nil {
+			return nil, err
+		}
+	}
+	return tmpl, nil
+}
+
+// pageTemplate 返回页面 page 的模板，没有覆盖目录时使用缓存
+func pageTemplate(page string) (*template.Template, error) {
+	if OverrideDir() != "" {
+		return parsePage(page)
+	}
+	templateMu.Lock()
+	defer templateMu.Unlock()
+	if tmpl, ok := templateCache[page]; ok {
+		return tmpl, nil
+	}
+	tmpl, err := parsePage(page)
+	if err != nil {
+		return nil, err
+	}
+	templateCache[page] = tmpl
+	return tmpl, nil
+}
+
+// render 用布局渲染页面 page，data 中需要嵌入 Layout。完整渲染成功后再输出，避免输出半个页面
+func (s Site) render(w io.Writer, page string, data any) error {
+	tmpl, err := pageTemplate(page)
+	if err == nil {
+		// 缓存的模板不直接执行，每次复制一份绑定页面语言的 T、Tf
+		tmpl, err = tmpl.Clone()
+	}
+	if err == nil {
+		tmpl = tmpl.Funcs(translateFuncs(s.printer()))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error parsing HTML template for %s: %v\n", page, err)
+		fmt.Fprint(w, "<h1>Error: Internal UI rendering issue.</h1>")
+		return err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+		fmt.Fprintf(os.Stderr, "Error executing template for %s: %v\n", page, err)
+		fmt.Fprint(w, "<h1>Error: Internal UI rendering issue.</h1>")
+		return err
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
@@ -0,0 +1,83 @@
+package pages
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"webhook-ui/common/config"
+)
+
+// renderUploadForm 渲染上传页（不需要 hooks 文件，上传目录为空），返回页面内容和错误
+func renderUploadForm(t *testing.T, lang string) (string, error) {
+	t.Helper()
+	site := Site{
+		Load:      func() ([]config.Source, error) { return nil, nil },
+		UploadDir: "/nonexistent/upload", // 目录不存在时列表为空，只在标准错误输出中记录
+		Lang:      lang,
+	}
+	var out bytes.Buffer
+	err := site.UploadForm(&out, "上传可执行文件")
+	return out.String(), err
+}
+
+// writeOverride 在覆盖目录中写入模板文件 name
+func writeOverride(t *testing.T, dir, name, content string) {
+	t.Helper()
+	path := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestOverrideDir 检查覆盖目录中的同名模板替换嵌入的版本、新增的片段被加载、修改后无需重启即生效，
+// 取消覆盖目录后恢复嵌入的模板
+func TestOverrideDir(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("UI_OVERRIDE_DIR", "")
+	embeddedPage, err := renderUploadForm(t, "zh-CN")
+	if err != nil || !strings.Contains(embeddedPage, "github.com/soulteary/webhook") {
+		t.Fatalf("嵌入的页脚: %v\n%s", err, embeddedPage)
+	}
+
+	t.Setenv("UI_OVERRIDE_DIR", dir)
+	// 没有同名文件时仍使用嵌入的模板
+	if page, err := renderUploadForm(t, "zh-CN"); err != nil || page != embeddedPage {
+		t.Errorf("空的覆盖目录改变了页面: %v", err)
+	}
+
+	writeOverride(t, dir, "partials/footer.html", `{{ define "footer" }}<footer>ACME {{ template "support" . }} {{ T "上传文件" }}</footer>{{ end }}`)
+	writeOverride(t, dir, "partials/support.html", `{{ define "support" }}ops@example.com{{ end }}`)
+	page, err := renderUploadForm(t, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(page, "<footer>ACME ops@example.com Upload files</footer>") || strings.Contains(page, "github.com/soulteary/webhook") {
+		t.Errorf("覆盖的页脚没有生效或没有翻译:\n%s", page)
+	}
+	if !strings.Contains(page, `<nav`) {
+		t.Error("覆盖页脚后缺少嵌入的导航")
+	}
+
+	// 修改覆盖的模板后下一次渲染即生效
+	writeOverride(t, dir, "partials/footer.html", `{{ define "footer" }}<footer>ACME v2</footer>{{ end }}`)
+	if page, _ := renderUploadForm(t, "en"); !strings.Contains(page, "<footer>ACME v2</footer>") {
+		t.Error("修改覆盖的模板后没有重新加载")
+	}
+
+	// 模板有语法错误时返回错误，不输出半个页面
+	writeOverride(t, dir, "partials/footer.html", `{{ define "footer" }}{{ if }}{{ end }}`)
+	if page, err := renderUploadForm(t, "en"); err == nil || strings.Contains(page, "<nav") {
+		t.Errorf("模板错误时 err = %v，页面为:\n%s", err, page)
+	}
+
+	t.Setenv("UI_OVERRIDE_DIR", "")
+	if page, err := renderUploadForm(t, "zh-CN"); err != nil || page != embeddedPage {
+		t.Errorf("取消覆盖目录后没有恢复嵌入的模板: %v", err)
+	}
+}
@@ -0,0 +1,96 @@
+package pages
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/i18n"
+)
+
+// UI 渲染主页：按文件分组展示满足 view 条件的 hook（或跨文件的表格），以及修改配置、上传文件和单个 hook 操作的入口
+func (s Site) UI(w io.Writer, title string, view Overview) error {
+	// 1. 读取 webhook 配置文件。HOOKS 可以是逗号分隔的多个文件或通配符，单个文件出错只在对应分组中提示
+	sources, err := s.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving hooks files: %v\n", err)
+		fmt.Fprint(w, "<h1>Error: Could not load Webhook configuration.</h1><p>Please check server logs.</p>")
+		return err
+	}
+	for _, source := range sources {
+		if source.IsNotExist() {
+			fmt.Fprintf(os.Stderr, "Config file %s not found, starting with empty config.\n", source.Path)
+		} else if source.Err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config file %s: %v\n", source.Path, source.Err)
+		}
+		if source.DisabledErr != nil {
+			fmt.Fprintf(os.Stderr, "Error loading disabled hooks of %s: %v\n", source.Path, source.DisabledErr)
+		}
+	}
+
+	// 2. 检查跨文件重复的 hook id，webhook 遇到重复 id 会拒绝加载
+	var duplicates []string
+	for id, paths := range config.DuplicateIDs(sources) {
+		duplicates = append(duplicates, fmt.Sprintf("%s (%s)", id, strings.Join(paths, ", ")))
+	}
+	sort.Strings(duplicates)
+
+	// 定义要传递给模板的数据结构
+	type TemplateData struct {
+		Layout
+		Sources   []overviewSource
+		Rows      []overviewRow // 表格视图
+		Overview  Overview
+		Files     []string // 筛选用的文件列表，只有一个文件时为空
+		Methods   []string // 筛选用的 HTTP 方法
+		Shown     int      // 满足条件的 hook 数
+		Total     int
+		Template  bool // hooks 文件是 Go 模板（webhook -template），展示模板原文和渲染结果
+		EditUrl   string
+		UploadUrl string
+		HookUrl   string
+		DetailUrl string
+		URLPrefix string // 确保 URLPrefix 被传递
+	}
+
+	// 3. 按查询参数中的视图筛选和排序
+	groups, rows := view.apply(sources)
+	var files []string
+	total := 0
+	for _, source := range sources {
+		files = append(files, source.Path)
+		total += len(source.Hooks)
+		if source.Disabled != nil {
+			total += len(source.Disabled.Hooks)
+		}
+	}
+	if len(files) < 2 {
+		files = nil
+	}
+
+	templateData := TemplateData{
+		Layout:    s.layout("ui", i18n.M(title), i18n.M("%s 概览", i18n.M(title))),
+		Sources:   groups,
+		Rows:      rows,
+		Overview:  view,
+		Files:     files,
+		Methods:   allMethods(sources),
+		Shown:     len(rows),
+		Total:     total,
+		Template:  config.TemplateEnabled(),
+		EditUrl:   s.EditURL,
+		UploadUrl: s.UploadURL,
+		HookUrl:   s.HookURL,
+		DetailUrl: s.DetailURL,
+		URLPrefix: s.URLPrefix, // 将 prefix 传递给模板
+	}
+	if len(duplicates) > 0 {
+		templateData.flash("error", i18n.M("⚠ 以下 hook id 重复，webhook 将拒绝加载：%s", strings.Join(duplicates, "；")))
+	}
+
+	// 4. 用布局渲染 templates/ui.html
+	return s.render(w, "ui", templateData)
+}
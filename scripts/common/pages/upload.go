@@ -0,0 +1,84 @@
+package pages
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"webhook-ui/common/i18n"
+	"webhook-ui/common/upload"
+)
+
+// uploadResult 是单个文件的上传结果
+type uploadResult struct {
+	FileName string
+	Success  bool
+	Title    i18n.Message
+	Message  i18n.Message
+}
+
+// uploadJSON 是 JSON 格式响应中单个文件的上传结果，供上传页面逐个文件展示。标题和说明已按页面语言翻译
+type uploadJSON struct {
+	FileName string `json:"file_name"`
+	Success  bool   `json:"success"`
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+}
+
+// json 返回按 p 的语言翻译后的 JSON 结果
+func (r uploadResult) json(p i18n.Printer) uploadJSON {
+	return uploadJSON{FileName: r.FileName, Success: r.Success, Title: p.Message(r.Title), Message: p.Message(r.Message)}
+}
+
+// renderResult 按 format 输出结果：json 时输出 uploadJSON，否则输出 HTML 页面
+func (s Site) renderResult(w io.Writer, format string, result uploadResult) {
+	if format == "json" {
+		if err := json.NewEncoder(w).Encode(result.json(s.printer())); err != nil {
+			fmt.Fprintf(os.Stderr, "Error encoding JSON response: %v\n", err)
+		}
+		return
+	}
+	s.renderResponse(w, response{Title: result.Title, Message: result.Message})
+}
+
+// Upload 保存 base64 上传（upload-submit）的文件：srcPath 是 webhook 解码后写入的临时文件，name 是原始文件名。
+// format 为 json 时返回 JSON 结果，否则返回 HTML 页面
+func (s Site) Upload(w io.Writer, format, srcPath, name string, extract bool) bool {
+	respond := func(success bool, title, message i18n.Message) bool {
+		s.renderResult(w, format, uploadResult{
+			FileName: name,
+			Success:  success,
+			Title:    title,
+			Message:  message,
+		})
+		return success
+	}
+
+	// 检查必要参数
+	if srcPath == "" {
+		return respond(false, i18n.M("上传失败"), i18n.M("脚本：未接收到上传文件路径 (UPLOADED_FILE_PATH 环境变量未设置)。"))
+	}
+	if name == "" {
+		name = filepath.Base(srcPath)
+		fmt.Fprintf(os.Stderr, "Warning: Original filename not provided, using '%s' from temporary path.\n", name)
+	}
+
+	return respond(storeUpload(srcPath, name, s.UploadDir, extract))
+}
+
+// storeUpload 把 srcPath 处的临时文件放到 uploadDestDir：压缩包解压到子目录，其他文件移动并赋予可执行权限。
+// 返回是否成功以及用于展示的标题和消息
+func storeUpload(srcPath, originalFilename, uploadDestDir string, extract bool) (bool, i18n.Message, i18n.Message) {
+	stored, err := upload.Store(srcPath, originalFilename, uploadDestDir, extract)
+	switch {
+	case err != nil:
+		return false, i18n.M("上传失败"), i18n.M("脚本：%v", err)
+	case stored.Extracted:
+		return true, i18n.M("上传成功"), i18n.M("压缩包 '%s' 已解压到 %s，共 %d 个文件", stored.Name, stored.Path, stored.Files)
+	case !stored.Warning.IsZero():
+		return true, i18n.M("上传成功 (有警告)"), i18n.M("文件 '%s' 已成功上传到 %s，但%s", stored.Name, uploadDestDir, stored.Warning) // 即使有警告，也视为成功上传
+	}
+	return true, i18n.M("上传成功"), i18n.M("文件 '%s' 已成功上传到 %s", stored.Name, uploadDestDir)
+}
@@ -0,0 +1,78 @@
+package pages
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/i18n"
+	"webhook-ui/common/upload"
+)
+
+// BrokenHook 是 execute-command 位于上传目录下、但命令文件缺失或不可执行的 hook
+type BrokenHook struct {
+	ID      string
+	Command string
+	Status  config.CommandStatus
+}
+
+// UploadForm 渲染上传页：上传文件，以及上传目录中已有的文件及其关联的 hook
+func (s Site) UploadForm(w io.Writer, title string) error {
+	uploadDestDir := s.UploadDir
+
+	// 读取 hooks 配置，用于展示文件被哪些 hook 使用。读取失败只影响这部分信息
+	sources, err := s.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving hooks files: %v\n", err)
+	}
+	for _, source := range sources {
+		if source.Err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading hooks config %s: %v\n", source.Path, source.Err)
+		}
+	}
+	hooks := config.Merge(sources)
+
+	// 上传目录中已有的文件，忽略上传和解压时的临时文件
+	dirContents, err := upload.List(uploadDestDir, hooks)
+	if err != nil {
+		// 如果目录不存在或无法读取，记录错误但不阻止页面加载
+		fmt.Fprintf(os.Stderr, "Error reading upload destination directory '%s': %v\n", uploadDestDir, err)
+	}
+
+	// 命令位于上传目录下、但文件缺失或不可执行的 hook
+	var brokenHooks []BrokenHook
+	for _, h := range hooks.HooksReferencing(uploadDestDir) {
+		if status := h.CheckCommand(); status != config.CommandOK {
+			brokenHooks = append(brokenHooks, BrokenHook{ID: h.ID, Command: h.ExecuteCommand, Status: status})
+		}
+	}
+
+	// 定义要传递给模板的数据结构
+	type TemplateData struct {
+		Layout
+		HomeURL         string
+		UploadChunkURL  string
+		UploadRawURL    string
+		URLPrefix       string
+		DestDirContents []upload.File // 新增：目录内容列表
+		DestDirPath     string        // 新增：目标目录路径
+		EditURL         string
+		BrokenHooks     []BrokenHook
+	}
+
+	templateData := TemplateData{
+		Layout:          s.layout("upload_form", i18n.M(title), i18n.M(title)),
+		HomeURL:         s.HomeURL,
+		UploadChunkURL:  s.UploadChunkURL,
+		UploadRawURL:    s.UploadRawURL,
+		URLPrefix:       s.URLPrefix,
+		DestDirContents: dirContents,   // 传递目录内容
+		DestDirPath:     uploadDestDir, // 传递目录路径，以便在页面显示
+		EditURL:         s.EditURL,
+		BrokenHooks:     brokenHooks,
+	}
+
+	// 用布局渲染 templates/upload_form.html
+	return s.render(w, "upload_form", templateData)
+}
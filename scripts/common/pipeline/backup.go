@@ -0,0 +1,97 @@
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"webhook-ui/common/env"
+	"webhook-ui/common/i18n"
+)
+
+// versionLayout 是备份版本号的格式，按字符串排序即按时间排序
+const versionLayout = "20060102-150405.000000"
+
+// Version 是 hooks 文件的一个历史版本
+type Version struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	Size int64     `json:"size"`
+}
+
+// BackupDir 返回 hooks 文件的备份目录：环境变量 BACKUP_DIR，默认为 hooks 文件所在目录下的 .hooks-backup
+func BackupDir(path string) string {
+	return env.Str("BACKUP_DIR", filepath.Join(filepath.Dir(path), ".hooks-backup"))
+}
+
+func backupPath(path, id string) string {
+	return filepath.Join(BackupDir(path), filepath.Base(path)+"."+id)
+}
+
+// Backup 把 data 保存为 path 的一个历史版本并返回版本号，
+// 只保留最近 BACKUP_KEEP 个版本（默认 50，0 表示不清理）
+func Backup(path string, data []byte) (string, error) {
+	if err := os.MkdirAll(BackupDir(path), 0o755); err != nil {
+		return "", err
+	}
+	id := time.Now().Format(versionLayout)
+	if err := os.WriteFile(backupPath(path, id), data, 0o644); err != nil {
+		return "", err
+	}
+	keep, err := strconv.Atoi(env.Str("BACKUP_KEEP", "50"))
+	if err != nil || keep <= 0 {
+		return id, nil
+	}
+	versions, err := Versions(path)
+	if err != nil {
+		return id, nil
+	}
+	for _, v := range versions[min(keep, len(versions)):] {
+		if err := os.Remove(backupPath(path, v.ID)); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not remove old backup %s: %v\n", v.ID, err)
+		}
+	}
+	return id, nil
+}
+
+// Versions 返回 path 的所有历史版本，最新的在前
+func Versions(path string) ([]Version, error) {
+	entries, err := os.ReadDir(BackupDir(path))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	prefix := filepath.Base(path) + "."
+	var versions []Version
+	for _, entry := range entries {
+		id := strings.TrimPrefix(entry.Name(), prefix)
+		if id == entry.Name() || entry.IsDir() {
+			continue
+		}
+		t, err := time.ParseInLocation(versionLayout, id, time.Local)
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		versions = append(versions, Version{ID: id, Time: t, Size: info.Size()})
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i].ID > versions[j].ID })
+	return versions, nil
+}
+
+// ReadVersion 读取 path 的一个历史版本
+func ReadVersion(path, id string) ([]byte, error) {
+	if _, err := time.Parse(versionLayout, id); err != nil {
+		return nil, i18n.Errorf("无效的版本号 %q", id)
+	}
+	return os.ReadFile(backupPath(path, id))
+}
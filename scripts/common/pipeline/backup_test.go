@@ -0,0 +1,78 @@
+package pipeline
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestBackupVersions(t *testing.T) {
+	work := t.TempDir()
+	path := filepath.Join(work, "hooks.yaml")
+	t.Setenv("BACKUP_DIR", filepath.Join(work, "backup"))
+	t.Setenv("BACKUP_KEEP", "2")
+
+	if versions, err := Versions(path); err != nil || len(versions) != 0 {
+		t.Fatalf("备份目录不存在时 Versions = %v, %v", versions, err)
+	}
+	var ids []string
+	for _, content := range []string{"v1", "v2", "v3"} {
+		id, err := Backup(path, []byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+		time.Sleep(time.Millisecond) // 版本号精确到微秒，避免连续备份得到相同的版本号
+	}
+	// 其他文件的备份和无法识别的文件不算作版本
+	os.WriteFile(filepath.Join(work, "backup", "other.yaml."+ids[0]), []byte("other"), 0o644)
+	os.WriteFile(filepath.Join(work, "backup", "hooks.yaml.tmp"), []byte("tmp"), 0o644)
+
+	versions, err := Versions(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 只保留最近 BACKUP_KEEP 个版本，最新的在前
+	if len(versions) != 2 || versions[0].ID != ids[2] || versions[1].ID != ids[1] {
+		t.Fatalf("Versions = %+v，期望 %s, %s", versions, ids[2], ids[1])
+	}
+	if versions[0].Size != 2 {
+		t.Errorf("版本大小为 %d，应为 2", versions[0].Size)
+	}
+
+	data, err := ReadVersion(path, ids[1])
+	if err != nil || string(data) != "v2" {
+		t.Errorf("ReadVersion = %q, %v", data, err)
+	}
+	if _, err := ReadVersion(path, ids[0]); err == nil {
+		t.Error("读取已清理的版本没有报错")
+	}
+	for _, id := range []string{"", "../hooks.yaml", "latest"} {
+		if _, err := ReadVersion(path, id); err == nil {
+			t.Errorf("ReadVersion(%q) 没有拒绝无效的版本号", id)
+		}
+	}
+}
+
+func TestBackupKeepAll(t *testing.T) {
+	work := t.TempDir()
+	path := filepath.Join(work, "hooks.yaml")
+	t.Setenv("BACKUP_DIR", "") // 测试结束后恢复原值
+	os.Unsetenv("BACKUP_DIR")
+	t.Setenv("BACKUP_KEEP", "0")
+
+	for i := 0; i < 3; i++ {
+		if _, err := Backup(path, []byte("v")); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond)
+	}
+	// 未设置 BACKUP_DIR 时使用 hooks 文件旁边的 .hooks-backup，BACKUP_KEEP=0 不清理
+	if dir := BackupDir(path); dir != filepath.Join(work, ".hooks-backup") {
+		t.Errorf("BackupDir = %s", dir)
+	}
+	if versions, _ := Versions(path); len(versions) != 3 {
+		t.Errorf("BACKUP_KEEP=0 时保留了 %d 个版本，应为 3", len(versions))
+	}
+}
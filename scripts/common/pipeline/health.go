@@ -0,0 +1,141 @@
+package pipeline
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/env"
+	"webhook-ui/common/i18n"
+	"webhook-ui/common/reload"
+)
+
+// healthCheck 在保存后触发重载、探测每个 hook，必要时恢复保存前的配置
+type healthCheck struct {
+	configFilePath  string
+	sources         []config.Source // 保存前的所有 hooks 文件，webhook 加载的是它们合并后的配置
+	prober          reload.Prober
+	reachableBefore bool // 保存前 webhook 服务是否可以访问
+	oldConfig       config.Config
+	oldData         []byte // 保存前被编辑文件的内容，恢复时只写回这一个文件
+	hadOld          bool   // 保存前配置文件是否存在
+}
+
+// hookStateLabels 是健康检查中各状态的展示文字
+var hookStateLabels = map[reload.HookState]string{
+	reload.StateServed:  "✔ 已加载",
+	reload.StateMissing: "✘ 未加载",
+	reload.StateStale:   "✘ 已删除但仍在提供",
+	reload.StateRemoved: "✔ 已删除",
+	reload.StateSkipped: "- 未探测 (未限制 http-methods)",
+}
+
+// Section 是结果说明中的一段：一段文字，以及健康检查中每个 hook 的状态。
+// 内容都是纯文本，结果页用 html/template 转义后输出
+type Section struct {
+	Text  i18n.Message // 可以有多行
+	Error bool         // 以错误样式显示
+	Hooks []HookStatus // 健康检查结果，没有探测时为空
+}
+
+// HookStatus 是健康检查中一个 hook 的状态
+type HookStatus struct {
+	ID    string
+	Label string // 展示文字，如 "✔ 已加载"
+	Class string // success、error 或 skipped
+}
+
+// text 把说明转换为纯文本，每个 hook 一行，用于 API 和命令行
+func text(sections []Section) string {
+	var lines []string
+	for _, section := range sections {
+		if !section.Text.IsZero() {
+			lines = append(lines, section.Text.String())
+		}
+		for _, h := range section.Hooks {
+			lines = append(lines, h.ID+" "+h.Label)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// reportSection 把健康检查结果转换为结果说明中的一段
+func reportSection(report reload.Report) Section {
+	section := Section{Text: report.Summary()}
+	for _, h := range report.Hooks {
+		class := "success"
+		if h.State == reload.StateMissing || h.State == reload.StateStale {
+			class = "error"
+		} else if h.State == reload.StateSkipped {
+			class = "skipped"
+		}
+		section.Hooks = append(section.Hooks, HookStatus{ID: h.ID, Label: hookStateLabels[h.State], Class: class})
+	}
+	return section
+}
+
+// failed 把所有段落标记为错误样式
+func failed(sections []Section) []Section {
+	for i := range sections {
+		sections[i].Error = true
+	}
+	return sections
+}
+
+func (c healthCheck) timeout() time.Duration {
+	timeout, err := time.ParseDuration(env.Str("RELOAD_CONFIRM_TIMEOUT", "5s"))
+	if err != nil {
+		return 5 * time.Second
+	}
+	return timeout
+}
+
+// run 检查渲染后的新配置 rendered，返回结果页的标题、说明，以及新配置是否最终保留
+func (c healthCheck) run(rendered []byte) (i18n.Message, []Section, bool) {
+	options := reload.OptionsFromEnv(c.configFilePath)
+	result := reload.Trigger(options)
+	sections := []Section{{Text: result.Message}}
+
+	newConfig, err := config.Parse(rendered)
+	if err != nil {
+		return i18n.M("保存成功"), append(sections, Section{Text: i18n.M("新配置不是 hook 列表，无法检查 hook 是否已加载: %v", err)}), true
+	}
+	newConfig = config.Merge(config.ReplaceSource(c.sources, c.configFilePath, newConfig))
+
+	if result.Method == reload.MethodNone || !result.Triggered {
+		title := i18n.M("保存成功")
+		if result.Method != reload.MethodNone {
+			title = i18n.M("保存成功 (重载失败)")
+			sections[0].Error = true
+		}
+		// 没有触发重载时只探测一次，展示运行中的服务目前提供了哪些 hook
+		if c.reachableBefore {
+			sections = append(sections, reportSection(c.prober.Check(c.oldConfig, newConfig, 0)))
+		}
+		return title, sections, true
+	}
+
+	report := c.prober.Check(c.oldConfig, newConfig, c.timeout())
+	sections = append(sections, reportSection(report))
+	if report.Healthy() {
+		return i18n.M("保存成功"), sections, true
+	}
+
+	// 服务没有接受新配置（或重载后无法访问）：恢复保存前的配置并再次重载
+	rollback, _ := strconv.ParseBool(env.Str("RELOAD_AUTO_ROLLBACK", "true"))
+	if !rollback || !c.hadOld || (report.Err != nil && !c.reachableBefore) {
+		return i18n.M("保存成功 (新配置未生效)"), failed(sections), true
+	}
+	if err := writeAtomic(c.configFilePath, c.oldData); err != nil {
+		sections = append(sections, Section{Text: i18n.M("webhook 服务未能加载新配置，且无法恢复保存前的配置: %v", err)})
+		return i18n.M("保存失败 (恢复失败)"), failed(sections), false
+	}
+	sections = append([]Section{{Text: i18n.M("webhook 服务未能加载新配置，已自动恢复保存前的配置。"), Error: true}}, sections...)
+	again := reload.Trigger(options)
+	sections = append(sections, Section{Text: i18n.M("恢复后重新加载: %v", again.Message)})
+	if again.Triggered {
+		sections = append(sections, reportSection(c.prober.Check(newConfig, c.oldConfig, c.timeout())))
+	}
+	return i18n.M("保存失败 (已恢复)"), sections, false
+}
@@ -0,0 +1,13 @@
+package pipeline
+
+import (
+	"path/filepath"
+
+	"webhook-ui/common/filelock"
+)
+
+// lock 对 hooks 文件加排他锁，直到返回的函数被调用。锁文件与 hooks 文件在同一目录，
+// 以 . 开头，展开 HOOKS 中的通配符时会被忽略
+func lock(path string) (func(), error) {
+	return filelock.Lock(filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".lock"))
+}
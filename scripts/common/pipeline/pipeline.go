@@ -0,0 +1,272 @@
+// Package pipeline 是写入 hooks 文件的公共流程：校验新内容、备份旧内容、加锁后原子写入，
+// 然后通知 webhook 重新加载并确认新配置已生效，未生效时自动恢复。save 和单个 hook 的增删改都通过它写入
+package pipeline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"webhook-ui/common/adminhooks"
+	"webhook-ui/common/config"
+	"webhook-ui/common/i18n"
+	"webhook-ui/common/reload"
+)
+
+// Target 返回要写入的 hooks 文件：file 为空时是第一个文件，否则必须是 HOOKS 中列出的文件，避免通过参数覆盖任意文件
+func Target(sources []config.Source, file string) (string, error) {
+	if file == "" {
+		return sources[0].Path, nil
+	}
+	if !config.Contains(sources, file) {
+		return "", i18n.Errorf("%s 不是 HOOKS 中配置的 hooks 文件", file)
+	}
+	return filepath.Clean(file), nil
+}
+
+// ValidationError 表示新内容没有通过检查，webhook 无法加载
+type ValidationError struct {
+	Title    i18n.Message   // 错误类型，如 "YAML 语法错误"
+	Detail   string         // 解析错误、重复的 id 等，原样显示
+	Problems []i18n.Message // Admin 为 true 时受影响的管理 hook，每项一行，与 Detail 内容相同
+	Admin    bool           // 由 CheckAdmin 发现，可以通过 Options.AllowAdminChanges 跳过
+
+	Recovery string // Admin 为 true 时管理 hook 的恢复文件，文件不存在时为空
+}
+
+func (e *ValidationError) Error() string {
+	return e.Title.String() + ": " + e.Detail
+}
+
+// Validate 检查 path 的新内容：模板模式下先渲染模板，再按扩展名和内容检查 YAML/JSON 语法，
+// 最后检查 hook id 是否与本文件、其他 hooks 文件或停用的 hook 重复。返回渲染后的内容
+func Validate(sources []config.Source, path string, content []byte) ([]byte, *ValidationError) {
+	rendered, err := config.Expand(content)
+	if err != nil {
+		return nil, &ValidationError{Title: i18n.M("模板错误"), Detail: err.Error()}
+	}
+	format := config.DetectFormat(path, rendered)
+	if err := config.Validate(rendered, format); err != nil {
+		return nil, &ValidationError{Title: i18n.M("%s 语法错误", strings.ToUpper(string(format))), Detail: err.Error()}
+	}
+	if hooks, err := config.ParseFormat(rendered, format); err == nil {
+		if duplicates := config.DuplicateIDs(config.ReplaceSource(sources, path, hooks)); len(duplicates) > 0 {
+			var lines []string
+			for id, paths := range duplicates {
+				lines = append(lines, fmt.Sprintf("%s: %s", id, strings.Join(paths, ", ")))
+			}
+			sort.Strings(lines)
+			return nil, &ValidationError{Title: i18n.M("hook id 重复，webhook 将拒绝加载"), Detail: strings.Join(lines, "\n")}
+		}
+		// 停用的 hook 启用时会放回原文件，不允许新 hook 占用它的 id
+		disabled := config.DisabledIDs(sources)
+		var lines []string
+		for _, h := range hooks {
+			if file, ok := disabled[h.ID]; ok {
+				lines = append(lines, fmt.Sprintf("%s: %s", h.ID, file))
+			}
+		}
+		if len(lines) > 0 {
+			return nil, &ValidationError{Title: i18n.M("hook id 与已停用的 hook 重复"), Detail: strings.Join(lines, "\n")}
+		}
+	}
+	return rendered, nil
+}
+
+// CheckAdmin 检查把 path 的内容改为 rendered（Validate 的返回值）后，管理页面自身使用的 hook
+// （包括 execute-command 位于脚本安装目录下的 hook）是否被删除、停用或改动，避免保存后无法再打开管理页面。
+// rendered 无法解析时不检查（Validate 已报告）
+func CheckAdmin(sources []config.Source, path string, rendered []byte) *ValidationError {
+	hooks, err := config.ParseFormat(rendered, config.DetectFormat(path, rendered))
+	if err != nil {
+		return nil
+	}
+	problems := adminhooks.Check(config.Merge(sources), config.Merge(config.ReplaceSource(sources, path, hooks)), adminhooks.ScriptsDir())
+	if len(problems) == 0 {
+		return nil
+	}
+	lines := make([]string, len(problems))
+	for i, problem := range problems {
+		lines[i] = problem.String()
+	}
+	verr := &ValidationError{
+		Title:    i18n.M("修改涉及管理页面自身使用的 hook，保存后可能无法再打开管理页面"),
+		Detail:   strings.Join(lines, "\n"),
+		Problems: problems,
+		Admin:    true,
+	}
+	if recovery := adminhooks.RecoveryPath(sources[0].Path); recovery != "" {
+		if _, err := os.Stat(recovery); err == nil {
+			verr.Recovery = recovery
+		}
+	}
+	return verr
+}
+
+// writeRecovery 在保存前的配置能打开管理页面时，把其中的管理 hook 写入恢复文件，
+// 之后管理 hook 被误删或改坏时可以不经过管理页面恢复。保存前的配置已经缺少管理 hook 时保留原有的恢复文件
+func writeRecovery(sources []config.Source) error {
+	before := config.Merge(sources)
+	path := adminhooks.RecoveryPath(sources[0].Path)
+	if path == "" || !adminhooks.Reachable(before) {
+		return nil
+	}
+	if err := adminhooks.WriteRecovery(path, before, adminhooks.ScriptsDir()); err != nil {
+		return i18n.Errorf("无法写入管理 hook 的恢复文件 %s: %v", path, err)
+	}
+	return nil
+}
+
+// Result 是一次写入的结果
+type Result struct {
+	Title    i18n.Message
+	Message  string    // 纯文本说明，包括重载和健康检查结果
+	Sections []Section // 与 Message 相同的说明，按段落和 hook 状态组织，供结果页渲染
+	OK       bool      // 新配置最终是否保留
+	Backup   string    // 写入前内容的备份版本，文件原本不存在时为空
+}
+
+// Options 是写入时的可选设置
+type Options struct {
+	// Done 在新配置生效后、释放文件锁之前调用，用于同步写入与 hooks 文件配套的旁路文件（如停用的 hook）。
+	// 新配置被恢复时不调用
+	Done func() error
+	// AllowAdminChanges 为 true 时允许 CheckAdmin 发现的修改，即删除或修改管理页面自身使用的 hook，
+	// 此时保存前的管理 hook 必须已写入恢复文件
+	AllowAdminChanges bool
+}
+
+// CommitOptions 在 path 的文件锁内读取当前内容，交给 modify 生成新内容，校验、备份并原子写入，然后重载并确认生效。
+// modify 基于写入时的最新内容修改，因此多人同时修改不同的 hook 不会互相覆盖。
+// 校验失败时返回 *ValidationError，modify 的错误原样返回。可选设置见 Options
+func CommitOptions(sources []config.Source, path string, modify func(current []byte) ([]byte, error), opts Options) (Result, error) {
+	prober := reload.ProberFromEnv()
+	reachableBefore := prober.Reachable()
+
+	unlock, err := lock(path)
+	if err != nil {
+		return Result{}, i18n.Errorf("无法锁定 %s: %v", path, err)
+	}
+	defer unlock()
+
+	// 记录写入前的配置：用于健康检查时比较新增/删除的 hook，以及新配置加载失败时自动恢复
+	oldData, err := os.ReadFile(path)
+	hadOld := err == nil
+	if err != nil && !os.IsNotExist(err) {
+		return Result{}, i18n.Errorf("无法读取 %s: %v", path, err)
+	}
+	content, err := modify(oldData)
+	if err != nil {
+		return Result{}, err
+	}
+	rendered, verr := Validate(sources, path, content)
+	if verr != nil {
+		return Result{}, verr
+	}
+	for _, source := range sources {
+		if source.Err != nil && !source.IsNotExist() {
+			fmt.Fprintf(os.Stderr, "Warning: Could not load current config %s: %v\n", source.Path, source.Err)
+		}
+	}
+	// 其他人可能在加锁前修改了这个文件，以刚读到的内容为准
+	if oldHooks, err := config.Parse(mustExpand(oldData)); err == nil {
+		sources = config.ReplaceSource(sources, path, oldHooks)
+	}
+	recoveryErr := writeRecovery(sources)
+	if verr := CheckAdmin(sources, path, rendered); verr != nil {
+		if !opts.AllowAdminChanges {
+			return Result{}, verr
+		}
+		// 确认修改管理 hook 时必须先留好恢复文件
+		if recoveryErr != nil {
+			return Result{}, recoveryErr
+		}
+	} else if recoveryErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", recoveryErr)
+	}
+
+	var result Result
+	if hadOld {
+		if result.Backup, err = Backup(path, oldData); err != nil {
+			return Result{}, i18n.Errorf("无法备份 %s: %v", path, err)
+		}
+	}
+	if err := writeAtomic(path, content); err != nil {
+		return Result{}, err
+	}
+
+	check := healthCheck{
+		configFilePath:  path,
+		sources:         sources,
+		prober:          prober,
+		reachableBefore: reachableBefore,
+		oldConfig:       config.Merge(sources),
+		oldData:         oldData,
+		hadOld:          hadOld,
+	}
+	result.Title, result.Sections, result.OK = check.run(rendered)
+	result.Message = text(result.Sections)
+	if result.OK && opts.Done != nil {
+		if err := opts.Done(); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func mustExpand(data []byte) []byte {
+	expanded, err := config.Expand(data)
+	if err != nil {
+		return nil
+	}
+	return expanded
+}
+
+// WriteDisabled 原子写入 hooks 文件的旁路文件，应在 CommitOptions 的 Options.Done 中调用以保持文件锁
+func WriteDisabled(path string, state *config.DisabledState) error {
+	data, err := state.Bytes()
+	if err != nil {
+		return err
+	}
+	return writeAtomic(config.DisabledPath(path), data)
+}
+
+// writeAtomic 先写入同目录下的临时文件，再原子性替换配置文件
+func writeAtomic(configFilePath string, data []byte) error {
+	dir := filepath.Dir(configFilePath)
+	tmpFile, err := os.CreateTemp(dir, "hooks-temp-*"+filepath.Ext(configFilePath))
+	if err != nil {
+		return i18n.Errorf("无法创建临时文件: %v", err)
+	}
+	defer tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	if _, err := tmpFile.Write(data); err != nil {
+		return i18n.Errorf("无法写入临时文件: %v", err)
+	}
+
+	// 确保所有数据都已写入磁盘
+	if err := tmpFile.Sync(); err != nil {
+		return i18n.Errorf("无法同步临时文件到磁盘: %v", err)
+	}
+
+	// 关闭临时文件，否则在 Windows 上 os.Rename 可能会失败
+	tmpFile.Close()
+
+	// os.CreateTemp 创建的文件权限为 0600，沿用原文件的权限
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(configFilePath); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.Chmod(tmpFile.Name(), mode); err != nil {
+		return i18n.Errorf("无法设置临时文件权限: %v", err)
+	}
+
+	// 原子性替换原文件
+	if err := os.Rename(tmpFile.Name(), configFilePath); err != nil {
+		return i18n.Errorf("无法替换配置文件: %v，请检查文件权限", err)
+	}
+	return nil
+}
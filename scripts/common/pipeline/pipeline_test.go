@@ -0,0 +1,232 @@
+package pipeline
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"webhook-ui/common/adminhooks"
+	"webhook-ui/common/config"
+	"webhook-ui/common/reload"
+)
+
+const (
+	oldHooks = "- id: a\n  execute-command: /bin/true\n  http-methods: [POST]\n"
+	newHooks = "- id: b\n  execute-command: /bin/true\n  http-methods: [POST]\n"
+)
+
+// fakeWebhook 模拟运行中的 webhook 服务：对 served 中的 hook 的探测请求返回 405，其余返回 404。
+// reloads 为 false 时服务不接受新配置，始终提供 a
+func fakeWebhook(t *testing.T, path string, reloads bool) *httptest.Server {
+	served := func() config.Config {
+		if !reloads {
+			return config.Config{{ID: "a"}}
+		}
+		data, _ := os.ReadFile(path)
+		hooks, _ := config.Parse(data)
+		return hooks
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == reload.ProbeMethod && served().Find(strings.TrimPrefix(r.URL.Path, "/hooks/")) != nil {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+// setupCommit 在临时目录中写入旧配置，并设置重载、探测和备份相关的环境变量
+func setupCommit(t *testing.T, reloads bool) string {
+	work := t.TempDir()
+	path := filepath.Join(work, "hooks.yaml")
+	if err := os.WriteFile(path, []byte(oldHooks), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("WEBHOOK_URL", fakeWebhook(t, path, reloads).URL+"/hooks")
+	t.Setenv("RELOAD_METHOD", "command")
+	t.Setenv("RELOAD_COMMAND", "true")
+	t.Setenv("RELOAD_CONFIRM_TIMEOUT", "500ms")
+	t.Setenv("RELOAD_AUTO_ROLLBACK", "true")
+	t.Setenv("BACKUP_DIR", filepath.Join(work, "backup"))
+	t.Setenv("ADMIN_RECOVERY_FILE", "")
+	return path
+}
+
+func commit(t *testing.T, path, content string) Result {
+	t.Helper()
+	sources, err := config.LoadAll(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	calls := 0
+	result, err := CommitOptions(sources, path, func([]byte) ([]byte, error) { return []byte(content), nil }, Options{
+		Done: func() error { calls++; return nil },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Done 只在新配置最终保留时调用
+	if want := map[bool]int{true: 1, false: 0}[result.OK]; calls != want {
+		t.Errorf("Done 调用了 %d 次，应为 %d 次", calls, want)
+	}
+	return result
+}
+
+func TestCommitHealthy(t *testing.T) {
+	path := setupCommit(t, true)
+	result := commit(t, path, newHooks)
+	if !result.OK || result.Title.String() != "保存成功" {
+		t.Fatalf("结果为 %s (OK=%v):\n%s", result.Title, result.OK, result.Message)
+	}
+	if data, _ := os.ReadFile(path); string(data) != newHooks {
+		t.Errorf("hooks 文件内容为 %q", data)
+	}
+	// 写入前的内容已备份
+	if data, err := ReadVersion(path, result.Backup); err != nil || string(data) != oldHooks {
+		t.Errorf("备份 %s 的内容为 %q, %v", result.Backup, data, err)
+	}
+}
+
+func TestCommitRollback(t *testing.T) {
+	path := setupCommit(t, false)
+	result := commit(t, path, newHooks)
+	if result.OK || result.Title.String() != "保存失败 (已恢复)" {
+		t.Fatalf("结果为 %s (OK=%v):\n%s", result.Title, result.OK, result.Message)
+	}
+	// 服务没有加载新配置：文件恢复为保存前的内容，未生效的新内容不会成为备份
+	if data, _ := os.ReadFile(path); string(data) != oldHooks {
+		t.Errorf("hooks 文件没有恢复，内容为 %q", data)
+	}
+	if versions, _ := Versions(path); len(versions) != 1 || versions[0].ID != result.Backup {
+		t.Errorf("备份版本为 %+v，应只有 %s", versions, result.Backup)
+	}
+	if !strings.Contains(result.Message, "b ✘ 未加载") {
+		t.Errorf("说明中没有列出未加载的 hook:\n%s", result.Message)
+	}
+}
+
+func TestCommitNoRollback(t *testing.T) {
+	path := setupCommit(t, false)
+	t.Setenv("RELOAD_AUTO_ROLLBACK", "false")
+	result := commit(t, path, newHooks)
+	if !result.OK || result.Title.String() != "保存成功 (新配置未生效)" {
+		t.Fatalf("结果为 %s (OK=%v):\n%s", result.Title, result.OK, result.Message)
+	}
+	if data, _ := os.ReadFile(path); string(data) != newHooks {
+		t.Errorf("关闭自动恢复时 hooks 文件被改回，内容为 %q", data)
+	}
+}
+
+func TestCommitInvalid(t *testing.T) {
+	path := setupCommit(t, true)
+	sources, _ := config.LoadAll(path)
+	_, err := CommitOptions(sources, path, func([]byte) ([]byte, error) { return []byte("- id: [\n"), nil }, Options{})
+	if _, ok := err.(*ValidationError); !ok {
+		t.Fatalf("语法错误的内容返回 %v，应为 *ValidationError", err)
+	}
+	// 校验失败时不写入、也不备份
+	if data, _ := os.ReadFile(path); string(data) != oldHooks {
+		t.Errorf("hooks 文件被改动，内容为 %q", data)
+	}
+	if versions, _ := Versions(path); len(versions) != 0 {
+		t.Errorf("校验失败时产生了备份 %+v", versions)
+	}
+}
+
+// setupAdmin 把 hooks 文件换成默认生成的管理 hook，恢复文件写在临时目录中，返回 hooks 文件、恢复文件和删除 save 后的内容
+func setupAdmin(t *testing.T) (string, string, []byte) {
+	path := setupCommit(t, true)
+	admin, err := adminhooks.Generate(adminhooks.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, admin, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	recovery := filepath.Join(filepath.Dir(path), "recovery.yaml")
+	t.Setenv("ADMIN_RECOVERY_FILE", recovery)
+	t.Setenv("SCRIPTS_DIR", adminhooks.DefaultScriptsDir)
+	doc, err := config.ParseDocument(admin, config.FormatYAML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := doc.Remove("save"); err != nil {
+		t.Fatal(err)
+	}
+	withoutSave, err := doc.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, recovery, withoutSave
+}
+
+func TestCheckAdmin(t *testing.T) {
+	path, _, withoutSave := setupAdmin(t)
+	sources, _ := config.LoadAll(path)
+	admin, _ := os.ReadFile(path)
+
+	if verr := CheckAdmin(sources, path, admin); verr != nil {
+		t.Errorf("未修改时报告 %v", verr)
+	}
+	if verr := CheckAdmin(sources, path, append(admin, "- id: deploy\n  execute-command: /opt/deploy.sh\n"...)); verr != nil {
+		t.Errorf("添加普通 hook 时报告 %v", verr)
+	}
+	verr := CheckAdmin(sources, path, withoutSave)
+	if verr == nil || !verr.Admin {
+		t.Fatalf("删除 save 时返回 %+v，应为 Admin 错误", verr)
+	}
+	if len(verr.Problems) != 1 || verr.Problems[0].String() != "save: 被删除或停用" || verr.Detail != "save: 被删除或停用" {
+		t.Errorf("Problems = %v, Detail = %q", verr.Problems, verr.Detail)
+	}
+	// 恢复文件尚未写入
+	if verr.Recovery != "" {
+		t.Errorf("Recovery = %q，恢复文件不存在时应为空", verr.Recovery)
+	}
+	// 语法和重复 id 等错误不是 Admin 错误，可以跳过的只有管理 hook 的检查
+	if _, verr := Validate(sources, path, append(admin, admin...)); verr == nil || verr.Admin {
+		t.Errorf("重复 id 时返回 %+v", verr)
+	}
+}
+
+func TestCommitAdminChanges(t *testing.T) {
+	path, recovery, withoutSave := setupAdmin(t)
+	admin, _ := os.ReadFile(path)
+	sources, _ := config.LoadAll(path)
+	modify := func([]byte) ([]byte, error) { return withoutSave, nil }
+
+	// 未确认时拒绝，文件不变，但保存前的管理 hook 已写入恢复文件
+	_, err := CommitOptions(sources, path, modify, Options{})
+	verr, ok := err.(*ValidationError)
+	if !ok || !verr.Admin {
+		t.Fatalf("删除 save 返回 %v，应为 Admin 的 *ValidationError", err)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, admin) {
+		t.Error("被拒绝的修改写入了 hooks 文件")
+	}
+	if verr.Recovery != recovery {
+		t.Errorf("Recovery = %q，应为 %q", verr.Recovery, recovery)
+	}
+
+	// 确认后写入，恢复文件中保留 save，可以用于恢复
+	result, err := CommitOptions(sources, path, modify, Options{AllowAdminChanges: true})
+	if err != nil || !result.OK {
+		t.Fatalf("确认后保存失败: %v\n%s", err, result.Message)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, withoutSave) {
+		t.Error("确认后 hooks 文件没有更新")
+	}
+	data, err := os.ReadFile(recovery)
+	if err != nil {
+		t.Fatal(err)
+	}
+	saved, err := config.Parse(data)
+	if err != nil || saved.Find("save") == nil {
+		t.Errorf("恢复文件中没有 save: %v", err)
+	}
+}
@@ -0,0 +1,178 @@
+package reload
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/env"
+	"webhook-ui/common/i18n"
+)
+
+// ProbeMethod 是探测 hook 路由时使用的 HTTP 方法。它不会出现在任何 hook 的 http-methods 中，
+// webhook 对已加载的 hook 返回 405、对不存在的 hook 返回 404，而不会执行命令
+const ProbeMethod = "WEBHOOK-UI-PROBE"
+
+// Prober 通过 HTTP 探测正在运行的 webhook 服务中有哪些 hook
+type Prober struct {
+	BaseURL string // 形如 http://127.0.0.1:9000/hooks
+	Client  *http.Client
+}
+
+// ProberFromEnv 根据 WEBHOOK_URL 或 PORT、URL_PREFIX 得到 webhook 服务地址。
+// 脚本与 webhook 运行在同一台机器（容器）中，默认访问 127.0.0.1
+func ProberFromEnv() Prober {
+	base := os.Getenv("WEBHOOK_URL")
+	if base == "" {
+		base = "http://127.0.0.1:" + env.Str("PORT", "9000")
+		if prefix := env.Str("URL_PREFIX", "hooks"); prefix != "" {
+			base += "/" + prefix
+		}
+	}
+	return Prober{
+		BaseURL: strings.TrimRight(base, "/"),
+		Client:  &http.Client{Timeout: 2 * time.Second},
+	}
+}
+
+// Probeable 判断 hook 是否可以安全探测：只有限制了 http-methods 的 hook 才会对 ProbeMethod 返回 405，
+// 未限制方法的 hook 会直接执行命令，因此不能探测
+func Probeable(h config.Hook) bool {
+	return len(h.HTTPMethods) > 0
+}
+
+// Known 返回 webhook 服务当前是否加载了 id 对应的 hook
+func (p Prober) Known(id string) (bool, error) {
+	req, err := http.NewRequest(ProbeMethod, p.BaseURL+"/"+id, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := p.Client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode != http.StatusNotFound, nil
+}
+
+// Reachable 返回 webhook 服务当前是否可以访问
+func (p Prober) Reachable() bool {
+	_, err := p.Known(ProbeMethod)
+	return err == nil
+}
+
+// HookState 是单个 hook 在运行中的 webhook 服务里的状态
+type HookState string
+
+const (
+	StateServed  HookState = "served"  // 在新配置中，服务已加载
+	StateMissing HookState = "missing" // 在新配置中，服务未加载
+	StateStale   HookState = "stale"   // 已从配置中删除，服务仍在提供
+	StateRemoved HookState = "removed" // 已从配置中删除，服务也已不再提供
+	StateSkipped HookState = "skipped" // 未限制 http-methods，无法安全探测
+)
+
+// HookHealth 是单个 hook 的探测结果
+type HookHealth struct {
+	ID    string
+	State HookState
+}
+
+// Report 是对新配置的健康检查结果
+type Report struct {
+	Hooks      []HookHealth
+	IDsChanged bool  // 新旧配置的 hook id 集合是否不同
+	Err        error // 无法访问 webhook 服务
+}
+
+// Healthy 表示服务可访问，且所有可探测的 hook 都与新配置一致
+func (r Report) Healthy() bool {
+	return r.Err == nil && r.Count(StateMissing) == 0 && r.Count(StateStale) == 0
+}
+
+// Count 返回处于 state 的 hook 数量
+func (r Report) Count(state HookState) int {
+	n := 0
+	for _, h := range r.Hooks {
+		if h.State == state {
+			n++
+		}
+	}
+	return n
+}
+
+// Summary 返回给用户看的一句话结论
+func (r Report) Summary() i18n.Message {
+	switch {
+	case r.Err != nil:
+		return i18n.M("无法访问 webhook 服务: %v", r.Err)
+	case !r.Healthy():
+		return i18n.M("webhook 服务与新配置不一致：%d 个 hook 未加载，%d 个已删除的 hook 仍在提供。",
+			r.Count(StateMissing), r.Count(StateStale))
+	case !r.IDsChanged:
+		return i18n.M("webhook 服务提供了新配置中全部 %d 个可探测的 hook；hook 列表未变化，无法确认修改的内容是否已加载。", r.Count(StateServed))
+	default:
+		return i18n.M("webhook 服务已加载新配置：%d 个 hook 已就绪。", r.Count(StateServed))
+	}
+}
+
+// Check 探测新配置中的每个 hook 以及已删除的 hook。timeout 大于 0 时，
+// 在超时前反复探测直到服务与新配置一致（用于等待重载完成）
+func (p Prober) Check(oldConfig, newConfig config.Config, timeout time.Duration) Report {
+	var report Report
+	var order []string           // 结果按新配置中的顺序排列，已删除的 hook 排在最后
+	present := map[string]bool{} // 参与探测的 hook -> 新配置中是否存在
+	for _, h := range newConfig {
+		order = append(order, h.ID)
+		if oldConfig.Find(h.ID) == nil {
+			report.IDsChanged = true
+		}
+		if Probeable(h) {
+			present[h.ID] = true
+		}
+	}
+	for _, h := range oldConfig {
+		if newConfig.Find(h.ID) != nil {
+			continue
+		}
+		report.IDsChanged = true
+		if Probeable(h) {
+			order = append(order, h.ID)
+			present[h.ID] = false
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		report.Hooks = nil
+		report.Err = nil
+		for _, id := range order {
+			inNew, probe := present[id]
+			if !probe {
+				report.Hooks = append(report.Hooks, HookHealth{ID: id, State: StateSkipped})
+				continue
+			}
+			known, err := p.Known(id)
+			if err != nil {
+				report.Err = err
+				break
+			}
+			state := StateServed
+			switch {
+			case inNew && !known:
+				state = StateMissing
+			case !inNew && known:
+				state = StateStale
+			case !inNew && !known:
+				state = StateRemoved
+			}
+			report.Hooks = append(report.Hooks, HookHealth{ID: id, State: state})
+		}
+		if report.Healthy() || time.Now().After(deadline) {
+			return report
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
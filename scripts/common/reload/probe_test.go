@@ -0,0 +1,153 @@
+package reload
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"webhook-ui/common/config"
+)
+
+// fakeWebhook 模拟 webhook 的路由：对 served 中的 hook 返回 405，其他返回 404，并记录收到的请求方法
+type fakeWebhook struct {
+	mu      sync.Mutex
+	served  map[string]bool
+	methods map[string]bool
+}
+
+func newFakeWebhook(t *testing.T, ids ...string) (*fakeWebhook, Prober) {
+	f := &fakeWebhook{served: map[string]bool{}, methods: map[string]bool{}}
+	f.serve(ids...)
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	return f, Prober{BaseURL: server.URL + "/hooks", Client: server.Client()}
+}
+
+// serve 把提供的 hook 换成 ids，相当于 webhook 重新加载了配置
+func (f *fakeWebhook) serve(ids ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.served = map[string]bool{}
+	for _, id := range ids {
+		f.served[id] = true
+	}
+}
+
+func (f *fakeWebhook) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.methods[r.Method] = true
+	if f.served[strings.TrimPrefix(r.URL.Path, "/hooks/")] {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+	w.WriteHeader(http.StatusNotFound)
+}
+
+// hooks 返回限制了 http-methods（可以探测）的 hook，id 以 ! 开头的未限制方法
+func hooks(ids ...string) config.Config {
+	var c config.Config
+	for _, id := range ids {
+		if name, ok := strings.CutPrefix(id, "!"); ok {
+			c = append(c, config.Hook{ID: name})
+		} else {
+			c = append(c, config.Hook{ID: id, HTTPMethods: []string{"POST"}})
+		}
+	}
+	return c
+}
+
+func states(r Report) map[string]HookState {
+	m := map[string]HookState{}
+	for _, h := range r.Hooks {
+		m[h.ID] = h.State
+	}
+	return m
+}
+
+func TestCheckStates(t *testing.T) {
+	f, p := newFakeWebhook(t, "kept", "stale")
+	report := p.Check(hooks("kept", "stale", "gone", "!open"), hooks("kept", "added", "!open"), 0)
+	want := map[string]HookState{
+		"kept":  StateServed,
+		"added": StateMissing,
+		"open":  StateSkipped,
+		"stale": StateStale,
+		"gone":  StateRemoved,
+	}
+	if got := states(report); len(got) != len(want) {
+		t.Errorf("结果为 %v，应为 %v", got, want)
+	} else {
+		for id, state := range want {
+			if got[id] != state {
+				t.Errorf("%s 的状态为 %s，应为 %s", id, got[id], state)
+			}
+		}
+	}
+	if report.Healthy() || !report.IDsChanged || report.Err != nil {
+		t.Errorf("报告为 %+v", report)
+	}
+	if order := []string{report.Hooks[0].ID, report.Hooks[1].ID, report.Hooks[2].ID}; strings.Join(order, ",") != "kept,added,open" {
+		t.Errorf("结果没有按新配置的顺序排列: %v", order)
+	}
+	// 只使用探测方法，不会触发任何 hook 的命令
+	if len(f.methods) != 1 || !f.methods[ProbeMethod] {
+		t.Errorf("探测使用了方法 %v", f.methods)
+	}
+}
+
+func TestCheckUnchangedIDs(t *testing.T) {
+	_, p := newFakeWebhook(t, "a", "b")
+	report := p.Check(hooks("a", "b"), hooks("a", "b"), 0)
+	if !report.Healthy() || report.IDsChanged || report.Count(StateServed) != 2 {
+		t.Errorf("报告为 %+v", report)
+	}
+	if !strings.Contains(report.Summary().String(), "无法确认") {
+		t.Errorf("hook 列表未变化时的结论为 %q", report.Summary())
+	}
+}
+
+// TestCheckWaitsForReload 检查设置了超时时反复探测，直到服务加载新配置
+func TestCheckWaitsForReload(t *testing.T) {
+	f, p := newFakeWebhook(t, "a")
+	time.AfterFunc(300*time.Millisecond, func() { f.serve("a", "b") })
+	report := p.Check(hooks("a"), hooks("a", "b"), 5*time.Second)
+	if !report.Healthy() || states(report)["b"] != StateServed {
+		t.Errorf("等待重载后报告为 %+v", report)
+	}
+
+	// 超时后返回最后一次的结果
+	start := time.Now()
+	report = p.Check(hooks("a", "b"), hooks("a", "b", "c"), 300*time.Millisecond)
+	if report.Healthy() || states(report)["c"] != StateMissing || time.Since(start) > 3*time.Second {
+		t.Errorf("超时后报告为 %+v（用时 %v）", report, time.Since(start))
+	}
+}
+
+func TestCheckUnreachable(t *testing.T) {
+	_, p := newFakeWebhook(t)
+	p.BaseURL = "http://127.0.0.1:1/hooks"
+	if p.Reachable() {
+		t.Fatal("无法连接的地址被认为可以访问")
+	}
+	report := p.Check(nil, hooks("a"), 0)
+	if report.Err == nil || report.Healthy() || !strings.Contains(report.Summary().String(), "无法访问") {
+		t.Errorf("报告为 %+v", report)
+	}
+}
+
+func TestProberFromEnv(t *testing.T) {
+	t.Setenv("WEBHOOK_URL", "http://webhook:9000/custom/")
+	if p := ProberFromEnv(); p.BaseURL != "http://webhook:9000/custom" {
+		t.Errorf("WEBHOOK_URL 时地址为 %s", p.BaseURL)
+	}
+	t.Setenv("WEBHOOK_URL", "")
+	t.Setenv("PORT", "8002")
+	t.Setenv("URL_PREFIX", "")
+	if p := ProberFromEnv(); p.BaseURL != "http://127.0.0.1:8002" {
+		t.Errorf("URL_PREFIX 为空时地址为 %s", p.BaseURL)
+	}
+}
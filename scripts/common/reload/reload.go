@@ -0,0 +1,172 @@
+// Package reload 在配置保存后通知 webhook 重新加载 hooks，并通过 HTTP 探测确认新配置是否生效
+package reload
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"webhook-ui/common/env"
+	"webhook-ui/common/i18n"
+)
+
+// 重载方式
+const (
+	MethodNone    = "none"    // 不做任何操作，由用户自行重启
+	MethodSignal  = "signal"  // 向 webhook 进程发送 SIGUSR1/SIGHUP
+	MethodTouch   = "touch"   // 更新被监视文件的修改时间（webhook 开启 HOT_RELOAD 时）
+	MethodCommand = "command" // 执行自定义重载命令
+)
+
+// ParentIsWebhook 为 true 时，signal 方式在未设置 RELOAD_PIDFILE 和 RELOAD_PROCESS_NAME 时向父进程发送信号。
+// 只有由 webhook 通过 execute-command 启动的脚本应设置它；独立服务和 webhookctl 的父进程是 shell 或 systemd，
+// SIGUSR1 的默认动作会结束这些进程
+var ParentIsWebhook bool
+
+// Options 描述如何触发重载，通常由 OptionsFromEnv 从环境变量得到
+type Options struct {
+	Method      string
+	Signal      string // HUP 或 USR1
+	PIDFile     string // 从 pidfile 读取 webhook 进程号
+	ProcessName string // 按进程名查找 webhook 进程（读取 /proc/*/comm）
+	TouchFile   string
+	Command     string
+}
+
+// OptionsFromEnv 从环境变量读取重载配置：
+//
+//	RELOAD_METHOD        none / signal / touch / command，默认 HOT_RELOAD=true 时为 touch，否则为 none
+//	RELOAD_SIGNAL        USR1（默认）或 HUP
+//	RELOAD_PIDFILE       webhook 的 pidfile；未设置时按 RELOAD_PROCESS_NAME 查找，都未设置时只有脚本（见 ParentIsWebhook）使用父进程
+//	RELOAD_PROCESS_NAME  webhook 的进程名
+//	RELOAD_TOUCH_FILE    touch 的文件，默认为 hooks 文件本身
+//	RELOAD_COMMAND       command 方式执行的命令（通过 sh -c 执行）
+func OptionsFromEnv(hooksPath string) Options {
+	method := MethodNone
+	if hot, _ := strconv.ParseBool(os.Getenv("HOT_RELOAD")); hot {
+		method = MethodTouch
+	}
+	return Options{
+		Method:      strings.ToLower(env.Str("RELOAD_METHOD", method)),
+		Signal:      strings.ToUpper(strings.TrimPrefix(env.Str("RELOAD_SIGNAL", "USR1"), "SIG")),
+		PIDFile:     os.Getenv("RELOAD_PIDFILE"),
+		ProcessName: os.Getenv("RELOAD_PROCESS_NAME"),
+		TouchFile:   env.Str("RELOAD_TOUCH_FILE", hooksPath),
+		Command:     os.Getenv("RELOAD_COMMAND"),
+	}
+}
+
+// Check 检查 signal 方式能否确定要通知的进程：未设置 pidfile 和进程名，且不是由 webhook 启动的脚本时返回错误。
+// 独立服务启动时调用，配置错误时拒绝启动
+func (o Options) Check() error {
+	if o.Method == MethodSignal && o.PIDFile == "" && o.ProcessName == "" && !ParentIsWebhook {
+		return i18n.Errorf("RELOAD_METHOD=signal 需要设置 RELOAD_PIDFILE 或 RELOAD_PROCESS_NAME")
+	}
+	return nil
+}
+
+// Result 是一次重载操作的结果
+type Result struct {
+	Method    string
+	Triggered bool         // 是否已成功触发重载
+	Message   i18n.Message // 给用户看的说明
+}
+
+// Trigger 按 Options 触发重载。MethodNone 时不做任何事，Triggered 为 false
+func Trigger(o Options) Result {
+	r := Result{Method: o.Method}
+	switch o.Method {
+	case "", MethodNone:
+		r.Method = MethodNone
+		r.Message = i18n.M("未配置自动重载 (RELOAD_METHOD)，Webhook 服务可能需要重启才能加载新配置。")
+	case MethodTouch:
+		now := time.Now()
+		if err := os.Chtimes(o.TouchFile, now, now); err != nil {
+			r.Message = i18n.M("无法更新 %s 的修改时间: %v", o.TouchFile, err)
+			return r
+		}
+		r.Triggered = true
+		r.Message = i18n.M("已更新 %s 的修改时间，等待 webhook 热加载。", o.TouchFile)
+	case MethodSignal:
+		pids, err := findPIDs(o)
+		if err != nil {
+			r.Message = i18n.M("无法找到 webhook 进程: %v", err)
+			return r
+		}
+		for _, pid := range pids {
+			if err := sendSignal(pid, o.Signal); err != nil {
+				r.Message = i18n.M("无法向进程 %d 发送 SIG%s: %v", pid, o.Signal, err)
+				return r
+			}
+		}
+		r.Triggered = true
+		r.Message = i18n.M("已向 webhook 进程 %v 发送 SIG%s。", pids, o.Signal)
+	case MethodCommand:
+		if o.Command == "" {
+			r.Message = i18n.M("RELOAD_METHOD=command 但未设置 RELOAD_COMMAND。")
+			return r
+		}
+		out, err := exec.Command("sh", "-c", o.Command).CombinedOutput()
+		if err != nil {
+			r.Message = i18n.M("重载命令执行失败: %v\n%s", err, strings.TrimSpace(string(out)))
+			return r
+		}
+		r.Triggered = true
+		r.Message = i18n.M("重载命令执行成功。")
+		if output := strings.TrimSpace(string(out)); output != "" {
+			r.Message = i18n.M("重载命令执行成功。\n%s", output)
+		}
+	default:
+		r.Message = i18n.M("未知的重载方式 RELOAD_METHOD=%q。", o.Method)
+	}
+	return r
+}
+
+// findPIDs 依次按 pidfile、进程名、父进程（仅限脚本）确定要发送信号的进程
+func findPIDs(o Options) ([]int, error) {
+	if err := o.Check(); err != nil {
+		return nil, err
+	}
+	if o.PIDFile != "" {
+		data, err := os.ReadFile(o.PIDFile)
+		if err != nil {
+			return nil, err
+		}
+		pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+		if err != nil || pid <= 0 {
+			return nil, i18n.Errorf("pidfile %s 内容无效", o.PIDFile)
+		}
+		return []int{pid}, nil
+	}
+	if o.ProcessName != "" {
+		return findProcessByName(o.ProcessName)
+	}
+	// 脚本由 webhook 通过 execute-command 启动，父进程就是 webhook
+	return []int{os.Getppid()}, nil
+}
+
+// findProcessByName 通过 /proc/<pid>/comm 查找进程，只在 Linux 上可用
+func findProcessByName(name string) ([]int, error) {
+	comms, err := filepath.Glob("/proc/[0-9]*/comm")
+	if err != nil {
+		return nil, err
+	}
+	var pids []int
+	for _, comm := range comms {
+		data, err := os.ReadFile(comm)
+		if err != nil || strings.TrimSpace(string(data)) != name {
+			continue
+		}
+		pid, err := strconv.Atoi(filepath.Base(filepath.Dir(comm)))
+		if err == nil && pid != os.Getpid() {
+			pids = append(pids, pid)
+		}
+	}
+	if len(pids) == 0 {
+		return nil, i18n.Errorf("没有名为 %s 的进程", name)
+	}
+	return pids, nil
+}
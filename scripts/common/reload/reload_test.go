@@ -0,0 +1,124 @@
+package reload
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestOptionsFromEnv(t *testing.T) {
+	// 未设置时使用默认值（设置为空字符串时按空字符串处理，见 env.Str）
+	for _, key := range []string{"RELOAD_METHOD", "RELOAD_TOUCH_FILE"} {
+		t.Setenv(key, "")
+		os.Unsetenv(key)
+	}
+	t.Setenv("HOT_RELOAD", "true")
+	t.Setenv("RELOAD_SIGNAL", "SIGHUP")
+	o := OptionsFromEnv("/etc/webhook/hooks.yaml")
+	if o.Method != MethodTouch || o.Signal != "HUP" || o.TouchFile != "/etc/webhook/hooks.yaml" {
+		t.Errorf("HOT_RELOAD=true 时 %+v", o)
+	}
+	t.Setenv("HOT_RELOAD", "")
+	t.Setenv("RELOAD_METHOD", "Command")
+	if o := OptionsFromEnv(""); o.Method != MethodCommand {
+		t.Errorf("RELOAD_METHOD=Command 时方式为 %s", o.Method)
+	}
+}
+
+func TestTriggerNone(t *testing.T) {
+	for _, method := range []string{"", MethodNone} {
+		if r := Trigger(Options{Method: method}); r.Triggered || r.Method != MethodNone || r.Message.IsZero() {
+			t.Errorf("方式 %q: %+v", method, r)
+		}
+	}
+	if r := Trigger(Options{Method: "restart"}); r.Triggered || !strings.Contains(r.Message.String(), "restart") {
+		t.Errorf("未知的方式: %+v", r)
+	}
+}
+
+func TestTriggerTouch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "hooks.yaml")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(path, old, old)
+	if r := Trigger(Options{Method: MethodTouch, TouchFile: path}); !r.Triggered {
+		t.Fatalf("touch 失败: %+v", r)
+	}
+	if info, _ := os.Stat(path); !info.ModTime().After(old) {
+		t.Error("修改时间没有更新")
+	}
+	if r := Trigger(Options{Method: MethodTouch, TouchFile: filepath.Join(path, "missing")}); r.Triggered {
+		t.Error("文件不存在时 touch 成功")
+	}
+}
+
+func TestTriggerCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("command 方式通过 sh -c 执行")
+	}
+	r := Trigger(Options{Method: MethodCommand, Command: "echo reloaded"})
+	if !r.Triggered || !strings.Contains(r.Message.String(), "reloaded") {
+		t.Errorf("命令成功时 %+v", r)
+	}
+	r = Trigger(Options{Method: MethodCommand, Command: "echo broken >&2; exit 3"})
+	if r.Triggered || !strings.Contains(r.Message.String(), "broken") {
+		t.Errorf("命令失败时 %+v", r)
+	}
+	if r := Trigger(Options{Method: MethodCommand}); r.Triggered {
+		t.Error("未设置 RELOAD_COMMAND 时触发成功")
+	}
+}
+
+// TestTriggerSignal 通过 pidfile 向测试进程自身发送 SIGHUP
+func TestTriggerSignal(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows 不支持信号")
+	}
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, syscall.SIGHUP)
+	defer signal.Stop(received)
+
+	pidfile := filepath.Join(t.TempDir(), "webhook.pid")
+	os.WriteFile(pidfile, []byte(strconv.Itoa(os.Getpid())+"\n"), 0o644)
+	if r := Trigger(Options{Method: MethodSignal, Signal: "HUP", PIDFile: pidfile}); !r.Triggered {
+		t.Fatalf("发送信号失败: %+v", r)
+	}
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("没有收到 SIGHUP")
+	}
+
+	os.WriteFile(pidfile, []byte("webhook"), 0o644)
+	if r := Trigger(Options{Method: MethodSignal, Signal: "HUP", PIDFile: pidfile}); r.Triggered {
+		t.Error("pidfile 内容无效时触发成功")
+	}
+}
+
+func TestCheck(t *testing.T) {
+	defer func(v bool) { ParentIsWebhook = v }(ParentIsWebhook)
+	ParentIsWebhook = false
+	if err := (Options{Method: MethodSignal}).Check(); err == nil {
+		t.Error("独立运行时没有 pidfile 和进程名也通过了检查")
+	}
+	if r := Trigger(Options{Method: MethodSignal, Signal: "USR1"}); r.Triggered {
+		t.Error("独立运行时向父进程发送了信号")
+	}
+	for _, o := range []Options{{Method: MethodSignal, PIDFile: "/run/webhook.pid"}, {Method: MethodSignal, ProcessName: "webhook"}, {Method: MethodTouch}} {
+		if err := o.Check(); err != nil {
+			t.Errorf("%+v: %v", o, err)
+		}
+	}
+	ParentIsWebhook = true
+	if err := (Options{Method: MethodSignal}).Check(); err != nil {
+		t.Errorf("由 webhook 启动时: %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !windows
+
+package reload
+
+import (
+	"syscall"
+
+	"webhook-ui/common/i18n"
+)
+
+func sendSignal(pid int, name string) error {
+	var sig syscall.Signal
+	switch name {
+	case "USR1":
+		sig = syscall.SIGUSR1
+	case "HUP":
+		sig = syscall.SIGHUP
+	default:
+		return i18n.Errorf("不支持的信号 SIG%s，只支持 USR1 和 HUP", name)
+	}
+	return syscall.Kill(pid, sig)
+}
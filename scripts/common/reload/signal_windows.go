@@ -0,0 +1,9 @@
+//go:build windows
+
+package reload
+
+import "webhook-ui/common/i18n"
+
+func sendSignal(pid int, name string) error {
+	return i18n.Errorf("Windows 不支持通过信号重载，请使用 RELOAD_METHOD=command")
+}
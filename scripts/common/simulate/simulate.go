@@ -0,0 +1,446 @@
+// Package simulate 按 webhook 的规则离线演练一次请求：检查 http-methods 和 trigger-rule，
+// 并计算会传给命令的参数、环境变量和文件，但不执行命令
+package simulate
+
+import (
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/i18n"
+)
+
+// Request 是要演练的请求
+type Request struct {
+	Method     string
+	Headers    http.Header
+	Query      url.Values
+	Body       []byte
+	RemoteAddr string // 形如 1.2.3.4:5678，用于 ip-whitelist 和 source: request
+}
+
+// File 是通过 pass-file-to-command 传给命令的临时文件
+type File struct {
+	EnvName string
+	Content []byte
+}
+
+// RuleResult 是 trigger-rule 中一个节点的结果
+type RuleResult struct {
+	Kind     string // and、or、not、match
+	Detail   string // match 的说明，如 `value: payload ref == refs/heads/main`
+	Matched  bool
+	Err      string // 参数不存在、签名错误等
+	Children []*RuleResult
+}
+
+// Result 是演练结果
+type Result struct {
+	MethodAllowed    bool
+	Rule             *RuleResult // 没有 trigger-rule 时为 nil
+	Triggered        bool        // 是否会执行命令
+	Status           int         // webhook 返回的状态码
+	Response         string      // webhook 返回的响应内容（执行命令并返回输出时为空）
+	Command          []string    // execute-command 及 pass-arguments-to-command
+	WorkingDirectory string
+	Env              []string
+	Files            []File
+	Warnings         []string // 找不到的参数等，webhook 会在日志中报告
+}
+
+// request 是解析后的请求，与 webhook 内部的结构相同
+type request struct {
+	Request
+	payload map[string]interface{}
+	headers map[string]interface{}
+	query   map[string]interface{}
+}
+
+// valuesToMap 把多值的 header/query 转换为 webhook 使用的 map：只有一个值时为字符串
+func valuesToMap(values map[string][]string) map[string]interface{} {
+	m := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		if len(value) == 1 {
+			m[key] = value[0]
+		} else {
+			items := make([]interface{}, len(value))
+			for i, v := range value {
+				items[i] = v
+			}
+			m[key] = items
+		}
+	}
+	return m
+}
+
+// parsePayload 按 Content-Type（hook 设置了 incoming-payload-content-type 时以它为准）解析请求体
+func parsePayload(hook config.Hook, r Request) (map[string]interface{}, error) {
+	contentType := r.Headers.Get("Content-Type")
+	if hook.IncomingPayloadContentType != "" {
+		contentType = hook.IncomingPayloadContentType
+	}
+	if len(r.Body) == 0 {
+		return map[string]interface{}{}, nil
+	}
+	switch {
+	case strings.Contains(contentType, "json"):
+		var payload interface{}
+		if err := json.Unmarshal(r.Body, &payload); err != nil {
+			return nil, i18n.Errorf("无法解析 JSON 请求体: %v", err)
+		}
+		if m, ok := payload.(map[string]interface{}); ok {
+			return m, nil
+		}
+		// 顶层是数组时 webhook 把它放在 root 下
+		return map[string]interface{}{"root": payload}, nil
+	case strings.Contains(contentType, "x-www-form-urlencoded"):
+		values, err := url.ParseQuery(string(r.Body))
+		if err != nil {
+			return nil, i18n.Errorf("无法解析表单请求体: %v", err)
+		}
+		return valuesToMap(values), nil
+	}
+	return map[string]interface{}{}, nil
+}
+
+// getParameter 按 webhook 的写法查找参数：a.b.0.c 依次进入对象和数组，键中本身带 "." 时优先整体匹配
+func getParameter(name string, params interface{}) (interface{}, bool) {
+	switch p := params.(type) {
+	case map[string]interface{}:
+		if v, ok := p[name]; ok {
+			return v, true
+		}
+		head, rest, found := strings.Cut(name, ".")
+		if !found {
+			return nil, false
+		}
+		if v, ok := p[head]; ok {
+			return getParameter(rest, v)
+		}
+	case []interface{}:
+		head, rest, found := strings.Cut(name, ".")
+		index, err := strconv.Atoi(head)
+		if err != nil || index < 0 || index >= len(p) {
+			return nil, false
+		}
+		if !found {
+			return p[index], true
+		}
+		return getParameter(rest, p[index])
+	}
+	return nil, false
+}
+
+// setParameter 把 name 处的值替换为 value，用于 parse-parameters-as-json
+func setParameter(name string, params interface{}, value interface{}) bool {
+	switch p := params.(type) {
+	case map[string]interface{}:
+		if _, ok := p[name]; ok {
+			p[name] = value
+			return true
+		}
+		head, rest, found := strings.Cut(name, ".")
+		if v, ok := p[head]; ok && found {
+			return setParameter(rest, v, value)
+		}
+	case []interface{}:
+		head, rest, found := strings.Cut(name, ".")
+		index, err := strconv.Atoi(head)
+		if err != nil || index < 0 || index >= len(p) {
+			return false
+		}
+		if !found {
+			p[index] = value
+			return true
+		}
+		return setParameter(rest, p[index], value)
+	}
+	return false
+}
+
+// toString 把参数值转换为字符串：对象和数组序列化为 JSON
+func toString(v interface{}) string {
+	switch value := v.(type) {
+	case string:
+		return value
+	case map[string]interface{}, []interface{}:
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
+
+// get 返回参数的值
+func (r *request) get(a config.Argument) (string, error) {
+	var source interface{}
+	key := a.Name
+	switch a.Source {
+	case "string":
+		return a.Name, nil
+	case "header":
+		source, key = r.headers, textproto.CanonicalMIMEHeaderKey(a.Name)
+	case "url", "query":
+		source = r.query
+	case "payload":
+		source = r.payload
+	case "request":
+		switch strings.ToLower(a.Name) {
+		case "remote-addr":
+			return r.RemoteAddr, nil
+		case "method":
+			return r.Method, nil
+		}
+		return "", i18n.Errorf("不支持的 request 参数 %q", a.Name)
+	case "entire-payload":
+		return toString(r.payload), nil
+	case "entire-headers":
+		return toString(r.headers), nil
+	case "entire-query":
+		return toString(r.query), nil
+	case "raw-request-body":
+		return string(r.Body), nil
+	default:
+		return "", i18n.Errorf("未知的参数来源 %q", a.Source)
+	}
+	value, ok := getParameter(key, source)
+	if !ok {
+		return "", i18n.Errorf("找不到参数 %s %q", a.Source, a.Name)
+	}
+	return toString(value), nil
+}
+
+// envName 返回参数对应的环境变量名，未设置 envname 时为 HOOK_<name>
+func envName(a config.Argument) string {
+	if a.EnvName != "" {
+		return a.EnvName
+	}
+	return "HOOK_" + a.Name
+}
+
+// Run 演练 hook 收到请求 r 时的处理过程
+func Run(hook config.Hook, r Request) Result {
+	var result Result
+	req := &request{Request: r, headers: valuesToMap(r.Headers), query: valuesToMap(r.Query)}
+	if req.Headers == nil {
+		req.Headers = http.Header{}
+	}
+
+	result.MethodAllowed = len(hook.HTTPMethods) == 0
+	for _, m := range hook.HTTPMethods {
+		if strings.EqualFold(strings.TrimSpace(m), r.Method) {
+			result.MethodAllowed = true
+		}
+	}
+	if !result.MethodAllowed {
+		result.Status = http.StatusMethodNotAllowed
+		return result
+	}
+
+	payload, err := parsePayload(hook, r)
+	if err != nil {
+		result.Warnings = append(result.Warnings, err.Error())
+		payload = map[string]interface{}{}
+	}
+	req.payload = payload
+	for _, a := range hook.JSONStringParameters {
+		value, err := req.get(a)
+		if err != nil {
+			result.Warnings = append(result.Warnings, err.Error())
+			continue
+		}
+		var parsed interface{}
+		if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+			result.Warnings = append(result.Warnings, i18n.M("参数 %q 不是合法的 JSON: %v", a.Name, err).String())
+			continue
+		}
+		switch a.Source {
+		case "payload":
+			setParameter(a.Name, req.payload, parsed)
+		case "header":
+			setParameter(textproto.CanonicalMIMEHeaderKey(a.Name), req.headers, parsed)
+		case "url", "query":
+			setParameter(a.Name, req.query, parsed)
+		}
+	}
+
+	result.Triggered = true
+	if hook.TriggerRule != nil {
+		result.Rule = req.evaluate(*hook.TriggerRule, hook.TriggerSignatureSoftFailures)
+		result.Triggered = result.Rule.Matched
+	}
+	if !result.Triggered {
+		result.Status = http.StatusOK
+		if hook.TriggerRuleMismatchHttpResponseCode != 0 {
+			result.Status = hook.TriggerRuleMismatchHttpResponseCode
+		}
+		result.Response = "Hook rules were not satisfied."
+		return result
+	}
+
+	result.Command = []string{hook.ExecuteCommand}
+	for _, a := range hook.PassArgumentsToCommand {
+		value, err := req.get(a)
+		if err != nil {
+			// webhook 对找不到的参数传入空字符串，保持参数位置不变
+			result.Warnings = append(result.Warnings, err.Error())
+		}
+		result.Command = append(result.Command, value)
+	}
+	for _, a := range hook.PassEnvironmentToCommand {
+		value, err := req.get(a)
+		if err != nil {
+			result.Warnings = append(result.Warnings, err.Error())
+			continue
+		}
+		result.Env = append(result.Env, envName(a)+"="+value)
+	}
+	for _, a := range hook.PassFileToCommand {
+		value, err := req.get(a)
+		if err != nil {
+			result.Warnings = append(result.Warnings, err.Error())
+			continue
+		}
+		content := []byte(value)
+		if a.Base64Decode {
+			if content, err = base64.StdEncoding.DecodeString(value); err != nil {
+				result.Warnings = append(result.Warnings, i18n.M("参数 %q 不是合法的 base64: %v", a.Name, err).String())
+				continue
+			}
+		}
+		result.Files = append(result.Files, File{EnvName: envName(a), Content: content})
+	}
+	result.WorkingDirectory = hook.CommandWorkingDirectory
+	result.Status = http.StatusOK
+	if hook.SuccessHttpResponseCode != 0 {
+		result.Status = hook.SuccessHttpResponseCode
+	}
+	if !hook.CaptureCommandOutput {
+		result.Response = hook.ResponseMessage
+	}
+	return result
+}
+
+// evaluate 计算 trigger-rule，记录每个节点的结果
+func (r *request) evaluate(rule config.Rules, soft bool) *RuleResult {
+	switch {
+	case rule.And != nil:
+		res := &RuleResult{Kind: "and", Matched: true}
+		for _, child := range *rule.And {
+			c := r.evaluate(child, soft)
+			res.Children = append(res.Children, c)
+			res.Matched = res.Matched && c.Matched
+		}
+		return res
+	case rule.Or != nil:
+		res := &RuleResult{Kind: "or"}
+		for _, child := range *rule.Or {
+			c := r.evaluate(child, soft)
+			res.Children = append(res.Children, c)
+			res.Matched = res.Matched || c.Matched
+		}
+		return res
+	case rule.Not != nil:
+		c := r.evaluate(config.Rules(*rule.Not), soft)
+		return &RuleResult{Kind: "not", Matched: !c.Matched, Children: []*RuleResult{c}}
+	case rule.Match != nil:
+		return r.match(*rule.Match, soft)
+	}
+	return &RuleResult{Kind: "match", Detail: i18n.M("空规则").String(), Matched: false}
+}
+
+// match 计算一条 match 规则
+func (r *request) match(m config.MatchRule, soft bool) *RuleResult {
+	res := &RuleResult{Kind: "match"}
+	if m.Type == "ip-whitelist" {
+		res.Detail = fmt.Sprintf("ip-whitelist: %s in %s", r.RemoteAddr, m.IPRange)
+		res.Matched, res.Err = ipAllowed(r.RemoteAddr, m.IPRange)
+		return res
+	}
+	res.Detail = fmt.Sprintf("%s: %s %s", m.Type, m.Parameter.Source, m.Parameter.Name)
+	value, err := r.get(m.Parameter)
+	if err != nil {
+		res.Err = err.Error()
+		return res
+	}
+	switch m.Type {
+	case "value":
+		res.Detail += " " + i18n.M("== %q (实际为 %q)", m.Value, value).String()
+		res.Matched = hmac.Equal([]byte(value), []byte(m.Value))
+	case "regex":
+		res.Detail += " " + i18n.M("=~ %s (实际为 %q)", m.Regex, value).String()
+		if res.Matched, err = regexp.MatchString(m.Regex, value); err != nil {
+			res.Err = err.Error()
+		}
+	case "payload-hmac-sha1", "payload-hash-sha1":
+		res.Matched, res.Err = checkSignature(sha1.New, "sha1=", r.Body, m.Secret, value, soft)
+	case "payload-hmac-sha256", "payload-hash-sha256":
+		res.Matched, res.Err = checkSignature(sha256.New, "sha256=", r.Body, m.Secret, value, soft)
+	case "payload-hmac-sha512", "payload-hash-sha512":
+		res.Matched, res.Err = checkSignature(sha512.New, "sha512=", r.Body, m.Secret, value, soft)
+	default:
+		res.Err = i18n.M("不支持演练的规则类型 %q", m.Type).String()
+	}
+	return res
+}
+
+// checkSignature 检查请求体的 HMAC 签名。签名可以带 sha256= 之类的前缀，也可以是逗号分隔的多个签名
+func checkSignature(newHash func() hash.Hash, prefix string, body []byte, secret, signatures string, soft bool) (bool, string) {
+	if secret == "" {
+		return false, i18n.M("未设置 secret").String()
+	}
+	mac := hmac.New(newHash, []byte(secret))
+	mac.Write(body)
+	expected := hex.EncodeToString(mac.Sum(nil))
+	for _, signature := range strings.Split(signatures, ",") {
+		signature = strings.TrimPrefix(strings.TrimSpace(signature), prefix)
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return true, ""
+		}
+	}
+	if soft {
+		return false, ""
+	}
+	return false, i18n.M("签名不匹配，期望 %s%s", prefix, expected).String()
+}
+
+// ipAllowed 判断 remoteAddr 是否在空格分隔的 IP 或 CIDR 列表中
+func ipAllowed(remoteAddr, ipRange string) (bool, string) {
+	host := remoteAddr
+	if h, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		host = h
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false, i18n.M("无效的客户端地址 %q", remoteAddr).String()
+	}
+	for _, item := range strings.Fields(ipRange) {
+		if !strings.Contains(item, "/") {
+			if ip.Equal(net.ParseIP(item)) {
+				return true, ""
+			}
+			continue
+		}
+		_, network, err := net.ParseCIDR(item)
+		if err != nil {
+			return false, i18n.M("无效的 ip-range %q", item).String()
+		}
+		if network.Contains(ip) {
+			return true, ""
+		}
+	}
+	return false, ""
+}
@@ -0,0 +1,262 @@
+package upload
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"webhook-ui/common/i18n"
+)
+
+// 支持解压的压缩包后缀，顺序决定匹配优先级（.tar.gz 要先于 .gz 之类的短后缀）
+var archiveSuffixes = []string{".tar.gz", ".tgz", ".zip"}
+
+// 单个压缩包解压后允许的最大总字节数，防止压缩炸弹写满磁盘
+const maxExtractedBytes = 2 << 30 // 2 GiB
+
+// archiveSuffix 返回文件名匹配到的压缩包后缀，不是压缩包时返回空字符串
+func archiveSuffix(name string) string {
+	lower := strings.ToLower(name)
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(lower, suffix) && len(name) > len(suffix) {
+			return suffix
+		}
+	}
+	return ""
+}
+
+// archiveFolderName 去掉压缩包后缀，得到解压目标子目录名，例如 tool.tar.gz -> tool
+func archiveFolderName(name string) string {
+	return name[:len(name)-len(archiveSuffix(name))]
+}
+
+// safeJoin 把压缩包内的条目路径拼接到 root 下，拒绝绝对路径和 ".." 逃逸（zip-slip）
+func safeJoin(root, entry string) (string, error) {
+	entry = filepath.FromSlash(strings.ReplaceAll(entry, "\\", "/"))
+	if filepath.IsAbs(entry) || filepath.VolumeName(entry) != "" {
+		return "", i18n.Errorf("压缩包条目使用了绝对路径: %s", entry)
+	}
+	target := filepath.Join(root, entry)
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return "", i18n.Errorf("压缩包条目试图写到目标目录之外: %s", entry)
+	}
+	return target, nil
+}
+
+// entryMode 只保留权限位，并保证所有者可读写；可执行位按压缩包里的原样保留
+func entryMode(mode os.FileMode) os.FileMode {
+	perm := mode.Perm() &^ 0022 // 去掉组/其他用户的写权限
+	return perm | 0600
+}
+
+// extractLimiter 统计所有条目累计写出的字节数，超出上限时报错
+type extractLimiter struct {
+	written int64
+}
+
+func (l *extractLimiter) copy(dst io.Writer, src io.Reader) error {
+	n, err := io.Copy(dst, io.LimitReader(src, maxExtractedBytes-l.written+1))
+	l.written += n
+	if err != nil {
+		return err
+	}
+	if l.written > maxExtractedBytes {
+		return i18n.Errorf("解压后的内容超过上限 %d 字节", int64(maxExtractedBytes))
+	}
+	return nil
+}
+
+// writeEntry 在 root 下创建一个普通文件并写入内容
+func writeEntry(root, name string, mode os.FileMode, src io.Reader, limiter *extractLimiter) error {
+	target, err := safeJoin(root, name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	// O_EXCL：同名条目重复出现时直接报错，而不是悄悄覆盖
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE|os.O_EXCL, entryMode(mode))
+	if err != nil {
+		return err
+	}
+	if err := limiter.copy(f, src); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	// OpenFile 的权限会受 umask 影响，这里再设置一次以保留可执行位
+	return os.Chmod(target, entryMode(mode))
+}
+
+// extractTarGz 解压 .tar.gz / .tgz 到 root。符号链接、硬链接和设备文件一律拒绝
+func extractTarGz(archivePath, root string) (int, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return 0, i18n.Errorf("不是有效的 gzip 文件: %v", err)
+	}
+	defer gz.Close()
+
+	var limiter extractLimiter
+	count := 0
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return count, i18n.Errorf("读取 tar 条目失败: %v", err)
+		}
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			target, err := safeJoin(root, hdr.Name)
+			if err != nil {
+				return count, err
+			}
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return count, err
+			}
+		case tar.TypeReg:
+			if err := writeEntry(root, hdr.Name, hdr.FileInfo().Mode(), tr, &limiter); err != nil {
+				return count, err
+			}
+			count++
+		case tar.TypeXGlobalHeader:
+			// pax 全局头不包含文件内容，忽略
+		default:
+			return count, i18n.Errorf("不支持的条目类型 (符号链接/硬链接/设备文件等): %s", hdr.Name)
+		}
+	}
+	return count, nil
+}
+
+// extractZip 解压 .zip 到 root。zip 中标记为符号链接的条目同样拒绝
+func extractZip(archivePath, root string) (int, error) {
+	zr, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return 0, i18n.Errorf("不是有效的 zip 文件: %v", err)
+	}
+	defer zr.Close()
+
+	var limiter extractLimiter
+	count := 0
+	for _, zf := range zr.File {
+		mode := zf.Mode()
+		switch {
+		case mode.IsDir():
+			target, err := safeJoin(root, zf.Name)
+			if err != nil {
+				return count, err
+			}
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return count, err
+			}
+		case mode.IsRegular():
+			rc, err := zf.Open()
+			if err != nil {
+				return count, err
+			}
+			err = writeEntry(root, zf.Name, mode, rc, &limiter)
+			rc.Close()
+			if err != nil {
+				return count, err
+			}
+			count++
+		default:
+			return count, i18n.Errorf("不支持的条目类型 (符号链接/设备文件等): %s", zf.Name)
+		}
+	}
+	return count, nil
+}
+
+// errExchangeUnsupported 表示系统不支持原子交换两个目录（见 exchange）
+var errExchangeUnsupported = errors.New("exchange not supported")
+
+// extractArchive 把压缩包解压到 destDir/folder。
+// 先解压到同目录下的临时目录，全部成功后再整体替换旧目录：Linux 上用 renameat2(RENAME_EXCHANGE)
+// 原子交换新旧目录，hook 在任何时刻看到的都是完整的旧版本或完整的新版本。
+// 其他系统（或文件系统不支持交换时）先把旧目录改名挪开再把新目录改名就位，两次改名之间目录短暂不存在。
+func extractArchive(archivePath, archiveName, destDir string) (string, int, error) {
+	folder := filepath.Base(archiveFolderName(archiveName))
+	if folder == "." || folder == ".." || strings.HasPrefix(folder, ".") {
+		return "", 0, i18n.Errorf("无法从文件名 '%s' 得到有效的目录名", archiveName)
+	}
+	finalDir := filepath.Join(destDir, folder)
+
+	stagingDir, err := os.MkdirTemp(destDir, "."+folder+".extract-*")
+	if err != nil {
+		return "", 0, i18n.Errorf("无法创建临时解压目录: %v", err)
+	}
+	// MkdirTemp 创建的目录权限为 0700，换成普通目录权限
+	if err := os.Chmod(stagingDir, 0755); err != nil {
+		os.RemoveAll(stagingDir)
+		return "", 0, err
+	}
+
+	var count int
+	if archiveSuffix(archiveName) == ".zip" {
+		count, err = extractZip(archivePath, stagingDir)
+	} else {
+		count, err = extractTarGz(archivePath, stagingDir)
+	}
+	if err != nil {
+		os.RemoveAll(stagingDir)
+		return "", count, err
+	}
+
+	var backupDir string
+	if info, statErr := os.Lstat(finalDir); statErr == nil {
+		if !info.IsDir() {
+			os.RemoveAll(stagingDir)
+			return "", count, i18n.Errorf("%s 已存在且不是目录", finalDir)
+		}
+		// 交换后临时目录中是旧版本，删除即可
+		err := exchange(stagingDir, finalDir)
+		if err == nil {
+			if err := os.RemoveAll(stagingDir); err != nil {
+				fmt.Fprintf(os.Stderr, "Warning: Could not remove old directory '%s': %v\n", stagingDir, err)
+			}
+			return finalDir, count, nil
+		} else if err != errExchangeUnsupported {
+			os.RemoveAll(stagingDir)
+			return "", count, i18n.Errorf("无法替换目标目录: %v", err)
+		}
+
+		// 不支持交换：旧目录先改名挪开，新目录改名就位，最后再删除旧目录
+		suffix := strings.TrimPrefix(filepath.Base(stagingDir), "."+folder+".extract-")
+		backupDir = filepath.Join(destDir, "."+folder+".old-"+suffix)
+		if err := os.Rename(finalDir, backupDir); err != nil {
+			os.RemoveAll(stagingDir)
+			return "", count, i18n.Errorf("无法移走旧目录: %v", err)
+		}
+	}
+	if err := os.Rename(stagingDir, finalDir); err != nil {
+		if backupDir != "" {
+			os.Rename(backupDir, finalDir) // 尽量恢复旧版本
+		}
+		os.RemoveAll(stagingDir)
+		return "", count, i18n.Errorf("无法替换目标目录: %v", err)
+	}
+	if backupDir != "" {
+		if err := os.RemoveAll(backupDir); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Could not remove old directory '%s': %v\n", backupDir, err)
+		}
+	}
+	return finalDir, count, nil
+}
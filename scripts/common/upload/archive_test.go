@@ -0,0 +1,256 @@
+package upload
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// archiveEntry 是构造测试压缩包用的条目，link 不为空时写成指向 link 的符号链接
+type archiveEntry struct {
+	name, body, link string
+	mode             os.FileMode
+}
+
+// writeZip 在 dir 下生成名为 name 的 zip 文件，返回其路径
+func writeZip(t *testing.T, dir, name string, entries []archiveEntry) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		body := e.body
+		if e.link != "" {
+			hdr.SetMode(os.ModeSymlink | 0777)
+			body = e.link
+		} else if e.mode != 0 {
+			hdr.SetMode(e.mode)
+		} else {
+			hdr.SetMode(0644)
+		}
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write([]byte(body))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// writeTarGz 在 dir 下生成名为 name 的 tar.gz 文件，返回其路径
+func writeTarGz(t *testing.T, dir, name string, entries []archiveEntry) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		hdr := &tar.Header{Name: e.name, Mode: 0644, Typeflag: tar.TypeReg, Size: int64(len(e.body))}
+		if e.mode != 0 {
+			hdr.Mode = int64(e.mode)
+		}
+		if e.link != "" {
+			hdr.Typeflag, hdr.Linkname, hdr.Size = tar.TypeSymlink, e.link, 0
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			t.Fatal(err)
+		}
+		if e.link == "" {
+			tw.Write([]byte(e.body))
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// listDir 返回目录下的所有文件名，用于检查解压失败后没有留下临时目录
+func listDir(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	return names
+}
+
+func TestSafeJoin(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "root")
+	cases := []struct {
+		entry string
+		ok    bool
+	}{
+		{"a.txt", true},
+		{"dir/b.txt", true},
+		{"dir/../c.txt", true},
+		{"./d.txt", true},
+		{"../evil", false},
+		{"dir/../../evil", false},
+		{"..\\evil", false},
+		{"/etc/passwd", false},
+		{"..", false},
+	}
+	for _, tc := range cases {
+		target, err := safeJoin(root, tc.entry)
+		if tc.ok {
+			if err != nil {
+				t.Errorf("safeJoin(%q) 返回错误: %v", tc.entry, err)
+			} else if !strings.HasPrefix(target, root+string(os.PathSeparator)) {
+				t.Errorf("safeJoin(%q) = %q，不在 %q 下", tc.entry, target, root)
+			}
+		} else if err == nil {
+			t.Errorf("safeJoin(%q) = %q，应拒绝", tc.entry, target)
+		}
+	}
+}
+
+func TestExtractArchiveRejectsUnsafeEntries(t *testing.T) {
+	cases := []struct {
+		name    string
+		entries []archiveEntry
+	}{
+		{"zip-slip.zip", []archiveEntry{{name: "ok.txt", body: "ok"}, {name: "../evil.txt", body: "evil"}}},
+		{"absolute.zip", []archiveEntry{{name: "/evil.txt", body: "evil"}}},
+		{"symlink.zip", []archiveEntry{{name: "link", link: "/etc/passwd"}}},
+		{"zip-slip.tar.gz", []archiveEntry{{name: "ok.txt", body: "ok"}, {name: "../evil.txt", body: "evil"}}},
+		{"absolute.tgz", []archiveEntry{{name: "/evil.txt", body: "evil"}}},
+		{"symlink.tar.gz", []archiveEntry{{name: "link", link: "/etc/passwd"}}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			work := t.TempDir()
+			destDir := filepath.Join(work, "dest")
+			os.Mkdir(destDir, 0755)
+			var archive string
+			if archiveSuffix(tc.name) == ".zip" {
+				archive = writeZip(t, work, tc.name, tc.entries)
+			} else {
+				archive = writeTarGz(t, work, tc.name, tc.entries)
+			}
+
+			if _, _, err := extractArchive(archive, tc.name, destDir); err == nil {
+				t.Fatal("解压不安全的压缩包没有报错")
+			}
+			// 失败后临时目录应已删除，也不能有文件写到目标目录之外
+			if names := listDir(t, destDir); len(names) != 0 {
+				t.Errorf("目标目录中留下了 %v", names)
+			}
+			if _, err := os.Lstat(filepath.Join(work, "evil.txt")); err == nil {
+				t.Error("条目被写到了目标目录之外")
+			}
+		})
+	}
+}
+
+func TestExtractArchive(t *testing.T) {
+	for _, name := range []string{"tool.zip", "tool.tar.gz", "tool.tgz"} {
+		t.Run(name, func(t *testing.T) {
+			work := t.TempDir()
+			destDir := filepath.Join(work, "dest")
+			os.Mkdir(destDir, 0755)
+			write := writeTarGz
+			if archiveSuffix(name) == ".zip" {
+				write = writeZip
+			}
+
+			v1 := write(t, work, name, []archiveEntry{
+				{name: "run.sh", body: "v1", mode: 0755},
+				{name: "lib/old.txt", body: "old"},
+			})
+			dir, count, err := extractArchive(v1, name, destDir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dir != filepath.Join(destDir, "tool") || count != 2 {
+				t.Fatalf("extractArchive = %q, %d", dir, count)
+			}
+			info, err := os.Stat(filepath.Join(dir, "run.sh"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if info.Mode().Perm()&0100 == 0 {
+				t.Errorf("run.sh 的可执行位丢失: %v", info.Mode())
+			}
+
+			// 再次解压新版本：旧目录整体被替换，旧版本独有的文件不再存在，也不留下临时目录
+			v2 := write(t, work, name, []archiveEntry{{name: "run.sh", body: "v2", mode: 0755}})
+			if _, count, err = extractArchive(v2, name, destDir); err != nil || count != 1 {
+				t.Fatalf("第二次解压: count=%d err=%v", count, err)
+			}
+			if data, _ := os.ReadFile(filepath.Join(dir, "run.sh")); string(data) != "v2" {
+				t.Errorf("run.sh 内容为 %q，应为 v2", data)
+			}
+			if _, err := os.Stat(filepath.Join(dir, "lib", "old.txt")); err == nil {
+				t.Error("旧版本的 lib/old.txt 仍然存在")
+			}
+			if names := listDir(t, destDir); len(names) != 1 || names[0] != "tool" {
+				t.Errorf("目标目录中的内容为 %v，应只有 tool", names)
+			}
+		})
+	}
+}
+
+func TestExtractArchiveTargetNotDir(t *testing.T) {
+	work := t.TempDir()
+	destDir := filepath.Join(work, "dest")
+	os.Mkdir(destDir, 0755)
+	os.WriteFile(filepath.Join(destDir, "tool"), []byte("file"), 0644)
+	archive := writeZip(t, work, "tool.zip", []archiveEntry{{name: "a.txt", body: "a"}})
+
+	if _, _, err := extractArchive(archive, "tool.zip", destDir); err == nil {
+		t.Fatal("同名文件已存在时没有报错")
+	}
+	if data, _ := os.ReadFile(filepath.Join(destDir, "tool")); string(data) != "file" {
+		t.Error("已存在的同名文件被改动")
+	}
+	if names := listDir(t, destDir); len(names) != 1 {
+		t.Errorf("目标目录中留下了 %v", names)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	work := t.TempDir()
+	a, b := filepath.Join(work, "a"), filepath.Join(work, "b")
+	os.Mkdir(a, 0755)
+	os.Mkdir(b, 0755)
+	os.WriteFile(filepath.Join(a, "name"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(b, "name"), []byte("b"), 0644)
+
+	err := exchange(a, b)
+	if err == errExchangeUnsupported {
+		t.Skip("当前系统不支持 RENAME_EXCHANGE")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(a, "name")); string(data) != "b" {
+		t.Errorf("交换后 a 中的内容为 %q，应为 b", data)
+	}
+	if data, _ := os.ReadFile(filepath.Join(b, "name")); string(data) != "a" {
+		t.Errorf("交换后 b 中的内容为 %q，应为 a", data)
+	}
+}
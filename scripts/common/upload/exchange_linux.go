@@ -0,0 +1,16 @@
+//go:build linux
+
+package upload
+
+import "golang.org/x/sys/unix"
+
+// exchange 用 renameat2(RENAME_EXCHANGE) 原子地交换两个路径，两者都必须存在。
+// 内核或文件系统不支持时返回 errExchangeUnsupported
+func exchange(a, b string) error {
+	err := unix.Renameat2(unix.AT_FDCWD, a, unix.AT_FDCWD, b, unix.RENAME_EXCHANGE)
+	switch err {
+	case unix.ENOSYS, unix.EINVAL:
+		return errExchangeUnsupported
+	}
+	return err
+}
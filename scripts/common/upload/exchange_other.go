@@ -0,0 +1,8 @@
+//go:build !linux
+
+package upload
+
+// exchange 在 Linux 以外的系统上不可用，extractArchive 改用两次改名
+func exchange(a, b string) error {
+	return errExchangeUnsupported
+}
@@ -0,0 +1,144 @@
+// Package upload 是上传可执行文件的公共部分：把临时文件放到上传目录（压缩包解压到子目录）并列出上传目录中的文件。
+// upload 脚本和 JSON API 都通过它保存文件
+package upload
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/i18n"
+)
+
+// Stored 是一次上传的结果
+type Stored struct {
+	Name      string       `json:"name"`
+	Path      string       `json:"path"`      // 文件路径，压缩包为解压后的目录
+	Extracted bool         `json:"extracted"` // 是否为解压后的压缩包
+	Files     int          `json:"files,omitempty"`
+	Warning   i18n.Message `json:"warning,omitzero"` // 文件已保存，但无法设置可执行权限等
+}
+
+// SafeName 返回上传文件在目标目录中的文件名，拒绝空文件名和 "."、".."
+func SafeName(name string) (string, error) {
+	safe := filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	if strings.TrimSpace(name) == "" || safe == "." || safe == ".." || safe == "/" {
+		return "", i18n.Errorf("无效的文件名 %q", name)
+	}
+	return safe, nil
+}
+
+// Store 把 srcPath 处的临时文件放到 destDir：压缩包解压到与压缩包同名的子目录，其他文件移动并赋予可执行权限
+func Store(srcPath, name, destDir string, extract bool) (Stored, error) {
+	// 确保目标目录存在
+	if err := os.MkdirAll(destDir, 0755); err != nil { // 0755 权限：所有者读写执行，组和其他用户读和执行
+		return Stored{}, i18n.Errorf("无法创建目标目录 %s: %v", destDir, err)
+	}
+	safeFilename, err := SafeName(name)
+	if err != nil {
+		return Stored{}, err
+	}
+
+	// 压缩包：解压到 destDir 下与压缩包同名的子目录，整体替换旧目录
+	if extract && archiveSuffix(safeFilename) != "" {
+		extractedDir, count, err := extractArchive(srcPath, safeFilename, destDir)
+		os.Remove(srcPath) // 压缩包本身不保留
+		if err != nil {
+			return Stored{}, i18n.Errorf("无法解压 '%s': %v", safeFilename, err)
+		}
+		return Stored{Name: safeFilename, Path: extractedDir, Extracted: true, Files: count}, nil
+	}
+
+	destFilePath := filepath.Join(destDir, safeFilename)
+	if err := move(srcPath, destFilePath); err != nil {
+		return Stored{}, err
+	}
+	stored := Stored{Name: safeFilename, Path: destFilePath}
+
+	// 设置文件权限为 0755 (-rwxr-xr-x)，权限设置失败不影响上传结果，只给出警告
+	if err := os.Chmod(destFilePath, 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Could not set executable permissions on '%s': %v\n", destFilePath, err)
+		stored.Warning = i18n.M("无法设置可执行权限：%v", err)
+	}
+	return stored, nil
+}
+
+// move 移动 webhook 创建的临时文件到目标位置，跨设备时改为复制
+func move(srcPath, destFilePath string) error {
+	err := os.Rename(srcPath, destFilePath)
+	if err == nil {
+		return nil
+	}
+	if linkErr, ok := err.(*os.LinkError); !ok || linkErr.Op != "rename" {
+		return i18n.Errorf("无法移动文件: %v", err)
+	}
+	// Cross-device link, need to copy
+	srcFile, err := os.Open(srcPath)
+	if err != nil {
+		return i18n.Errorf("无法打开上传的临时文件 %s: %v", srcPath, err)
+	}
+	defer srcFile.Close()
+
+	dstFile, err := os.Create(destFilePath)
+	if err != nil {
+		return i18n.Errorf("无法创建目标文件 %s: %v", destFilePath, err)
+	}
+	defer dstFile.Close()
+
+	if _, err := io.Copy(dstFile, srcFile); err != nil {
+		return i18n.Errorf("无法复制文件内容: %v", err)
+	}
+	if err := dstFile.Sync(); err != nil {
+		return i18n.Errorf("无法同步文件到磁盘: %v", err)
+	}
+	os.Remove(srcPath) // Remove temp file after successful copy
+	return nil
+}
+
+// File 是上传目录中的一个文件或目录
+type File struct {
+	Name          string   `json:"name"`
+	Path          string   `json:"path"`
+	IsDir         bool     `json:"is_dir"`
+	Size          int64    `json:"size"`
+	NotExecutable bool     `json:"not_executable,omitempty"` // 普通文件没有可执行权限
+	UsedBy        []string `json:"used_by,omitempty"`        // execute-command 指向该文件的 hook
+}
+
+// List 列出 destDir 中的文件（忽略解压时的临时目录等隐藏文件），目录在前，按名称排序
+func List(destDir string, hooks config.Config) ([]File, error) {
+	entries, err := os.ReadDir(destDir)
+	if err != nil {
+		return nil, err
+	}
+	files := []File{}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		file := File{
+			Name:  entry.Name(),
+			Path:  filepath.Join(destDir, entry.Name()),
+			IsDir: entry.IsDir(),
+		}
+		if fi, err := entry.Info(); err == nil && fi.Mode().IsRegular() {
+			file.Size = fi.Size()
+			file.NotExecutable = fi.Mode().Perm()&0111 == 0
+		}
+		for _, h := range hooks.HooksReferencing(file.Path) {
+			file.UsedBy = append(file.UsedBy, h.ID)
+		}
+		files = append(files, file)
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if files[i].IsDir != files[j].IsDir {
+			return files[i].IsDir
+		}
+		return files[i].Name < files[j].Name
+	})
+	return files, nil
+}
@@ -0,0 +1,47 @@
+package upload
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"webhook-ui/common/config"
+)
+
+// TestList 检查上传目录的列表忽略上传和解压时的临时文件，目录在前，并标出没有可执行权限的文件和引用文件的 hook
+func TestList(t *testing.T) {
+	dir := t.TempDir()
+	for name, mode := range map[string]os.FileMode{
+		"deploy.sh":          0o755,
+		"notes.txt":          0o644,
+		".upload-123":        0o600,
+		".tools.extract-456": 0o755,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("x"), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "tools"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	hooks := config.Config{{ID: "deploy", ExecuteCommand: filepath.Join(dir, "deploy.sh")}}
+
+	files, err := List(dir, hooks)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name)
+	}
+	if want := []string{"tools", "deploy.sh", "notes.txt"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("列出了 %v，应为 %v", names, want)
+	}
+	if !files[0].IsDir || files[1].NotExecutable || !files[2].NotExecutable {
+		t.Errorf("目录或可执行权限不正确: %+v", files)
+	}
+	if !reflect.DeepEqual(files[1].UsedBy, []string{"deploy"}) || files[1].Size != 1 {
+		t.Errorf("deploy.sh 为 %+v", files[1])
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/env"
+	"webhook-ui/common/i18n"
+	"webhook-ui/common/pages"
+)
+
+func main() {
+	// 定义命令行参数
+	homeUrl := flag.String("home", "/ui", "Home URL for the Webhook")
+	editUrl := flag.String("edit", "/edit_form", "URL for the edit configuration form")
+	hookUrl := flag.String("hook", "/hook-", "URL prefix for single hook operations")
+	detailUrl := flag.String("detail", "/detail", "URL for this detail page")
+
+	flag.Parse()
+
+	prefix := env.Str("URL_PREFIX", "hooks")
+	if prefix != "" {
+		prefix = "/" + prefix
+	}
+	site := pages.Site{
+		Load:      func() ([]config.Source, error) { return config.LoadAll(config.HooksSpec()) },
+		HomeURL:   prefix + *homeUrl,
+		EditURL:   prefix + *editUrl,
+		HookURL:   prefix + *hookUrl,
+		DetailURL: prefix + *detailUrl,
+		URLPrefix: prefix,
+		// 页面语言：语言切换保存的 cookie 优先，其次是浏览器的 Accept-Language
+		Lang: i18n.Negotiate(os.Getenv("COOKIE"), os.Getenv("ACCEPT_LANGUAGE")),
+	}
+
+	// ?id= 和 ?hash= 由 webhook 通过环境变量 HOOK_ID 和 HOOK_HASH 传入。PUBLIC_URL 是客户端访问 webhook 的地址，
+	// 用于调用示例，未设置时页面按浏览器中的地址补全
+	err := site.Detail(os.Stdout, pages.DetailRequest{
+		ID:        os.Getenv("HOOK_ID"),
+		PublicURL: os.Getenv("PUBLIC_URL"),
+		Hash:      os.Getenv("HOOK_HASH") != "",
+	})
+	if err != nil {
+		os.Exit(1)
+	}
+}
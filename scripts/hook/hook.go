@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	"webhook-ui/common/adminhooks"
+	"webhook-ui/common/config"
+	"webhook-ui/common/env"
+	"webhook-ui/common/i18n"
+	"webhook-ui/common/pages"
+	"webhook-ui/common/reload"
+)
+
+func main() {
+	var homeUrl, hookUrl, action string
+	flag.StringVar(&homeUrl, "home", "/ui", "Home URL for the Webhook")
+	flag.StringVar(&hookUrl, "hook", "/hook-", "URL prefix for single hook operations, the admin hook confirmation form is submitted to <prefix><action>")
+	flag.StringVar(&action, "action", "", "Operation on a single hook: create, update, delete, duplicate, move, disable or enable")
+	flag.Parse()
+	// 本脚本由 webhook 执行，signal 方式重载时父进程就是 webhook
+	reload.ParentIsWebhook = true
+
+	prefix := env.Str("URL_PREFIX", "hooks")
+	if prefix != "" {
+		prefix = "/" + prefix
+	}
+	site := pages.Site{
+		Load:      func() ([]config.Source, error) { return config.LoadAll(config.HooksSpec()) },
+		HomeURL:   prefix + homeUrl,
+		HookURL:   prefix + hookUrl,
+		URLPrefix: prefix,
+		// 页面语言：语言切换保存的 cookie 优先，其次是浏览器的 Accept-Language
+		Lang: i18n.Negotiate(os.Getenv("COOKIE"), os.Getenv("ACCEPT_LANGUAGE")),
+	}
+
+	// X-Forwarded-User 可以由客户端任意填写，只采用可信反向代理转发的请求中的值
+	user := os.Getenv("HOOK_USER")
+	if !pages.TrustedProxy(os.Getenv("HOOK_REMOTE_ADDR")) {
+		user = ""
+	}
+
+	// 表单字段和请求头由 webhook 通过环境变量传入，见 README
+	ok := site.Hook(os.Stdout, pages.HookRequest{
+		Action:   action,
+		File:     os.Getenv("HOOKS_FILE"),
+		ID:       os.Getenv("HOOK_ID"),
+		Content:  os.Getenv("HOOK_CONTENT"),
+		Revision: os.Getenv("HOOK_REVISION"),
+		NewID:    os.Getenv("HOOK_NEW_ID"),
+		Position: os.Getenv("HOOK_POSITION"),
+		Reason:   os.Getenv("HOOK_REASON"),
+		By:       pages.Operator(user, os.Getenv("HOOK_OPERATOR"), os.Getenv("HOOK_REMOTE_ADDR")),
+
+		AllowAdminChanges: adminhooks.Confirmed(os.Getenv("ALLOW_ADMIN_CHANGES")),
+	})
+	if !ok {
+		os.Exit(1)
+	}
+}
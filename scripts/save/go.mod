@@ -2,4 +2,11 @@ module webhook-ui/save
 
 go 1.24.4
 
-require gopkg.in/yaml.v2 v2.4.0
+require webhook-ui/common v0.0.0
+
+require (
+	golang.org/x/sys v0.30.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+)
+
+replace webhook-ui/common => ../common
@@ -0,0 +1,453 @@
+// server 是独立运行的管理服务：在一个进程中提供 ui、edit_form、save、hook-*、upload_form 和上传接口，
+// 代替 webhook 为每个请求执行一次的脚本。页面与脚本共用 webhook-ui/common/pages 中的实现，
+// 所有请求共用一个 hooks 文件缓存（config.Cache）和一个分块上传会话存储（pages.Chunks）。
+// 使用 -proxy 时放在 webhook 前面，其他 hook 请求转发给 webhook 并写入执行记录（见 proxy.go）
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"webhook-ui/common/adminhooks"
+	"webhook-ui/common/api"
+	"webhook-ui/common/assets"
+	"webhook-ui/common/config"
+	"webhook-ui/common/env"
+	"webhook-ui/common/history"
+	"webhook-ui/common/i18n"
+	"webhook-ui/common/pages"
+	"webhook-ui/common/reload"
+)
+
+// maxChunkRequest 是分块上传单个请求的大小上限，块内容经过 base64 编码，比块大小多约三分之一
+const maxChunkRequest = 64 << 20 // 64 MiB
+
+// server 保存所有请求共用的状态
+type server struct {
+	site    pages.Site
+	cache   *config.Cache
+	chunks  *pages.Chunks
+	extract bool
+
+	admin    *http.ServeMux // 管理页面和 API
+	proxy    *proxy         // 其他 hook 请求转发给 webhook，为 nil 时返回 404
+	user     string         // 管理页面的 Basic 认证，password 为空时不认证
+	password string
+}
+
+// render 先把页面写入缓冲区，再按 page 的结果返回 200 或 500，与 webhook 执行脚本成功或失败时的状态码一致
+func render(w http.ResponseWriter, contentType string, page func(io.Writer) bool) {
+	var buf bytes.Buffer
+	ok := page(&buf)
+	w.Header().Set("Content-Type", contentType)
+	if !ok {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	buf.WriteTo(w)
+}
+
+// formatType 返回上传结果的 Content-Type
+func formatType(format string) string {
+	if format == "json" {
+		return "application/json; charset=utf-8"
+	}
+	return "text/html; charset=utf-8"
+}
+
+const htmlType = "text/html; charset=utf-8"
+
+// language 返回按请求的 cookie 和 Accept-Language 选择的页面语言
+func language(r *http.Request) string {
+	return i18n.Negotiate(r.Header.Get("Cookie"), r.Header.Get("Accept-Language"))
+}
+
+// siteFor 返回按请求的语言输出页面的 Site
+func (s *server) siteFor(r *http.Request) pages.Site {
+	site := s.site
+	site.Lang = language(r)
+	return site
+}
+
+func (s *server) ui(w http.ResponseWriter, r *http.Request) {
+	render(w, htmlType, func(w io.Writer) bool {
+		return s.siteFor(r).UI(w, "Webhook Configuration", pages.ParseOverview(r.URL.Query())) == nil
+	})
+}
+
+func (s *server) detail(w http.ResponseWriter, r *http.Request) {
+	render(w, htmlType, func(w io.Writer) bool {
+		return s.siteFor(r).Detail(w, pages.DetailRequest{ID: r.URL.Query().Get("id"), PublicURL: os.Getenv("PUBLIC_URL"), Hash: r.URL.Query().Get("hash") != ""}) == nil
+	})
+}
+
+func (s *server) editForm(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	render(w, htmlType, func(w io.Writer) bool {
+		return s.siteFor(r).Edit(w, pages.EditRequest{
+			Title:      "Edit Webhook Configuration",
+			File:       query.Get("file"),
+			HookID:     query.Get("id"),
+			Convert:    query.Get("convert"),
+			NewCommand: query.Get("new_command"),
+		}) == nil
+	})
+}
+
+func (s *server) save(w http.ResponseWriter, r *http.Request) {
+	render(w, htmlType, func(w io.Writer) bool {
+		return s.siteFor(r).Save(w, r.PostFormValue("file"), r.PostFormValue("config"), adminhooks.Confirmed(r.PostFormValue("allow_admin_changes")))
+	})
+}
+
+func (s *server) hook(action string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render(w, htmlType, func(w io.Writer) bool {
+			return s.siteFor(r).Hook(w, pages.HookRequest{
+				Action:   action,
+				File:     r.PostFormValue("file"),
+				ID:       r.PostFormValue("id"),
+				Content:  r.PostFormValue("content"),
+				Revision: r.PostFormValue("revision"),
+				NewID:    r.PostFormValue("new_id"),
+				Position: r.PostFormValue("position"),
+				Reason:   r.PostFormValue("reason"),
+				By:       pages.Operator(r.Header.Get("X-Forwarded-User"), r.PostFormValue("user"), r.RemoteAddr),
+
+				AllowAdminChanges: adminhooks.Confirmed(r.PostFormValue("allow_admin_changes")),
+			})
+		})
+	}
+}
+
+func (s *server) uploadForm(w http.ResponseWriter, r *http.Request) {
+	render(w, htmlType, func(w io.Writer) bool {
+		return s.siteFor(r).UploadForm(w, "上传可执行文件") == nil
+	})
+}
+
+// uploadSubmit 处理 base64 上传：{"file_name": ..., "file_content": <base64>}，与 upload-submit hook 相同返回 HTML 页面
+func (s *server) uploadSubmit(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		FileName    string `json:"file_name"`
+		FileContent string `json:"file_content"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, i18n.For(language(r)).Sprintf("无效的请求: %v", err), http.StatusBadRequest)
+		return
+	}
+	render(w, formatType("html"), func(w io.Writer) bool {
+		// 与 webhook 的 pass-file-to-command 一样先解码到临时文件，放在上传目录中，保证移动时不会跨设备
+		var path string
+		if err := os.MkdirAll(s.site.UploadDir, 0755); err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating %s: %v\n", s.site.UploadDir, err)
+		} else if tmp, err := os.CreateTemp(s.site.UploadDir, ".upload-*"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error creating temp file: %v\n", err)
+		} else {
+			_, err := io.Copy(tmp, base64.NewDecoder(base64.StdEncoding, strings.NewReader(payload.FileContent)))
+			if closeErr := tmp.Close(); err == nil {
+				err = closeErr
+			}
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error decoding file_content: %v\n", err)
+			} else {
+				path = tmp.Name()
+			}
+			defer os.Remove(tmp.Name()) // 保存成功时临时文件已被移走
+		}
+		return s.siteFor(r).Upload(w, "html", path, payload.FileName, s.extract)
+	})
+}
+
+// uploadRaw 处理直接上传，请求体是文件内容（文件名在 X-File-Name 请求头中）或 multipart/form-data
+func (s *server) uploadRaw(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	render(w, formatType("html"), func(w io.Writer) bool {
+		return s.siteFor(r).UploadRaw(w, format, r.Body, r.Header.Get("Content-Type"), r.Header.Get("X-File-Name"), s.extract)
+	})
+}
+
+// uploadChunk 处理分块上传的一个动作，字段与上传页面提交的 JSON 相同，chunk 是 base64 编码的块内容
+func (s *server) uploadChunk(w http.ResponseWriter, r *http.Request) {
+	var payload struct {
+		Action      string  `json:"action"`
+		UploadID    string  `json:"upload_id"`
+		FileName    string  `json:"file_name"`
+		TotalSize   string  `json:"total_size"`
+		ChunkSize   string  `json:"chunk_size"`
+		SHA256      string  `json:"sha256"`
+		FileKey     string  `json:"file_key"`
+		Index       string  `json:"index"`
+		ChunkSHA256 string  `json:"chunk_sha256"`
+		Chunk       *string `json:"chunk"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxChunkRequest)).Decode(&payload); err != nil {
+		http.Error(w, i18n.For(language(r)).Sprintf("无效的请求: %v", err), http.StatusBadRequest)
+		return
+	}
+	req := pages.ChunkRequest{
+		Action:      payload.Action,
+		UploadID:    payload.UploadID,
+		FileName:    payload.FileName,
+		TotalSize:   payload.TotalSize,
+		ChunkSize:   payload.ChunkSize,
+		SHA256:      payload.SHA256,
+		FileKey:     payload.FileKey,
+		Index:       payload.Index,
+		ChunkSHA256: payload.ChunkSHA256,
+	}
+	if payload.Chunk != nil {
+		req.Chunk = base64.NewDecoder(base64.StdEncoding, strings.NewReader(*payload.Chunk))
+	}
+	render(w, formatType("json"), func(w io.Writer) bool {
+		return s.siteFor(r).UploadChunk(w, s.chunks, req, s.extract)
+	})
+}
+
+// authorized 检查管理页面的 Basic 认证。认证通过后用认证的用户名覆盖 X-Forwarded-User，作为操作人记录。
+// 未设置密码时只保留可信反向代理（见 pages.TrustedProxy）设置的 X-Forwarded-User，客户端自行传入的被删除
+func (s *server) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if s.password == "" {
+		if !pages.TrustedProxy(r.RemoteAddr) {
+			r.Header.Del("X-Forwarded-User")
+		}
+		return true
+	}
+	user, password, ok := r.BasicAuth()
+	userHash, passwordHash := sha256.Sum256([]byte(user)), sha256.Sum256([]byte(password))
+	wantUser, wantPassword := sha256.Sum256([]byte(s.user)), sha256.Sum256([]byte(s.password))
+	if !ok || subtle.ConstantTimeCompare(userHash[:], wantUser[:])&subtle.ConstantTimeCompare(passwordHash[:], wantPassword[:]) != 1 {
+		w.Header().Set("WWW-Authenticate", `Basic realm="webhook-ui", charset="UTF-8"`)
+		http.Error(w, i18n.For(language(r)).T("需要登录"), http.StatusUnauthorized)
+		return false
+	}
+	r.Header.Set("X-Forwarded-User", user)
+	return true
+}
+
+// loopback 返回 listen 是否只监听本机回环地址，如 127.0.0.1:8003、[::1]:8003、localhost:8003。
+// 主机部分为空（如 :8003）时监听所有地址
+func loopback(listen string) bool {
+	host, _, err := net.SplitHostPort(listen)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// findHook 返回当前配置中 id 对应的 hook，不存在或无法读取配置时返回 nil
+func (s *server) findHook(id string) *config.Hook {
+	sources, err := s.cache.Load()
+	if err != nil {
+		return nil
+	}
+	return config.Merge(sources).Find(id)
+}
+
+// adminHook 返回 id 是否是管理 hook：adminhooks.IDs 中的 hook，以及 execute-command 位于脚本安装目录下的 hook。
+// 这些 hook 在脚本模式下修改配置、上传文件，转发给 webhook 会绕过本服务的认证
+func (s *server) adminHook(id string) bool {
+	if adminhooks.IsAdmin(id) {
+		return true
+	}
+	sources, err := s.cache.Load()
+	if err != nil {
+		return false
+	}
+	for _, protected := range adminhooks.Protected(config.Merge(sources), adminhooks.ScriptsDir()) {
+		if protected == id {
+			return true
+		}
+	}
+	return false
+}
+
+// sameOrigin 检查修改类请求是否来自本服务的页面，防止其他网站借用浏览器保存的 Basic 认证提交表单（CSRF）。
+// 浏览器跨站提交时一定带有 Origin 或 Sec-Fetch-Site；都没有的请求来自 curl、CI 等非浏览器客户端，不受 CSRF 影响
+func sameOrigin(r *http.Request) bool {
+	if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+		return true
+	}
+	if site := r.Header.Get("Sec-Fetch-Site"); site != "" && site != "same-origin" && site != "none" {
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		origin = r.Header.Get("Referer")
+	}
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	// 放在反向代理之后时，浏览器看到的是代理的地址
+	return u.Host == r.Host || u.Host == r.Header.Get("X-Forwarded-Host")
+}
+
+// ServeHTTP 为每个请求设置请求 ID，管理页面和 API 由 admin 处理（需要认证），其他请求转发给 webhook。
+// 管理 hook 不转发，它们只能通过本服务的管理页面和 API 使用
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := requestID(r)
+	r.Header.Set(requestIDHeader, id)
+	w.Header().Set(requestIDHeader, id)
+
+	if _, pattern := s.admin.Handler(r); pattern == "" && s.proxy != nil {
+		if s.adminHook(s.proxy.hookID(r.URL.Path)) {
+			http.Error(w, i18n.For(language(r)).T("管理 hook 不通过转发调用，请使用本服务的管理页面和 API"), http.StatusForbidden)
+			return
+		}
+		s.proxy.ServeHTTP(w, r)
+		return
+	}
+	if !s.authorized(w, r) {
+		return
+	}
+	if !sameOrigin(r) {
+		http.Error(w, i18n.For(language(r)).T("请求不是来自本服务的页面"), http.StatusForbidden)
+		return
+	}
+	s.admin.ServeHTTP(w, r)
+	// 修改类请求之后丢弃 hooks 文件缓存，下一个请求读到的一定是新内容
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		s.cache.Invalidate()
+	}
+}
+
+func main() {
+	var listen string
+	var extract, proxyHooks, insecure bool
+	flag.StringVar(&listen, "listen", ":8003", "Address to listen on")
+	flag.BoolVar(&insecure, "insecure", false, "Serve the admin pages and API without ADMIN_PASSWORD on a non-loopback address or with -proxy")
+	flag.BoolVar(&extract, "extract", true, "Extract uploaded .tar.gz/.tgz/.zip archives into a sub directory of UPLOAD_DEST_DIR")
+	flag.BoolVar(&proxyHooks, "proxy", false, "Reverse-proxy all other hook requests to webhook (WEBHOOK_URL) and record them in HISTORY_FILE")
+	flag.Parse()
+
+	prefix := env.Str("URL_PREFIX", "hooks")
+	if prefix != "" {
+		prefix = "/" + prefix
+	}
+	hooks := config.HooksSpec()
+	uploadDestDir := env.Str("UPLOAD_DEST_DIR", "/etc/webhook/scripts/upload_destination/")
+	ttl, err := time.ParseDuration(env.Str("UPLOAD_STAGING_TTL", "24h"))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Invalid UPLOAD_STAGING_TTL, using 24h: %v\n", err)
+		ttl = 24 * time.Hour
+	}
+
+	// 独立服务不是由 webhook 启动的，父进程不是 webhook，signal 方式必须指定要通知的进程
+	if err := reload.OptionsFromEnv(hooks).Check(); err != nil {
+		log.Fatalf("Invalid reload configuration: %v", err)
+	}
+
+	cache := config.NewCache(hooks)
+	s := &server{
+		site: pages.Site{
+			Load:           cache.Load,
+			UploadDir:      uploadDestDir,
+			HomeURL:        prefix + "/ui",
+			EditURL:        prefix + "/edit_form",
+			SaveURL:        prefix + "/save",
+			UploadURL:      prefix + "/upload_form",
+			HookURL:        prefix + "/hook-",
+			DetailURL:      prefix + "/detail",
+			HookUpdateURL:  prefix + "/hook-update",
+			UploadChunkURL: prefix + "/upload-chunk",
+			UploadRawURL:   prefix + "/upload-raw",
+			URLPrefix:      prefix,
+		},
+		cache: cache,
+		chunks: &pages.Chunks{
+			StagingDir: env.Str("UPLOAD_STAGING_DIR", filepath.Join(os.TempDir(), "webhook-ui-chunks")),
+			TTL:        ttl,
+		},
+		extract:  extract,
+		admin:    http.NewServeMux(),
+		user:     env.Str("ADMIN_USER", "admin"),
+		password: os.Getenv("ADMIN_PASSWORD"),
+	}
+	// 未设置 ADMIN_PASSWORD 时管理页面和 API 不做认证，任何能访问端口的人都可以修改配置和上传文件。
+	// 只允许不转发 hook 请求且监听回环地址（由带认证的反向代理对外提供），或者用 -insecure 明确接受
+	if s.password == "" {
+		// -proxy 把对外公开的业务 hook 和管理页面放在同一个端口上，监听回环地址也不能代替认证
+		if proxyHooks && !insecure {
+			log.Fatalf("ADMIN_PASSWORD is not set: -proxy serves the admin pages on the same port as the public hooks; set ADMIN_PASSWORD or pass -insecure")
+		}
+		if !insecure && !loopback(listen) {
+			log.Fatalf("ADMIN_PASSWORD is not set: refusing to serve the admin pages without authentication on %s; set ADMIN_PASSWORD, listen on a loopback address or pass -insecure", listen)
+		}
+		log.Printf("Warning: ADMIN_PASSWORD is not set, the admin pages and API on %s are not authenticated", listen)
+	}
+
+	// 执行记录放在第一个 hooks 文件旁（见 history.Path），HISTORY_FILE 为空时不记录
+	var historyFile string
+	if paths, err := config.ResolvePaths(hooks); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Cannot resolve hooks files, history disabled: %v\n", err)
+	} else {
+		historyFile = history.Path(paths[0])
+	}
+	if proxyHooks {
+		target, err := url.Parse(reload.ProberFromEnv().BaseURL)
+		if err != nil {
+			log.Fatalf("Invalid WEBHOOK_URL: %v", err)
+		}
+		var hist *history.Log
+		if historyFile != "" {
+			hist = &history.Log{Path: historyFile}
+		}
+		// HISTORY_BODIES=true 时同时记录请求体，请求体中可能有令牌等凭据，默认不记录
+		bodies, _ := strconv.ParseBool(os.Getenv("HISTORY_BODIES"))
+		s.proxy = newProxy(prefix, target, hist, bodies, s.findHook)
+		log.Printf("Proxying hook requests to %s, history in %q (request bodies: %v)", target.Redacted(), historyFile, bodies)
+	}
+
+	// 路径与 config/hooks.yaml 中的 hook 相同，页面中的链接不需要修改
+	mux := s.admin
+	mux.HandleFunc("GET "+prefix+"/ui", s.ui)
+	mux.Handle("GET "+prefix+"/assets", assets.Handler())
+	mux.HandleFunc("GET "+prefix+"/edit_form", s.editForm)
+	mux.HandleFunc("GET "+prefix+"/detail", s.detail)
+	mux.HandleFunc("POST "+prefix+"/save", s.save)
+	for _, action := range pages.HookActions() {
+		mux.HandleFunc("POST "+prefix+"/hook-"+action, s.hook(action))
+	}
+	mux.HandleFunc("GET "+prefix+"/upload_form", s.uploadForm)
+	mux.HandleFunc("POST "+prefix+"/upload-submit", s.uploadSubmit)
+	mux.HandleFunc("POST "+prefix+"/upload-raw", s.uploadRaw)
+	mux.HandleFunc("POST "+prefix+"/upload-chunk", s.uploadChunk)
+	mux.Handle(prefix+"/api/", http.StripPrefix(prefix, api.Handler(api.Service{
+		Hooks:       hooks,
+		UploadDir:   uploadDestDir,
+		Load:        cache.Load,
+		HistoryFile: historyFile,
+	})))
+	mux.Handle("GET /{$}", http.RedirectHandler(prefix+"/ui", http.StatusFound))
+
+	log.Printf("webhook-ui server listening on %s, pages under %s/", listen, prefix)
+	srv := &http.Server{
+		Addr:    listen,
+		Handler: s,
+		// 不限制整个请求的读取时间（上传可能很慢），只限制请求头，避免慢速连接长期占用
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	log.Fatal(srv.ListenAndServe())
+}
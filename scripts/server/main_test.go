@@ -0,0 +1,57 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestLoopback(t *testing.T) {
+	cases := []struct {
+		listen string
+		want   bool
+	}{
+		{"127.0.0.1:8003", true},
+		{"127.0.0.2:8003", true},
+		{"[::1]:8003", true},
+		{"localhost:8003", true},
+		{":8003", false},
+		{"0.0.0.0:8003", false},
+		{"[::]:8003", false},
+		{"192.168.1.10:8003", false},
+		{"example.com:8003", false},
+		{"8003", false},
+	}
+	for _, tc := range cases {
+		if got := loopback(tc.listen); got != tc.want {
+			t.Errorf("loopback(%q) = %v，期望 %v", tc.listen, got, tc.want)
+		}
+	}
+}
+
+// TestAuthorizedForwardedUser 检查操作人只来自认证的用户名或可信代理设置的 X-Forwarded-User
+func TestAuthorizedForwardedUser(t *testing.T) {
+	t.Setenv("TRUSTED_PROXIES", "10.0.0.1")
+	cases := []struct {
+		password, remoteAddr, basicUser, want string
+	}{
+		{"", "192.168.1.5:4000", "", ""},
+		{"", "10.0.0.1:4000", "", "mallory"},
+		{"secret", "192.168.1.5:4000", "admin", "admin"},
+		{"secret", "10.0.0.1:4000", "admin", "admin"},
+	}
+	for _, tc := range cases {
+		s := &server{user: "admin", password: tc.password}
+		r := httptest.NewRequest("POST", "/hooks/hook-disable", nil)
+		r.RemoteAddr = tc.remoteAddr
+		r.Header.Set("X-Forwarded-User", "mallory")
+		if tc.basicUser != "" {
+			r.SetBasicAuth(tc.basicUser, tc.password)
+		}
+		if !s.authorized(httptest.NewRecorder(), r) {
+			t.Fatalf("%+v: 认证失败", tc)
+		}
+		if got := r.Header.Get("X-Forwarded-User"); got != tc.want {
+			t.Errorf("%+v: X-Forwarded-User = %q，期望 %q", tc, got, tc.want)
+		}
+	}
+}
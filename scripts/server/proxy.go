@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/history"
+	"webhook-ui/common/i18n"
+)
+
+// requestIDHeader 是请求 ID 的请求头和响应头，转发给 webhook 时一并带上
+const requestIDHeader = "X-Request-Id"
+
+// requestID 返回请求中已有的请求 ID（通常由更外层的代理设置），没有或格式不合适时生成一个新的
+func requestID(r *http.Request) string {
+	id := r.Header.Get(requestIDHeader)
+	if id != "" && len(id) <= 128 && !strings.ContainsFunc(id, func(c rune) bool { return c <= ' ' || c > '~' }) {
+		return id
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// capture 保存经过的前 history.BodyLimit 字节
+type capture struct {
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (c *capture) keep(p []byte) {
+	if room := history.BodyLimit - c.buf.Len(); len(p) > room {
+		p, c.truncated = p[:room], true
+	}
+	c.buf.Write(p)
+}
+
+// captureReader 在读取请求体的同时保存其开头部分
+type captureReader struct {
+	io.ReadCloser
+	capture
+}
+
+func (c *captureReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.keep(p[:n])
+	return n, err
+}
+
+// captureWriter 记录响应的状态码和开头部分
+type captureWriter struct {
+	http.ResponseWriter
+	status int
+	body   capture
+}
+
+func (w *captureWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *captureWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	w.body.keep(p)
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap 供 http.ResponseController 使用，webhook 流式输出命令结果时需要 Flush
+func (w *captureWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// entryKey 是请求 context 中当前记录的键，ErrorHandler 通过它写入转发失败的原因
+type entryKey struct{}
+
+// proxy 把 <prefix>/<hook id> 转发给 webhook，并把请求和响应写入执行记录
+type proxy struct {
+	prefix  string
+	target  *url.URL     // webhook 的地址，已包含 webhook 的 URL 前缀，如 http://127.0.0.1:9000/hooks
+	history *history.Log // 为 nil 时不记录
+	bodies  bool         // 是否记录请求体。请求体中可能有令牌等凭据，默认不记录
+	// lookup 返回 id 对应的 hook，用于隐去触发规则比较的请求头和查询参数（见 history.SecretsOf），不存在时返回 nil
+	lookup func(id string) *config.Hook
+	rp     *httputil.ReverseProxy
+}
+
+func newProxy(prefix string, target *url.URL, hist *history.Log, bodies bool, lookup func(id string) *config.Hook) *proxy {
+	p := &proxy{prefix: prefix, target: target, history: hist, bodies: bodies, lookup: lookup}
+	p.rp = &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.Out.URL.Scheme = target.Scheme
+			r.Out.URL.Host = target.Host
+			r.Out.URL.Path = target.Path + strings.TrimPrefix(r.In.URL.Path, prefix)
+			r.Out.URL.RawPath = ""
+			r.Out.Host = ""
+			r.SetXForwarded()
+		},
+		FlushInterval: -1, // 立即转发，支持 stream-command-output
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if entry, ok := r.Context().Value(entryKey{}).(*history.Entry); ok {
+				entry.Error = err.Error()
+			}
+			fmt.Fprintf(os.Stderr, "Error proxying %s to webhook: %v\n", r.URL.Path, err)
+			http.Error(w, i18n.For(language(r)).T("webhook 不可用"), http.StatusBadGateway)
+		},
+	}
+	return p
+}
+
+// hookID 返回请求路径中的 hook id，不是 <prefix>/<id> 形式时返回空字符串
+func (p *proxy) hookID(path string) string {
+	rest, ok := strings.CutPrefix(path, p.prefix+"/")
+	if !ok {
+		return ""
+	}
+	id, _, _ := strings.Cut(rest, "/")
+	return id
+}
+
+func (p *proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id := p.hookID(r.URL.Path)
+	if id == "" {
+		http.NotFound(w, r)
+		return
+	}
+	entry := &history.Entry{
+		RequestID:  r.Header.Get(requestIDHeader),
+		Time:       time.Now(),
+		Hook:       id,
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Query:      r.URL.RawQuery,
+		RemoteAddr: r.RemoteAddr,
+		Headers:    history.Headers(r.Header),
+	}
+	body := &captureReader{ReadCloser: r.Body}
+	if p.bodies && r.Body != nil && r.Body != http.NoBody {
+		r.Body = body
+	}
+	cw := &captureWriter{ResponseWriter: w}
+	p.rp.ServeHTTP(cw, r.WithContext(context.WithValue(r.Context(), entryKey{}, entry)))
+
+	entry.Body = body.buf.String()
+	entry.Status = cw.status
+	entry.Response = cw.body.buf.String()
+	entry.Truncated = body.truncated || cw.body.truncated
+	entry.DurationMS = time.Since(entry.Time).Milliseconds()
+	log.Printf("%s %s %s -> %d (%dms)", entry.RequestID, r.Method, r.URL.Path, entry.Status, entry.DurationMS)
+	if p.history != nil {
+		if hook := p.lookup(id); hook != nil {
+			entry.Redact(history.SecretsOf(*hook))
+		}
+		if err := p.history.Append(*entry); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing history %s: %v\n", p.history.Path, err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"webhook-ui/common/config"
+	"webhook-ui/common/history"
+)
+
+func TestProxyRecordsRedactedHistory(t *testing.T) {
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// webhook 收到的是原始请求，隐去只影响记录
+		if r.URL.Path != "/hooks/deploy" || r.Header.Get("X-Token") != "s3cret" || r.URL.Query().Get("token") != "s3cret" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte("deployed"))
+	}))
+	defer webhook.Close()
+
+	target, _ := url.Parse(webhook.URL + "/hooks")
+	hist := &history.Log{Path: filepath.Join(t.TempDir(), "history.jsonl")}
+	hook := &config.Hook{ID: "deploy", TriggerRule: &config.Rules{And: &config.AndRule{
+		{Match: &config.MatchRule{Type: "value", Value: "s3cret", Parameter: config.Argument{Source: "header", Name: "X-Token"}}},
+		{Match: &config.MatchRule{Type: "value", Value: "s3cret", Parameter: config.Argument{Source: "url", Name: "token"}}},
+	}}}
+	p := newProxy("/hooks", target, hist, false, func(id string) *config.Hook {
+		if id == "deploy" {
+			return hook
+		}
+		return nil
+	})
+
+	req := httptest.NewRequest("POST", "/hooks/deploy?token=s3cret&env=prod", strings.NewReader("{}"))
+	req.Header.Set("X-Token", "s3cret")
+	rec := httptest.NewRecorder()
+	p.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "deployed" {
+		t.Fatalf("转发结果为 %d %s", rec.Code, rec.Body.String())
+	}
+
+	entries, err := history.Read(hist.Path, "deploy", 0)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("执行记录为 %+v, %v", entries, err)
+	}
+	e := entries[0]
+	if e.Headers["X-Token"] != history.Redacted || e.Query != "token=%5BREDACTED%5D&env=prod" {
+		t.Errorf("记录中的共享密钥没有隐去: headers=%v query=%s", e.Headers, e.Query)
+	}
+	if e.Status != http.StatusOK || e.Response != "deployed" || e.Body != "" {
+		t.Errorf("记录为 %+v", e)
+	}
+}
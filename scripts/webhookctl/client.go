@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"webhook-ui/common/api"
+)
+
+// client 调用 JSON API：本地模式在进程内直接调用 api.Handler，远程模式通过 HTTP 调用运行中的实例
+type client struct {
+	service *api.Service // 本地模式
+	baseURL string       // 远程模式：webhook 的 hooks 地址，如 http://ip:8002/hooks
+	http    *http.Client
+}
+
+// webhookRoutes 是 API 在 webhook 中对应的 hook，见 config/hooks.yaml
+var webhookRoutes = map[string]string{
+	"GET /api/hooks":                "api-hooks",
+	"GET /api/hooks/{id}":           "api-hook",
+	"POST /api/config/validate":     "api-validate",
+	"GET /api/config":               "api-config",
+	"PUT /api/config":               "api-save",
+	"GET /api/config/versions":      "api-versions",
+	"POST /api/config/rollback":     "api-rollback",
+	"POST /api/admin-hooks/restore": "api-restore",
+	"GET /api/uploads":              "api-uploads",
+	"POST /api/uploads":             "api-upload",
+	"GET /api/openapi.yaml":         "api-openapi",
+}
+
+func newLocalClient(service api.Service) *client {
+	return &client{service: &service}
+}
+
+func newRemoteClient(baseURL string) *client {
+	// 保存后要等待重载和健康检查，超时时间留得宽一些
+	return &client{baseURL: strings.TrimRight(baseURL, "/"), http: &http.Client{Timeout: 5 * time.Minute}}
+}
+
+// call 调用 route（如 "GET /api/hooks/{id}"）对应的接口，把结果解码到 out。
+// 接口返回错误时返回 *api.Error，out 仍会被解码（保存失败时响应体中同时有保存结果）
+func (c *client) call(route, id string, query url.Values, header http.Header, body io.Reader, out interface{}) error {
+	method, path, _ := strings.Cut(route, " ")
+	if query == nil {
+		query = url.Values{}
+	}
+	if body == nil {
+		body = http.NoBody
+	}
+
+	var data []byte
+	if c.service != nil {
+		req, err := http.NewRequest(method, (&url.URL{Path: strings.ReplaceAll(path, "{id}", url.PathEscape(id)), RawQuery: query.Encode()}).String(), body)
+		if err != nil {
+			return err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		var w api.Recorder
+		api.Handler(*c.service).ServeHTTP(&w, req)
+		data = w.Body.Bytes()
+	} else {
+		hook, ok := webhookRoutes[route]
+		if !ok {
+			return fmt.Errorf("没有 %s 接口", route)
+		}
+		if strings.Contains(path, "{id}") {
+			query.Set("id", id)
+		}
+		target, err := url.Parse(c.baseURL + "/" + hook)
+		if err != nil {
+			return err
+		}
+		target.RawQuery = query.Encode()
+		req, err := http.NewRequest(method, target.String(), body)
+		if err != nil {
+			return err
+		}
+		for k, v := range header {
+			req.Header[k] = v
+		}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return fmt.Errorf("%s %s: %v", method, target.Redacted(), errors.Unwrap(err))
+		}
+		defer resp.Body.Close()
+		if data, err = io.ReadAll(resp.Body); err != nil {
+			return err
+		}
+		// 响应不是 API 的 JSON：webhook 找不到 hook、方法不允许，或前面的代理拒绝了请求
+		if resp.StatusCode >= http.StatusBadRequest && !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+			if resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusMethodNotAllowed {
+				return fmt.Errorf("%s 返回 %s，请确认 hooks.yaml 中配置了 %s hook", target.Redacted(), resp.Status, hook)
+			}
+			if text := bytes.TrimSpace(data); len(text) > 0 {
+				return fmt.Errorf("%s 返回 %s: %s", target.Redacted(), resp.Status, text)
+			}
+			return fmt.Errorf("%s 返回 %s", target.Redacted(), resp.Status)
+		}
+	}
+
+	var failure api.ErrorBody
+	if err := json.Unmarshal(data, &failure); err == nil && failure.Error != nil {
+		if out != nil {
+			json.Unmarshal(data, out)
+		}
+		return failure.Error
+	}
+	if out == nil {
+		return nil
+	}
+	if raw, ok := out.(*[]byte); ok {
+		*raw = data
+		return nil
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("无法解析响应: %v\n%s", err, data)
+	}
+	return nil
+}
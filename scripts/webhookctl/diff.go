@@ -0,0 +1,152 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext 是统一格式差异中每段前后保留的行数
+const diffContext = 3
+
+// splitLines 按行切分，保留最后一行没有换行符的情况
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// edit 是一行的比较结果：' ' 相同，'-' 只在旧内容中，'+' 只在新内容中
+type edit struct {
+	op   byte
+	line string
+}
+
+// diffLines 用最长公共子序列比较两组行。hooks 文件通常只有几百行，O(n*m) 足够
+func diffLines(a, b []string) []edit {
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+	var edits []edit
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch {
+		case a[i] == b[j]:
+			edits = append(edits, edit{' ', a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			edits = append(edits, edit{'-', a[i]})
+			i++
+		default:
+			edits = append(edits, edit{'+', b[j]})
+			j++
+		}
+	}
+	for ; i < len(a); i++ {
+		edits = append(edits, edit{'-', a[i]})
+	}
+	for ; j < len(b); j++ {
+		edits = append(edits, edit{'+', b[j]})
+	}
+	return edits
+}
+
+// unifiedDiff 返回 old 到 new 的统一格式差异，内容相同时返回空字符串
+func unifiedDiff(oldName, newName, old, new string) string {
+	edits := diffLines(splitLines(old), splitLines(new))
+	var b strings.Builder
+	for start := 0; start < len(edits); {
+		// 找到下一处改动，连同前后 diffContext 行组成一段，相距较近的改动合并到同一段
+		first := start
+		for first < len(edits) && edits[first].op == ' ' {
+			first++
+		}
+		if first == len(edits) {
+			break
+		}
+		from := max(first-diffContext, start)
+		to := first
+		for last := first; to < len(edits); to++ {
+			if edits[to].op != ' ' {
+				last = to
+			} else if to-last > 2*diffContext {
+				break
+			}
+		}
+		to = min(to, len(edits))
+		for to > from && edits[to-1].op == ' ' && trailingContext(edits[from:to]) > diffContext {
+			to--
+		}
+
+		if b.Len() == 0 {
+			fmt.Fprintf(&b, "--- %s\n+++ %s\n", oldName, newName)
+		}
+		oldStart, newStart := position(edits[:from])
+		oldCount, newCount := count(edits[from:to])
+		fmt.Fprintf(&b, "@@ -%s +%s @@\n", hunkRange(oldStart, oldCount), hunkRange(newStart, newCount))
+		for _, e := range edits[from:to] {
+			b.WriteByte(e.op)
+			b.WriteString(e.line)
+			if !strings.HasSuffix(e.line, "\n") {
+				b.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		start = to
+	}
+	return b.String()
+}
+
+// trailingContext 返回末尾连续相同的行数
+func trailingContext(edits []edit) int {
+	n := 0
+	for i := len(edits) - 1; i >= 0 && edits[i].op == ' '; i-- {
+		n++
+	}
+	return n
+}
+
+// position 返回 edits 之后在旧、新内容中的行号（从 1 开始）
+func position(edits []edit) (int, int) {
+	oldLines, newLines := count(edits)
+	return oldLines + 1, newLines + 1
+}
+
+// count 返回 edits 覆盖的旧、新内容行数
+func count(edits []edit) (int, int) {
+	oldLines, newLines := 0, 0
+	for _, e := range edits {
+		if e.op != '+' {
+			oldLines++
+		}
+		if e.op != '-' {
+			newLines++
+		}
+	}
+	return oldLines, newLines
+}
+
+// hunkRange 格式化段头中的行范围，空范围的起始行按惯例减一
+func hunkRange(start, n int) string {
+	if n == 0 {
+		start--
+	}
+	if n == 1 {
+		return fmt.Sprint(start)
+	}
+	return fmt.Sprintf("%d,%d", start, n)
+}
@@ -0,0 +1,559 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"webhook-ui/common/adminhooks"
+	"webhook-ui/common/api"
+	"webhook-ui/common/config"
+	"webhook-ui/common/env"
+	"webhook-ui/common/pipeline"
+	"webhook-ui/common/simulate"
+	"webhook-ui/common/upload"
+)
+
+// 退出码：0 成功；1 校验未通过、有差异、未生效或规则不匹配；2 调用出错
+const (
+	exitFailed = 1
+	exitError  = 2
+)
+
+const usage = `Usage: webhookctl [-url URL | -hooks FILE] [-upload-dir DIR] <command> [args]
+
+Commands:
+  list [-json]                       List hooks
+  show <id> [-json]                  Show one hook (including disabled ones)
+  validate <file> [-file target]     Validate file without writing it
+  diff <file> [-file target]         Compare file with the current config
+  apply <file> [-file target]        Save file as the new config, reload and check that it took effect
+  upload <path> [-name n] [-extract] Upload a file to the upload directory
+  rollback [version] [-file target]  List the saved versions, or restore the given version
+  simulate <id> -payload <json|@file> [-method M] [-header K:V] [-query k=v] [-remote-addr ip:port]
+                                     Simulate how the hook handles a request, without running the command
+  admin-hooks [-scripts-dir DIR] [-prefix P] [-json]
+                                     Print the hooks used by the admin pages themselves, without contacting webhook
+  restore-admin-hooks [-file target] Restore removed or broken admin hooks from the recovery file
+
+-file is the target hooks file, the first hooks file by default.
+validate, apply and rollback refuse to remove or change admin hooks unless -allow-admin-changes is given.
+
+Global flags:
+`
+
+// fatal 打印错误并以 exitError 退出
+func fatal(format string, a ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", a...)
+	os.Exit(exitError)
+}
+
+// check 处理 API 调用的错误：API 返回的错误以 exitFailed 退出，其他错误（网络等）以 exitError 退出
+func check(err error) {
+	if err == nil {
+		return
+	}
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) {
+		fmt.Fprintf(os.Stderr, "%s (%d %s)\n", apiErr.Error(), apiErr.Status, apiErr.Code)
+		os.Exit(exitFailed)
+	}
+	fatal("%v", err)
+}
+
+// parseArgs 解析子命令的参数，参数和选项可以交替出现，返回位置参数
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// listFlag 是可以重复指定的选项
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ", ") }
+
+func (l *listFlag) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// fileQuery 返回指定目标 hooks 文件的查询参数
+func fileQuery(file string) url.Values {
+	query := url.Values{}
+	if file != "" {
+		query.Set("file", file)
+	}
+	return query
+}
+
+// saveQuery 在 fileQuery 的基础上带上是否允许修改管理 hook
+func saveQuery(file string, allowAdminChanges bool) url.Values {
+	query := fileQuery(file)
+	if allowAdminChanges {
+		query.Set("allow_admin_changes", adminhooks.Confirmation)
+	}
+	return query
+}
+
+func main() {
+	service := api.ServiceFromEnv()
+	var baseURL string
+	flag.StringVar(&baseURL, "url", env.Str("WEBHOOKCTL_URL", ""), "Hooks URL of a running webhook, e.g. http://user:pass@ip:8002/hooks; empty to use local files")
+	flag.StringVar(&service.Hooks, "hooks", service.Hooks, "Local hooks files, same syntax as HOOKS")
+	flag.StringVar(&service.UploadDir, "upload-dir", service.UploadDir, "Local upload directory")
+	flag.Usage = func() {
+		fmt.Fprint(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(exitError)
+	}
+
+	c := newLocalClient(service)
+	if baseURL != "" {
+		c = newRemoteClient(baseURL)
+	}
+
+	commands := map[string]func(*client, []string){
+		"list":                cmdList,
+		"show":                cmdShow,
+		"validate":            cmdValidate,
+		"diff":                cmdDiff,
+		"apply":               cmdApply,
+		"upload":              cmdUpload,
+		"rollback":            cmdRollback,
+		"simulate":            cmdSimulate,
+		"admin-hooks":         cmdAdminHooks,
+		"restore-admin-hooks": cmdRestoreAdminHooks,
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "未知命令 %q\n\n", flag.Arg(0))
+		flag.Usage()
+		os.Exit(exitError)
+	}
+	cmd(c, flag.Args()[1:])
+}
+
+// newFlagSet 创建子命令的选项集合，positional 为位置参数的说明
+func newFlagSet(name, positional string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "用法: webhookctl %s %s\n", name, positional)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// requireArgs 检查位置参数的个数
+func requireArgs(fs *flag.FlagSet, args []string, n int) {
+	if len(args) != n {
+		fs.Usage()
+		os.Exit(exitError)
+	}
+}
+
+func cmdList(c *client, args []string) {
+	fs := newFlagSet("list", "[-json] [-file target]")
+	asJSON := fs.Bool("json", false, "Print the API response as JSON")
+	file := fs.String("file", "", "Only list hooks in this hooks file")
+	requireArgs(fs, parseArgs(fs, args), 0)
+
+	if *asJSON {
+		var raw []byte
+		check(c.call("GET /api/hooks", "", fileQuery(*file), nil, nil, &raw))
+		os.Stdout.Write(raw)
+		return
+	}
+	var hooks config.Config
+	check(c.call("GET /api/hooks", "", fileQuery(*file), nil, nil, &hooks))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tMETHODS\tCOMMAND")
+	for _, hook := range hooks {
+		methods := "*"
+		if len(hook.HTTPMethods) > 0 {
+			trimmed := make([]string, len(hook.HTTPMethods))
+			for i, m := range hook.HTTPMethods {
+				trimmed[i] = strings.TrimSpace(m)
+			}
+			methods = strings.Join(trimmed, ",")
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", hook.ID, methods, hook.ExecuteCommand)
+	}
+	w.Flush()
+}
+
+func cmdShow(c *client, args []string) {
+	fs := newFlagSet("show", "<id> [-json]")
+	asJSON := fs.Bool("json", false, "Print the API response as JSON")
+	positional := parseArgs(fs, args)
+	requireArgs(fs, positional, 1)
+
+	if *asJSON {
+		var raw []byte
+		check(c.call("GET /api/hooks/{id}", positional[0], nil, nil, nil, &raw))
+		os.Stdout.Write(raw)
+		return
+	}
+	var detail api.HookDetail
+	check(c.call("GET /api/hooks/{id}", positional[0], nil, nil, nil, &detail))
+	fmt.Printf("# 文件: %s\n", detail.File)
+	if detail.Revision != "" {
+		fmt.Printf("# 版本: %s\n", detail.Revision)
+	}
+	if d := detail.Disabled; d != nil {
+		fmt.Printf("# 已停用: %s，%s，原因: %s\n", d.By, d.Time.Local().Format("2006-01-02 15:04:05"), d.Reason)
+		fmt.Print(d.Fragment)
+		if !strings.HasSuffix(d.Fragment, "\n") {
+			fmt.Println()
+		}
+		return
+	}
+	data, err := config.MarshalFormat([]config.Hook{detail.Hook}, config.FormatYAML)
+	if err != nil {
+		fatal("无法序列化 hook: %v", err)
+	}
+	os.Stdout.Write(data)
+}
+
+// readLocal 读取本地文件，"-" 表示标准输入
+func readLocal(path string) []byte {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		fatal("无法读取 %s: %v", path, err)
+	}
+	return data
+}
+
+func cmdValidate(c *client, args []string) {
+	fs := newFlagSet("validate", "<file> [-file target] [-allow-admin-changes]")
+	file := fs.String("file", "", "Target hooks file the content is validated for")
+	allowAdmin := fs.Bool("allow-admin-changes", false, "Do not report removed or changed admin hooks")
+	positional := parseArgs(fs, args)
+	requireArgs(fs, positional, 1)
+
+	var result api.ValidateResult
+	check(c.call("POST /api/config/validate", "", saveQuery(*file, *allowAdmin), nil, bytes.NewReader(readLocal(positional[0])), &result))
+	fmt.Printf("%s: 校验通过（%s，%d 个 hook，目标 %s）\n", positional[0], result.Format, result.Hooks, result.File)
+}
+
+// current 读取目标 hooks 文件的当前内容
+func current(c *client, file string) api.ConfigContent {
+	var content api.ConfigContent
+	check(c.call("GET /api/config", "", fileQuery(file), nil, nil, &content))
+	return content
+}
+
+func cmdDiff(c *client, args []string) {
+	fs := newFlagSet("diff", "<file> [-file target]")
+	file := fs.String("file", "", "Target hooks file to compare with")
+	positional := parseArgs(fs, args)
+	requireArgs(fs, positional, 1)
+
+	cur := current(c, *file)
+	diff := unifiedDiff(cur.File, positional[0], cur.Content, string(readLocal(positional[0])))
+	if diff == "" {
+		return
+	}
+	fmt.Print(diff)
+	os.Exit(exitFailed)
+}
+
+// printSaved 打印保存或恢复的结果，新配置未生效时以 exitFailed 退出
+func printSaved(result api.SaveResult, err error) {
+	var apiErr *api.Error
+	if errors.As(err, &apiErr) && apiErr.Code == "reload_failed" {
+		fmt.Fprintf(os.Stderr, "%s: %s\n%s\n", result.File, result.Title, result.Message)
+		os.Exit(exitFailed)
+	}
+	check(err)
+	fmt.Printf("%s: %s\n", result.File, result.Title)
+	if result.Message != "" {
+		fmt.Println(result.Message)
+	}
+	if result.Backup != "" {
+		fmt.Printf("原内容已备份为版本 %s，可用 webhookctl rollback %s 恢复\n", result.Backup, result.Backup)
+	}
+}
+
+func cmdApply(c *client, args []string) {
+	fs := newFlagSet("apply", "<file> [-file target] [-force] [-allow-admin-changes]")
+	file := fs.String("file", "", "Target hooks file to save to")
+	force := fs.Bool("force", false, "Save and reload even if the content is unchanged")
+	allowAdmin := fs.Bool("allow-admin-changes", false, "Allow removing or changing the hooks used by the admin pages")
+	positional := parseArgs(fs, args)
+	requireArgs(fs, positional, 1)
+
+	content := readLocal(positional[0])
+	cur := current(c, *file)
+	diff := unifiedDiff(cur.File, positional[0], cur.Content, string(content))
+	if diff == "" && !*force {
+		fmt.Printf("%s: 内容没有变化，未保存\n", cur.File)
+		return
+	}
+	fmt.Print(diff)
+
+	var result api.SaveResult
+	err := c.call("PUT /api/config", "", saveQuery(cur.File, *allowAdmin), nil, bytes.NewReader(content), &result)
+	printSaved(result, err)
+}
+
+func cmdUpload(c *client, args []string) {
+	fs := newFlagSet("upload", "<path> [-name n] [-extract=false]")
+	name := fs.String("name", "", "File name in the upload directory, defaults to the base name of path")
+	extract := fs.Bool("extract", true, "Extract .tar.gz/.tgz/.zip archives into a directory of the same name")
+	positional := parseArgs(fs, args)
+	requireArgs(fs, positional, 1)
+
+	f, err := os.Open(positional[0])
+	if err != nil {
+		fatal("无法读取 %s: %v", positional[0], err)
+	}
+	defer f.Close()
+	if *name == "" {
+		*name = filepath.Base(positional[0])
+	}
+	query := url.Values{}
+	if !*extract {
+		query.Set("extract", "false")
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/octet-stream")
+	header.Set("X-File-Name", url.PathEscape(*name))
+
+	var stored upload.Stored
+	check(c.call("POST /api/uploads", "", query, header, f, &stored))
+	if stored.Extracted {
+		fmt.Printf("%s: 已解压到 %s（%d 个文件）\n", stored.Name, stored.Path, stored.Files)
+	} else {
+		fmt.Printf("%s: 已保存到 %s\n", stored.Name, stored.Path)
+	}
+	if !stored.Warning.IsZero() {
+		fmt.Fprintf(os.Stderr, "警告: %s\n", stored.Warning)
+	}
+}
+
+func cmdRollback(c *client, args []string) {
+	fs := newFlagSet("rollback", "[version] [-file target] [-allow-admin-changes]")
+	file := fs.String("file", "", "Target hooks file to roll back")
+	allowAdmin := fs.Bool("allow-admin-changes", false, "Allow restoring a version that removes or changes the admin hooks")
+	positional := parseArgs(fs, args)
+	if len(positional) > 1 {
+		fs.Usage()
+		os.Exit(exitError)
+	}
+
+	if len(positional) == 0 {
+		var versions []pipeline.Version
+		check(c.call("GET /api/config/versions", "", fileQuery(*file), nil, nil, &versions))
+		if len(versions) == 0 {
+			fmt.Println("没有历史版本")
+			return
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "VERSION\tTIME\tSIZE")
+		for _, v := range versions {
+			fmt.Fprintf(w, "%s\t%s\t%d\n", v.ID, v.Time.Local().Format("2006-01-02 15:04:05"), v.Size)
+		}
+		w.Flush()
+		return
+	}
+
+	query := saveQuery(*file, *allowAdmin)
+	query.Set("version", positional[0])
+	var result api.SaveResult
+	err := c.call("POST /api/config/rollback", "", query, nil, nil, &result)
+	printSaved(result, err)
+}
+
+// cmdAdminHooks 输出管理 hook 的配置片段，用于新安装或找回被误删的管理 hook，不需要访问 webhook
+func cmdAdminHooks(_ *client, args []string) {
+	fs := newFlagSet("admin-hooks", "[-scripts-dir DIR] [-prefix P] [-json]")
+	scriptsDir := fs.String("scripts-dir", adminhooks.DefaultScriptsDir, "Directory the scripts are installed in")
+	prefix := fs.String("prefix", "", "URL prefix of webhook (-urlprefix), passed to the scripts as URL_PREFIX; not set by default")
+	asJSON := fs.Bool("json", false, "Output JSON instead of YAML")
+	requireArgs(fs, parseArgs(fs, args), 0)
+
+	opts := adminhooks.Options{ScriptsDir: *scriptsDir}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "prefix" {
+			opts.URLPrefix = prefix
+		}
+	})
+	if *asJSON {
+		opts.Format = config.FormatJSON
+	}
+	data, err := adminhooks.Generate(opts)
+	if err != nil {
+		fatal("%v", err)
+	}
+	os.Stdout.Write(data)
+}
+
+// cmdRestoreAdminHooks 把保存时写入的恢复文件中的管理 hook 写回 hooks 文件。
+// 管理页面打不开时在服务器上以本地模式执行，不依赖 webhook 中的任何 hook
+func cmdRestoreAdminHooks(c *client, args []string) {
+	fs := newFlagSet("restore-admin-hooks", "[-file target]")
+	file := fs.String("file", "", "Target hooks file to restore the admin hooks into")
+	requireArgs(fs, parseArgs(fs, args), 0)
+
+	var result api.SaveResult
+	err := c.call("POST /api/admin-hooks/restore", "", fileQuery(*file), nil, nil, &result)
+	printSaved(result, err)
+}
+
+func cmdSimulate(c *client, args []string) {
+	fs := newFlagSet("simulate", "<id> -payload <json|@file> [options]")
+	payload := fs.String("payload", "", "Request body, or @file to read it from a file")
+	method := fs.String("method", http.MethodPost, "Request method")
+	contentType := fs.String("content-type", "", "Content-Type of the request, defaults to application/json when a payload is given")
+	remoteAddr := fs.String("remote-addr", "127.0.0.1:0", "Client address, used by ip-whitelist rules and source: request")
+	var headers, queries listFlag
+	fs.Var(&headers, "header", "Request header `Name: value`, can be repeated")
+	fs.Var(&queries, "query", "Query parameter `name=value`, can be repeated")
+	positional := parseArgs(fs, args)
+	requireArgs(fs, positional, 1)
+
+	var detail api.HookDetail
+	check(c.call("GET /api/hooks/{id}", positional[0], nil, nil, nil, &detail))
+
+	req := simulate.Request{Method: strings.ToUpper(*method), Headers: http.Header{}, Query: url.Values{}, RemoteAddr: *remoteAddr}
+	if strings.HasPrefix(*payload, "@") {
+		req.Body = readLocal((*payload)[1:])
+	} else {
+		req.Body = []byte(*payload)
+	}
+	for _, h := range headers {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			fatal("请求头 %q 应为 Name: value 格式", h)
+		}
+		req.Headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	for _, q := range queries {
+		name, value, _ := strings.Cut(q, "=")
+		req.Query.Add(name, value)
+	}
+	if *contentType != "" {
+		req.Headers.Set("Content-Type", *contentType)
+	} else if len(req.Body) > 0 && req.Headers.Get("Content-Type") == "" {
+		req.Headers.Set("Content-Type", "application/json")
+	}
+
+	if detail.Disabled != nil {
+		fmt.Printf("注意: hook 已停用，webhook 当前会返回 404（%s 停用，原因: %s）\n", detail.Disabled.By, detail.Disabled.Reason)
+	}
+	result := simulate.Run(detail.Hook, req)
+	printSimulation(detail.Hook, req, result)
+	if !result.Triggered {
+		os.Exit(exitFailed)
+	}
+}
+
+// mark 返回结果的标记
+func mark(ok bool) string {
+	if ok {
+		return "✔"
+	}
+	return "✘"
+}
+
+// printRule 缩进打印 trigger-rule 的计算结果
+func printRule(r *simulate.RuleResult, depth int) {
+	line := r.Kind
+	if r.Detail != "" {
+		line = r.Detail
+	}
+	fmt.Printf("%s%s %s", strings.Repeat("  ", depth+1), mark(r.Matched), line)
+	if r.Err != "" {
+		fmt.Printf("（%s）", r.Err)
+	}
+	fmt.Println()
+	for _, child := range r.Children {
+		printRule(child, depth+1)
+	}
+}
+
+func printSimulation(hook config.Hook, req simulate.Request, result simulate.Result) {
+	fmt.Printf("%s 请求方法 %s", mark(result.MethodAllowed), req.Method)
+	if !result.MethodAllowed {
+		fmt.Printf("，只允许 %s", strings.Join(hook.HTTPMethods, ","))
+	}
+	fmt.Println()
+	if result.MethodAllowed {
+		if result.Rule != nil {
+			fmt.Println("trigger-rule:")
+			printRule(result.Rule, 0)
+		} else {
+			fmt.Println("✔ 没有 trigger-rule")
+		}
+	}
+	for _, warning := range result.Warnings {
+		fmt.Printf("警告: %s\n", warning)
+	}
+
+	if result.Triggered {
+		fmt.Println("将执行:")
+		quoted := make([]string, len(result.Command))
+		for i, arg := range result.Command {
+			quoted[i] = shellQuote(arg)
+		}
+		fmt.Printf("  %s\n", strings.Join(quoted, " "))
+		if result.WorkingDirectory != "" {
+			fmt.Printf("工作目录: %s\n", result.WorkingDirectory)
+		}
+		if len(result.Env) > 0 {
+			fmt.Println("环境变量:")
+			for _, env := range result.Env {
+				fmt.Printf("  %s\n", env)
+			}
+		}
+		if len(result.Files) > 0 {
+			fmt.Println("临时文件:")
+			for _, f := range result.Files {
+				fmt.Printf("  %s（%d 字节）\n", f.EnvName, len(f.Content))
+			}
+		}
+	}
+
+	fmt.Printf("响应: %d %s", result.Status, http.StatusText(result.Status))
+	switch {
+	case result.Response != "":
+		fmt.Printf("，%s", result.Response)
+	case result.Triggered && hook.CaptureCommandOutput:
+		fmt.Print("，内容为命令输出")
+	}
+	fmt.Println()
+}
+
+// shellQuote 在需要时给参数加上单引号，便于复制到 shell 中执行
+func shellQuote(s string) string {
+	if s != "" && !strings.ContainsAny(s, " \t\n'\"\\$`*?[]{}()<>|&;#~!") {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"webhook-ui/common/adminhooks"
+	"webhook-ui/common/config"
+	"webhook-ui/common/reload"
+)
+
+// TestMain 在设置了 WEBHOOKCTL_TEST_MAIN 时作为 webhookctl 运行，供 run 在子进程中执行命令（命令出错时直接退出进程）
+func TestMain(m *testing.M) {
+	if os.Getenv("WEBHOOKCTL_TEST_MAIN") != "" {
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+const sampleHooks = "- id: deploy\n  execute-command: /bin/true\n  http-methods: [POST]\n- id: build\n  execute-command: /usr/local/bin/build\n"
+
+// setupLocal 在临时目录中写入 content 作为 hooks 文件，用模拟的 webhook 服务（提供文件中当前的 hook）完成重载和探测，
+// 返回 hooks 文件的路径
+func setupLocal(t *testing.T, content string) string {
+	work := t.TempDir()
+	path := filepath.Join(work, "hooks.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := os.ReadFile(path)
+		hooks, _ := config.Parse(data)
+		if r.Method == reload.ProbeMethod && hooks.Find(strings.TrimPrefix(r.URL.Path, "/hooks/")) != nil {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(server.Close)
+	t.Setenv("WEBHOOKCTL_URL", "")
+	t.Setenv("WEBHOOK_URL", server.URL+"/hooks")
+	t.Setenv("RELOAD_METHOD", "command")
+	t.Setenv("RELOAD_COMMAND", "true")
+	t.Setenv("RELOAD_CONFIRM_TIMEOUT", "500ms")
+	t.Setenv("BACKUP_DIR", filepath.Join(work, "backup"))
+	t.Setenv("ADMIN_RECOVERY_FILE", filepath.Join(work, "recovery.yaml"))
+	t.Setenv("SCRIPTS_DIR", adminhooks.DefaultScriptsDir)
+	return path
+}
+
+// run 在子进程中以本地模式执行 webhookctl -hooks hooks args...，返回标准输出和退出码
+func run(t *testing.T, hooks string, args ...string) (string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], append([]string{"-hooks", hooks, "-upload-dir", filepath.Join(filepath.Dir(hooks), "upload")}, args...)...)
+	cmd.Env = append(os.Environ(), "WEBHOOKCTL_TEST_MAIN=1")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatalf("无法执行 %v: %v", args, err)
+	}
+	if stderr.Len() > 0 {
+		t.Logf("%v 的错误输出:\n%s", args, stderr.String())
+	}
+	return stdout.String(), cmd.ProcessState.ExitCode()
+}
+
+// writeFile 在 hooks 文件旁写入 name，返回路径
+func writeFile(t *testing.T, hooks, name, content string) string {
+	t.Helper()
+	path := filepath.Join(filepath.Dir(hooks), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestList(t *testing.T) {
+	hooks := setupLocal(t, sampleHooks)
+	out, code := run(t, hooks, "list")
+	if code != 0 {
+		t.Fatalf("list 退出码为 %d", code)
+	}
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	want := [][]string{{"ID", "METHODS", "COMMAND"}, {"deploy", "POST", "/bin/true"}, {"build", "*", "/usr/local/bin/build"}}
+	if len(lines) != len(want) {
+		t.Fatalf("list 输出为:\n%s", out)
+	}
+	for i, fields := range want {
+		if got := strings.Fields(lines[i]); strings.Join(got, " ") != strings.Join(fields, " ") {
+			t.Errorf("第 %d 行为 %q，应为 %q", i+1, lines[i], strings.Join(fields, "  "))
+		}
+	}
+
+	if _, code := run(t, hooks, "list", "-file", "/etc/passwd"); code != exitFailed {
+		t.Errorf("列出不存在的文件时退出码为 %d，应为 %d", code, exitFailed)
+	}
+}
+
+func TestShow(t *testing.T) {
+	hooks := setupLocal(t, sampleHooks)
+	out, code := run(t, hooks, "show", "deploy")
+	if code != 0 || !strings.HasPrefix(out, "# 文件: "+hooks+"\n") {
+		t.Fatalf("show 退出码为 %d，输出为:\n%s", code, out)
+	}
+	shown, err := config.Parse([]byte(out))
+	if err != nil || len(shown) != 1 || shown[0].ID != "deploy" || shown[0].ExecuteCommand != "/bin/true" {
+		t.Errorf("show 输出的不是 deploy (%v):\n%s", err, out)
+	}
+
+	if out, code := run(t, hooks, "show", "missing"); code != exitFailed || out != "" {
+		t.Errorf("hook 不存在时退出码为 %d，输出为 %q", code, out)
+	}
+	if _, code := run(t, hooks, "show"); code != exitError {
+		t.Errorf("缺少 id 时退出码为 %d，应为 %d", code, exitError)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	hooks := setupLocal(t, sampleHooks)
+	same := writeFile(t, hooks, "same.yaml", sampleHooks)
+	if out, code := run(t, hooks, "diff", same); code != 0 || out != "" {
+		t.Errorf("内容相同时退出码为 %d，输出为:\n%s", code, out)
+	}
+
+	changed := writeFile(t, hooks, "changed.yaml", strings.Replace(sampleHooks, "/bin/true", "/bin/false", 1))
+	out, code := run(t, hooks, "diff", changed)
+	if code != exitFailed {
+		t.Errorf("内容不同时退出码为 %d，应为 %d", code, exitFailed)
+	}
+	for _, line := range []string{"--- " + hooks, "+++ " + changed, "-  execute-command: /bin/true", "+  execute-command: /bin/false"} {
+		if !strings.Contains(out, line+"\n") {
+			t.Errorf("diff 输出中没有 %q:\n%s", line, out)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	hooks := setupLocal(t, sampleHooks)
+	same := writeFile(t, hooks, "same.yaml", sampleHooks)
+	if out, code := run(t, hooks, "apply", same); code != 0 || out != hooks+": 内容没有变化，未保存\n" {
+		t.Errorf("内容相同时退出码为 %d，输出为:\n%s", code, out)
+	}
+
+	updated := sampleHooks + "- id: test\n  execute-command: /bin/true\n  http-methods: [POST]\n"
+	out, code := run(t, hooks, "apply", writeFile(t, hooks, "updated.yaml", updated))
+	if code != 0 {
+		t.Fatalf("apply 退出码为 %d，输出为:\n%s", code, out)
+	}
+	if !strings.Contains(out, "+- id: test\n") || !strings.Contains(out, "原内容已备份为版本 ") {
+		t.Errorf("apply 输出中没有差异或备份版本:\n%s", out)
+	}
+	if data, _ := os.ReadFile(hooks); string(data) != updated {
+		t.Errorf("apply 后 hooks 文件为:\n%s", data)
+	}
+
+	// 无法解析的内容不会写入
+	if _, code := run(t, hooks, "apply", writeFile(t, hooks, "broken.yaml", "- id: [")); code != exitFailed {
+		t.Errorf("无法解析的配置退出码为 %d，应为 %d", code, exitFailed)
+	}
+	if data, _ := os.ReadFile(hooks); string(data) != updated {
+		t.Errorf("无法解析的配置写入了 hooks 文件:\n%s", data)
+	}
+}